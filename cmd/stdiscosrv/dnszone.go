@@ -0,0 +1,83 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/discover"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// The TTL of the generated records. The zone is meant to be regenerated
+// about as often, so that the addresses don't go stale for long.
+const dnsZoneTTL = 5 * time.Minute
+
+// The longest string allowed in a TXT record. Longer ones are split into
+// several strings, which resolvers join together again.
+const maxTXTStringLen = 255
+
+// writeDNSZone writes the current addresses of the devices in the database
+// as TXT records under the domain, in zone file format, to be served by a
+// DNS server and looked up by the DNS discovery of Syncthing.
+func writeDNSZone(w io.Writer, s *levelDBStore, domain string) error {
+	bw := bufio.NewWriter(w)
+	now := s.clock.Now().UnixNano()
+	ttl := int(dnsZoneTTL / time.Second)
+
+	fmt.Fprintf(bw, "; Syncthing device addresses under %s, generated by %s\n", domain, LongVersion)
+
+	iter := s.db.NewIterator(&util.Range{}, nil)
+	defer iter.Release()
+	for iter.Next() {
+		device, err := protocol.DeviceIDFromString(string(iter.Key()))
+		if err != nil {
+			continue
+		}
+		var rec DatabaseRecord
+		if err := rec.Unmarshal(iter.Value()); err != nil {
+			continue
+		}
+
+		addrs := expire(rec.Addresses, now)
+		sort.Slice(addrs, func(i, j int) bool {
+			return addrs[i].Address < addrs[j].Address
+		})
+		name := discover.DNSName(device, domain) + "."
+		for _, addr := range addrs {
+			fmt.Fprintf(bw, "%s %d IN TXT %s\n", name, ttl, txtStrings("addr="+addr.Address))
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// txtStrings returns the value as the quoted strings of a TXT record.
+func txtStrings(val string) string {
+	var strs []string
+	for len(val) > maxTXTStringLen {
+		strs = append(strs, quoteTXT(val[:maxTXTStringLen]))
+		val = val[maxTXTStringLen:]
+	}
+	strs = append(strs, quoteTXT(val))
+	return strings.Join(strs, " ")
+}
+
+func quoteTXT(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteDNSZone(t *testing.T) {
+	os.RemoveAll("_database")
+	defer os.RemoveAll("_database")
+	db, err := newLevelDBStore("_database")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go db.Serve()
+	defer db.Stop()
+
+	tc := &testClock{time.Now()}
+	db.clock = tc
+
+	rec := DatabaseRecord{
+		Addresses: []DatabaseAddress{
+			{Address: "tcp://192.0.2.42:22000", Expires: tc.Now().Add(time.Hour).UnixNano()},
+			{Address: "tcp://192.0.2.43:22000", Expires: tc.Now().Add(-time.Hour).UnixNano()},
+			{Address: `relay://192.0.2.44:22067/?id="x"`, Expires: tc.Now().Add(time.Hour).UnixNano()},
+		},
+	}
+	if err := db.put("AIR6LPZ-7K4PTTV-UXQSMUU-CPQ5YWH-OEDFIIQ-JUG777G-2YQXXR5-YD6AWQR", rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.put("notadevice", rec); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeDNSZone(&buf, db, "sync.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{
+		`air6lpz-7k4pttv-uxqsmuu-cpq5ywh-oedfiiq-jug777g-2yqxxr5-yd6awqr.sync.example.com. 300 IN TXT "addr=relay://192.0.2.44:22067/?id=\"x\""`,
+		`air6lpz-7k4pttv-uxqsmuu-cpq5ywh-oedfiiq-jug777g-2yqxxr5-yd6awqr.sync.example.com. 300 IN TXT "addr=tcp://192.0.2.42:22000"`,
+	}
+	if len(lines) != len(expected)+1 || !strings.HasPrefix(lines[0], ";") {
+		t.Fatalf("Unexpected zone:\n%s", buf.String())
+	}
+	for i, line := range lines[1:] {
+		if line != expected[i] {
+			t.Errorf("Unexpected record\n%s\nexpected\n%s", line, expected[i])
+		}
+	}
+}
+
+func TestTXTStrings(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	if s := txtStrings(long); s != `"`+long[:255]+`" "`+long[255:]+`"` {
+		t.Error("Long value not split:", s)
+	}
+}
@@ -96,6 +96,7 @@ func main() {
 	var certFile string
 	var keyFile string
 	var useHTTP bool
+	var dnsZone string
 
 	log.SetOutput(os.Stdout)
 	log.SetFlags(0)
@@ -103,6 +104,7 @@ func main() {
 	flag.StringVar(&certFile, "cert", "./cert.pem", "Certificate file")
 	flag.StringVar(&dir, "db-dir", "./discovery.db", "Database directory")
 	flag.BoolVar(&debug, "debug", false, "Print debug output")
+	flag.StringVar(&dnsZone, "dns-zone", "", "Write the addresses in the database as DNS records under this domain, and exit")
 	flag.BoolVar(&useHTTP, "http", false, "Listen on HTTP (behind an HTTPS proxy)")
 	flag.StringVar(&listen, "listen", ":8443", "Listen address")
 	flag.StringVar(&keyFile, "key", "./key.pem", "Key file")
@@ -111,6 +113,20 @@ func main() {
 	flag.StringVar(&replicationListen, "replication-listen", ":19200", "Replication listen address")
 	flag.Parse()
 
+	if dnsZone != "" {
+		// Write the zone for DNS discovery to stdout instead of serving.
+		// The database can't be in use by a running server meanwhile, so
+		// this is usually done on a copy of it.
+		db, err := newLevelDBStore(dir)
+		if err != nil {
+			log.Fatalln("Open database:", err)
+		}
+		if err := writeDNSZone(os.Stdout, db, dnsZone); err != nil {
+			log.Fatalln("Writing DNS zone:", err)
+		}
+		return
+	}
+
 	log.Println(LongVersion)
 
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
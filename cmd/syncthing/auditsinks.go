@@ -0,0 +1,486 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/sync"
+	"github.com/thejerf/suture"
+)
+
+// The schema version of audit records. Fields may be added to the record
+// without changing it, but not changed or removed.
+const auditSchemaVersion = 1
+
+// An auditRecord is the form in which events are written by audit sinks in
+// the JSON format.
+type auditRecord struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Time          time.Time        `json:"time"`
+	GlobalID      int              `json:"globalID"`
+	Type          events.EventType `json:"type"`
+	Device        string           `json:"device"` // the device logging the event
+	Data          interface{}      `json:"data"`
+}
+
+func auditMessage(ev events.Event, format string) ([]byte, error) {
+	if format == config.AuditFormatRaw {
+		return json.Marshal(ev)
+	}
+	return json.Marshal(auditRecord{
+		SchemaVersion: auditSchemaVersion,
+		Time:          ev.Time,
+		GlobalID:      ev.GlobalID,
+		Type:          ev.Type,
+		Device:        myID.String(),
+		Data:          ev.Data,
+	})
+}
+
+// The auditSinkService runs an auditSink for each enabled audit sink
+// configuration, and restarts them as the configuration changes. Relative
+// file paths are taken to be relative to dir.
+type auditSinkService struct {
+	*suture.Supervisor
+	dir    string
+	mut    sync.Mutex
+	sinks  map[string]*auditSink
+	tokens map[string]suture.ServiceToken
+}
+
+func newAuditSinkService(cfg *config.Wrapper, dir string) *auditSinkService {
+	s := &auditSinkService{
+		Supervisor: suture.New("auditSinkService", suture.Spec{
+			Log: func(line string) {
+				l.Infoln(line)
+			},
+			PassThroughPanics: true,
+		}),
+		dir:    dir,
+		mut:    sync.NewMutex(),
+		sinks:  make(map[string]*auditSink),
+		tokens: make(map[string]suture.ServiceToken),
+	}
+	s.CommitConfiguration(config.Configuration{}, cfg.RawCopy())
+	cfg.Subscribe(s)
+	return s
+}
+
+func (s *auditSinkService) VerifyConfiguration(from, to config.Configuration) error {
+	return nil
+}
+
+func (s *auditSinkService) CommitConfiguration(from, to config.Configuration) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	wanted := make(map[string]config.AuditSinkConfiguration, len(to.AuditSinks))
+	for _, sinkCfg := range to.AuditSinks {
+		if sinkCfg.Type == config.AuditSinkFile && !filepath.IsAbs(sinkCfg.Path) {
+			sinkCfg.Path = filepath.Join(s.dir, sinkCfg.Path)
+		}
+		wanted[sinkCfg.ID] = sinkCfg
+	}
+
+	for id, sink := range s.sinks {
+		if sinkCfg, ok := wanted[id]; ok && sinkCfg.Enabled && reflect.DeepEqual(sinkCfg, sink.cfg) {
+			continue
+		}
+		s.RemoveAndWait(s.tokens[id], 10*time.Second)
+		delete(s.sinks, id)
+		delete(s.tokens, id)
+	}
+
+	for id, sinkCfg := range wanted {
+		if _, ok := s.sinks[id]; ok || !sinkCfg.Enabled {
+			continue
+		}
+		sink := newAuditSink(sinkCfg)
+		s.sinks[id] = sink
+		s.tokens[id] = s.Add(sink)
+	}
+
+	return true
+}
+
+func (s *auditSinkService) String() string {
+	return fmt.Sprintf("auditSinkService@%p", s)
+}
+
+// An auditWriter writes events to a destination.
+type auditWriter interface {
+	writeEvent(ev events.Event) error
+	Close() error
+}
+
+// An auditSink writes the configured event types to an auditWriter.
+type auditSink struct {
+	cfg  config.AuditSinkConfiguration
+	stop chan struct{}
+}
+
+func newAuditSink(cfg config.AuditSinkConfiguration) *auditSink {
+	return &auditSink{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+	}
+}
+
+func (s *auditSink) Serve() {
+	var w auditWriter
+	switch s.cfg.Type {
+	case config.AuditSinkSyslog:
+		w = newSyslogAuditWriter(s.cfg)
+	default:
+		w = &fileAuditWriter{
+			file:   newRotatingFile(s.cfg),
+			format: s.cfg.Format,
+		}
+	}
+	defer w.Close()
+
+	sub := events.Default.Subscribe(s.cfg.EventMask())
+	defer events.Default.Unsubscribe(sub)
+
+	failing := false
+	for {
+		select {
+		case ev := <-sub.C():
+			if err := w.writeEvent(ev); err != nil {
+				// Warn once per streak of failures, not for every event.
+				if !failing {
+					l.Warnf("Audit sink %q: %v", s.cfg.ID, err)
+					failing = true
+				} else {
+					l.Debugf("audit sink %q: %v", s.cfg.ID, err)
+				}
+			} else if failing {
+				l.Infof("Audit sink %q: writing events again", s.cfg.ID)
+				failing = false
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *auditSink) Stop() {
+	close(s.stop)
+}
+
+func (s *auditSink) String() string {
+	return fmt.Sprintf("auditSink@%p(%s)", s, s.cfg.ID)
+}
+
+type fileAuditWriter struct {
+	file   *rotatingFile
+	format string
+}
+
+func (w *fileAuditWriter) writeEvent(ev events.Event) error {
+	bs, err := auditMessage(ev, w.format)
+	if err != nil {
+		return err
+	}
+	_, err = w.file.Write(append(bs, '\n'))
+	return err
+}
+
+func (w *fileAuditWriter) Close() error {
+	return w.file.Close()
+}
+
+// A rotatingFile appends to the file at path. Before a write would make the
+// file larger than maxSize, or when the current interval has passed since
+// the file was started, the file is renamed with a timestamp suffix and
+// optionally compressed, and a new one is started. Only the newest maxFiles
+// rotated files are kept.
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	interval time.Duration
+	maxFiles int
+	compress bool
+
+	fd     *os.File
+	size   int64
+	period time.Time // start of the interval the file was started in
+}
+
+func newRotatingFile(cfg config.AuditSinkConfiguration) *rotatingFile {
+	return &rotatingFile{
+		path:     cfg.Path,
+		maxSize:  int64(cfg.MaxSizeMiB) << 20,
+		interval: time.Duration(cfg.RotateIntervalH) * time.Hour,
+		maxFiles: cfg.MaxFiles,
+		compress: cfg.Compress,
+	}
+}
+
+func (f *rotatingFile) Write(bs []byte) (int, error) {
+	if f.fd == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.needsRotation(len(bs)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.fd.Write(bs)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) Close() error {
+	if f.fd == nil {
+		return nil
+	}
+	err := f.fd.Close()
+	f.fd = nil
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	fd, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := fd.Stat()
+	if err != nil {
+		fd.Close()
+		return err
+	}
+
+	f.fd = fd
+	f.size = info.Size()
+	// An existing file belongs to the interval it was last written in.
+	started := time.Now()
+	if f.size > 0 {
+		started = info.ModTime()
+	}
+	f.period = f.periodOf(started)
+	return nil
+}
+
+func (f *rotatingFile) periodOf(t time.Time) time.Time {
+	if f.interval <= 0 {
+		return time.Time{}
+	}
+	return t.Truncate(f.interval)
+}
+
+func (f *rotatingFile) needsRotation(n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+int64(n) > f.maxSize {
+		return true
+	}
+	return f.interval > 0 && !f.periodOf(time.Now()).Equal(f.period)
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	rotated := f.path + "." + time.Now().Format("20060102-150405.000")
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+	if f.compress {
+		if err := gzipFile(rotated); err != nil {
+			l.Infoln("Compressing rotated audit log:", err)
+		}
+	}
+
+	// Remove the oldest rotated files. The timestamp suffixes sort in
+	// chronological order.
+	names, _ := filepath.Glob(globEscape(f.path) + ".*")
+	sort.Strings(names)
+	for len(names) > f.maxFiles {
+		os.Remove(names[0])
+		names = names[1:]
+	}
+	return nil
+}
+
+// gzipFile replaces the file at path with a compressed copy at path.gz.
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(dst)
+	_, err = io.Copy(gw, src)
+	if cerr := gw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
+
+func globEscape(s string) string {
+	for _, c := range []string{"\\", "[", "]", "*", "?"} {
+		s = strings.Replace(s, c, "\\"+c, -1)
+	}
+	return s
+}
+
+// Local syslog sockets, in order of preference.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+const (
+	syslogSeverityWarning = 4
+	syslogSeverityNotice  = 5
+	syslogSeverityInfo    = 6
+)
+
+var errNoSyslog = errors.New("no local syslog socket found")
+
+// A syslogAuditWriter sends events as RFC 5424 messages to a local syslog
+// socket or over UDP. The message is the audit record in JSON.
+type syslogAuditWriter struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	conn     net.Conn
+}
+
+func newSyslogAuditWriter(cfg config.AuditSinkConfiguration) *syslogAuditWriter {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &syslogAuditWriter{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: cfg.FacilityCode(),
+		appName:  cfg.AppName,
+		hostname: strings.Replace(hostname, " ", "_", -1),
+	}
+}
+
+func (w *syslogAuditWriter) writeEvent(ev events.Event) error {
+	msg, err := auditMessage(ev, config.AuditFormatJSON)
+	if err != nil {
+		return err
+	}
+
+	if w.conn == nil {
+		if err := w.dial(); err != nil {
+			return err
+		}
+	}
+
+	bs := syslogMessage(w.facility*8+syslogSeverity(ev), ev.Time, w.hostname, w.appName, ev.Type.String(), msg)
+	if w.network == "unix" {
+		// Stream sockets need the messages to be delimited.
+		bs = append(bs, '\n')
+	}
+	if _, err := w.conn.Write(bs); err != nil {
+		// Reconnect on the next event.
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (w *syslogAuditWriter) dial() error {
+	if w.network != "" {
+		conn, err := net.Dial(w.network, w.address)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		return nil
+	}
+
+	addrs := syslogSockets
+	if w.address != "" {
+		addrs = []string{w.address}
+	}
+	for _, addr := range addrs {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, addr); err == nil {
+				w.conn = conn
+				w.network = network
+				return nil
+			}
+		}
+	}
+	return errNoSyslog
+}
+
+func (w *syslogAuditWriter) Close() error {
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// syslogMessage formats an RFC 5424 message without structured data.
+func syslogMessage(pri int, t time.Time, hostname, appName, msgID string, msg []byte) []byte {
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ", pri, t.Format("2006-01-02T15:04:05.000000Z07:00"), hostname, appName, os.Getpid(), msgID)
+	return append([]byte(header), msg...)
+}
+
+// syslogSeverity returns the severity for the event: failed logins are
+// warnings and rejected devices and folders notices.
+func syslogSeverity(ev events.Event) int {
+	switch ev.Type {
+	case events.LoginAttempt:
+		if data, ok := ev.Data.(map[string]interface{}); ok && data["success"] == false {
+			return syslogSeverityWarning
+		}
+	case events.DeviceRejected, events.FolderRejected:
+		return syslogSeverityNotice
+	}
+	return syslogSeverityInfo
+}
@@ -0,0 +1,190 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-audit-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	f := &rotatingFile{
+		path:     path,
+		maxSize:  10,
+		maxFiles: 2,
+		compress: true,
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		// Keep the rotated names apart
+		time.Sleep(2 * time.Millisecond)
+	}
+	f.Close()
+
+	if bs, err := ioutil.ReadFile(path); err != nil || string(bs) != "fourth\n" {
+		t.Errorf("Unexpected current file %q, %v", bs, err)
+	}
+
+	// The first rotated file has been removed, the other two compressed.
+	rotated, _ := filepath.Glob(path + ".*.gz")
+	if len(rotated) != 2 {
+		t.Fatalf("Unexpected rotated files %v", rotated)
+	}
+	for i, expected := range []string{"second\n", "third\n"} {
+		fd, err := os.Open(rotated[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		gr, err := gzip.NewReader(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := ioutil.ReadAll(gr)
+		fd.Close()
+		if err != nil || string(bs) != expected {
+			t.Errorf("Unexpected rotated file %s: %q, %v", rotated[i], bs, err)
+		}
+	}
+
+	// A file from a previous interval is rotated before writing.
+	f = &rotatingFile{
+		path:     path,
+		interval: time.Hour,
+		maxFiles: 10,
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("fifth\n")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if bs, err := ioutil.ReadFile(path); err != nil || string(bs) != "fifth\n" {
+		t.Errorf("Unexpected current file %q, %v", bs, err)
+	}
+}
+
+func TestAuditSinkFilter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-audit-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	sink := newAuditSink(config.AuditSinkConfiguration{
+		ID:     "test",
+		Type:   config.AuditSinkFile,
+		Format: config.AuditFormatJSON,
+		Events: []string{"LoginAttempt"},
+		Path:   path,
+	})
+	done := make(chan struct{})
+	go func() {
+		sink.Serve()
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond) // let Serve subscribe
+
+	events.Default.Log(events.ConfigSaved, "ignored")
+	events.Default.Log(events.LoginAttempt, map[string]interface{}{"success": false, "username": "audited"})
+	waitFor(t, func() bool {
+		bs, _ := ioutil.ReadFile(path)
+		return len(bs) > 0
+	})
+	sink.Stop()
+	<-done
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Unexpected audit log %q", bs)
+	}
+	var rec auditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.SchemaVersion != auditSchemaVersion || rec.Type != events.LoginAttempt || rec.Data.(map[string]interface{})["username"] != "audited" {
+		t.Errorf("Unexpected audit record %+v", rec)
+	}
+}
+
+func TestSyslogAuditWriter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	cfg := config.AuditSinkConfiguration{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: "authpriv",
+		AppName:  "syncthing",
+	}
+	w := newSyslogAuditWriter(cfg)
+	defer w.Close()
+
+	ev := events.Event{
+		GlobalID: 42,
+		Time:     time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+		Type:     events.LoginAttempt,
+		Data:     map[string]interface{}{"success": false, "username": "someone"},
+	}
+	if err := w.writeEvent(ev); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := buf[:n]
+
+	// authpriv (10) * 8 + warning (4)
+	prefix := "<84>1 2019-10-01T12:00:00.000000Z " + w.hostname + " syncthing "
+	if !bytes.HasPrefix(msg, []byte(prefix)) {
+		t.Fatalf("Unexpected message %q", msg)
+	}
+	fields := strings.SplitN(string(msg[len(prefix):]), " ", 4)
+	if len(fields) != 4 || fields[1] != "LoginAttempt" || fields[2] != "-" {
+		t.Fatalf("Unexpected message %q", msg)
+	}
+	var rec auditRecord
+	if err := json.Unmarshal([]byte(fields[3]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.SchemaVersion != auditSchemaVersion || rec.GlobalID != 42 {
+		t.Errorf("Unexpected audit record %+v", rec)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/tls"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/locations"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// exportIndex writes the index state of the folder given by -index-folder
+// to the file given by -export-index.
+func exportIndex(options RuntimeOptions) error {
+	_, ldb, err := openIndexFolder(options)
+	if err != nil {
+		return err
+	}
+	defer ldb.Close()
+
+	fd, err := osutil.CreateAtomic(options.exportIndex)
+	if err != nil {
+		return err
+	}
+	if err := db.ExportFolder(fd, ldb, options.indexFolder, myID); err != nil {
+		fd.Close()
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+
+	l.Infof("Exported index of folder %q to %s", options.indexFolder, options.exportIndex)
+	return nil
+}
+
+// importIndex replaces the index state of the folder given by -index-folder
+// with that in the file given by -import-index, validating it against the
+// files in the folder.
+func importIndex(options RuntimeOptions) error {
+	folderCfg, ldb, err := openIndexFolder(options)
+	if err != nil {
+		return err
+	}
+	defer ldb.Close()
+
+	fd, err := os.Open(options.importIndex)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	res, err := db.ImportFolder(fd, ldb, options.indexFolder, myID, folderCfg.Filesystem())
+	if err != nil {
+		return err
+	}
+
+	l.Infof("Imported index of folder %q with %d local and %d remote files from %d devices", options.indexFolder, res.LocalFiles, res.RemoteFiles, res.Devices)
+	if res.MustRescan > 0 {
+		l.Infof("%d local files differ from the files on disk and will be rescanned", res.MustRescan)
+	}
+	return nil
+}
+
+func openIndexFolder(options RuntimeOptions) (config.FolderConfiguration, *db.Lowlevel, error) {
+	if options.indexFolder == "" {
+		return config.FolderConfiguration{}, nil, errors.New("no folder given, use -index-folder")
+	}
+
+	cert, err := tls.LoadX509KeyPair(
+		locations.Get(locations.CertFile),
+		locations.Get(locations.KeyFile),
+	)
+	if err != nil {
+		return config.FolderConfiguration{}, nil, err
+	}
+	myID = protocol.NewDeviceID(cert.Certificate[0])
+
+	cfg, err := config.Load(locations.Get(locations.ConfigFile), myID)
+	if err != nil {
+		return config.FolderConfiguration{}, nil, err
+	}
+	folderCfg, ok := cfg.Folder(options.indexFolder)
+	if !ok {
+		return config.FolderConfiguration{}, nil, errors.Errorf("folder %q does not exist", options.indexFolder)
+	}
+
+	ldb, err := openDatabase(locations.Get(locations.Database), options.dbBackend)
+	if err != nil {
+		return config.FolderConfiguration{}, nil, err
+	}
+	return folderCfg, ldb, nil
+}
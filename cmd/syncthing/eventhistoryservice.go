@@ -0,0 +1,373 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+const (
+	eventHistoryDayFormat  = "20060102"
+	eventHistoryFileSuffix = ".json"
+	eventHistoryExpiry     = time.Hour
+)
+
+// An eventHistoryEntry is an event as kept in the event history, with the
+// folder, device and path it concerns lifted out of the event data to
+// filter on.
+type eventHistoryEntry struct {
+	GlobalID int              `json:"globalID"`
+	Time     time.Time        `json:"time"`
+	Type     events.EventType `json:"type"`
+	Folder   string           `json:"folder,omitempty"`
+	Device   string           `json:"device,omitempty"`
+	Path     string           `json:"path,omitempty"`
+	Data     interface{}      `json:"data"`
+}
+
+func newEventHistoryEntry(ev events.Event) eventHistoryEntry {
+	field := func(key string) string {
+		switch data := ev.Data.(type) {
+		case map[string]string:
+			return data[key]
+		case map[string]interface{}:
+			if s, ok := data[key].(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+
+	e := eventHistoryEntry{
+		GlobalID: ev.GlobalID,
+		Time:     ev.Time,
+		Type:     ev.Type,
+		Folder:   field("folder"),
+		Device:   field("device"),
+		Path:     field("path"),
+		Data:     ev.Data,
+	}
+	if e.Path == "" {
+		e.Path = field("item")
+	}
+	e.Path = filepath.ToSlash(e.Path)
+
+	switch ev.Type {
+	case events.DeviceConnected, events.DeviceDisconnected:
+		e.Device = field("id")
+	case events.FolderPaused, events.FolderResumed:
+		e.Folder = field("id")
+	case events.RemoteChangeDetected:
+		e.Device = field("modifiedBy")
+	}
+
+	return e
+}
+
+// An eventHistoryQuery selects entries from the event history. Zero values
+// match anything.
+type eventHistoryQuery struct {
+	Folder  string
+	Folders []string // only events about these folders or none, if set
+	Device  protocol.DeviceID
+	Prefix  string
+	Mask    events.EventType
+	From    time.Time
+	To      time.Time
+	Page    int
+	PerPage int
+}
+
+func (q eventHistoryQuery) matches(e eventHistoryEntry) bool {
+	switch {
+	case q.Mask != 0 && q.Mask&e.Type == 0:
+		return false
+	case q.Folder != "" && q.Folder != e.Folder:
+		return false
+	case len(q.Folders) > 0 && e.Folder != "" && !stringInSlice(e.Folder, q.Folders):
+		return false
+	case q.Device != protocol.EmptyDeviceID && e.Device != q.Device.String() && e.Device != q.Device.Short().String():
+		return false
+	case q.Prefix != "" && !strings.HasPrefix(e.Path, q.Prefix):
+		return false
+	case !q.From.IsZero() && e.Time.Before(q.From):
+		return false
+	case !q.To.IsZero() && e.Time.After(q.To):
+		return false
+	}
+	return true
+}
+
+type eventHistoryResult struct {
+	Events  []eventHistoryEntry `json:"events"`
+	Total   int                 `json:"total"`
+	Page    int                 `json:"page"`
+	PerPage int                 `json:"perpage"`
+}
+
+// The eventHistoryService records the event types given by the
+// EventHistoryTypes option to one file per day in dir, and removes the
+// files older than EventHistoryDays.
+type eventHistoryService struct {
+	dir     string
+	mut     sync.Mutex
+	mask    events.EventType
+	days    int
+	fd      *os.File
+	fdDay   string
+	changed chan struct{}
+	stop    chan struct{}
+}
+
+func newEventHistoryService(cfg *config.Wrapper, dir string) *eventHistoryService {
+	s := &eventHistoryService{
+		dir:     dir,
+		mut:     sync.NewMutex(),
+		changed: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	s.CommitConfiguration(config.Configuration{}, cfg.RawCopy())
+	cfg.Subscribe(s)
+	return s
+}
+
+func (s *eventHistoryService) Serve() {
+	expire := time.NewTicker(eventHistoryExpiry)
+	defer expire.Stop()
+	s.expire(time.Now())
+
+	var sub *events.Subscription
+	defer func() {
+		if sub != nil {
+			events.Default.Unsubscribe(sub)
+		}
+		s.mut.Lock()
+		s.closeLocked()
+		s.mut.Unlock()
+	}()
+
+	for {
+		s.mut.Lock()
+		mask := s.mask
+		s.mut.Unlock()
+
+		var evs <-chan events.Event
+		if mask != 0 {
+			sub = events.Default.Subscribe(mask)
+			evs = sub.C()
+		}
+
+	loop:
+		for {
+			select {
+			case ev := <-evs:
+				s.record(ev)
+			case <-expire.C:
+				s.expire(time.Now())
+			case <-s.changed:
+				break loop
+			case <-s.stop:
+				return
+			}
+		}
+
+		if sub != nil {
+			events.Default.Unsubscribe(sub)
+			sub = nil
+		}
+	}
+}
+
+func (s *eventHistoryService) Stop() {
+	close(s.stop)
+}
+
+func (s *eventHistoryService) String() string {
+	return fmt.Sprintf("eventHistoryService@%p", s)
+}
+
+func (s *eventHistoryService) VerifyConfiguration(from, to config.Configuration) error {
+	return nil
+}
+
+func (s *eventHistoryService) CommitConfiguration(from, to config.Configuration) bool {
+	var mask events.EventType
+	if to.Options.EventHistoryDays > 0 {
+		for _, name := range to.Options.EventHistoryTypes {
+			mask |= events.UnmarshalEventType(strings.TrimSpace(name))
+		}
+	}
+
+	s.mut.Lock()
+	changed := mask != s.mask
+	s.mask = mask
+	s.days = to.Options.EventHistoryDays
+	s.mut.Unlock()
+
+	if changed {
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+func (s *eventHistoryService) record(ev events.Event) {
+	bs, err := json.Marshal(newEventHistoryEntry(ev))
+	if err != nil {
+		l.Debugln("event history:", err)
+		return
+	}
+	bs = append(bs, '\n')
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if day := ev.Time.UTC().Format(eventHistoryDayFormat); day != s.fdDay {
+		// A new day starts a new file. If it can't be opened we warn once
+		// and try again the next day.
+		s.closeLocked()
+		s.fdDay = day
+		if err := os.MkdirAll(s.dir, 0700); err != nil {
+			l.Warnln("Event history:", err)
+			return
+		}
+		fd, err := os.OpenFile(filepath.Join(s.dir, day+eventHistoryFileSuffix), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			l.Warnln("Event history:", err)
+			return
+		}
+		s.fd = fd
+	}
+	if s.fd == nil {
+		return
+	}
+	if _, err := s.fd.Write(bs); err != nil {
+		l.Debugln("event history:", err)
+	}
+}
+
+func (s *eventHistoryService) closeLocked() {
+	if s.fd != nil {
+		s.fd.Close()
+		s.fd = nil
+	}
+	s.fdDay = ""
+}
+
+// expire removes the files for days that are entirely older than the
+// configured number of days before now.
+func (s *eventHistoryService) expire(now time.Time) {
+	s.mut.Lock()
+	days := s.days
+	s.mut.Unlock()
+	if days <= 0 {
+		return
+	}
+
+	oldest := now.UTC().AddDate(0, 0, -days).Format(eventHistoryDayFormat)
+	for _, day := range s.dayFiles(time.Time{}, time.Time{}) {
+		if day < oldest {
+			l.Debugln("event history: removing", day)
+			os.Remove(filepath.Join(s.dir, day+eventHistoryFileSuffix))
+		}
+	}
+}
+
+// dayFiles returns the days for which there is a file, in order. Non-zero
+// from and to limit them to the days that can hold entries in that range,
+// going by the day in the file name.
+func (s *eventHistoryService) dayFiles(from, to time.Time) []string {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	var first, last string
+	if !from.IsZero() {
+		first = from.UTC().Format(eventHistoryDayFormat)
+	}
+	if !to.IsZero() {
+		last = to.UTC().Format(eventHistoryDayFormat)
+	}
+	var days []string
+	for _, info := range infos {
+		day := strings.TrimSuffix(info.Name(), eventHistoryFileSuffix)
+		if day == info.Name() || info.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(eventHistoryDayFormat, day); err != nil {
+			continue
+		}
+		if first != "" && day < first || last != "" && day > last {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days
+}
+
+// Query returns the page of entries matching the query, oldest first, and
+// the total number of matching entries.
+func (s *eventHistoryService) Query(q eventHistoryQuery) (eventHistoryResult, error) {
+	res := eventHistoryResult{
+		Events:  []eventHistoryEntry{},
+		Page:    q.Page,
+		PerPage: q.PerPage,
+	}
+	skip := (q.Page - 1) * q.PerPage
+
+	for _, day := range s.dayFiles(q.From, q.To) {
+		fd, err := os.Open(filepath.Join(s.dir, day+eventHistoryFileSuffix))
+		if os.IsNotExist(err) {
+			// Expired in the meantime
+			continue
+		} else if err != nil {
+			return res, err
+		}
+		br := bufio.NewReader(fd)
+		for {
+			line, err := br.ReadBytes('\n')
+			if err == io.EOF {
+				// Possibly a partially written entry, skipped.
+				break
+			} else if err != nil {
+				fd.Close()
+				return res, err
+			}
+			var e eventHistoryEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				continue
+			}
+			if !q.matches(e) {
+				continue
+			}
+			if res.Total >= skip && len(res.Events) < q.PerPage {
+				res.Events = append(res.Events, e)
+			}
+			res.Total++
+		}
+		fd.Close()
+	}
+
+	return res, nil
+}
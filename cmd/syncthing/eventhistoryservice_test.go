@@ -0,0 +1,108 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestEventHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-eventhistory-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := config.New(protocol.LocalDeviceID)
+	cfg.Options.EventHistoryDays = 2
+	s := newEventHistoryService(config.Wrap(filepath.Join(dir, "config.xml"), cfg), dir)
+
+	device := protocol.DeviceID{1, 2, 3}
+	day := func(d int) time.Time {
+		return time.Date(2019, 10, d, 12, 0, 0, 0, time.UTC)
+	}
+	for i, ev := range []events.Event{
+		{Time: day(1), Type: events.LocalChangeDetected, Data: map[string]string{"folder": "default", "path": filepath.FromSlash("a/b")}},
+		{Time: day(2), Type: events.RemoteChangeDetected, Data: map[string]string{"folder": "default", "path": "a/c", "modifiedBy": device.Short().String()}},
+		{Time: day(2), Type: events.ItemFinished, Data: map[string]interface{}{"folder": "other", "item": "a/d"}},
+		{Time: day(3), Type: events.DeviceConnected, Data: map[string]string{"id": device.String()}},
+		{Time: day(3), Type: events.FolderPaused, Data: map[string]string{"id": "default"}},
+	} {
+		ev.GlobalID = i + 1
+		s.record(ev)
+	}
+	s.mut.Lock()
+	s.closeLocked()
+	s.mut.Unlock()
+
+	cases := []struct {
+		query eventHistoryQuery
+		ids   []int
+		total int
+	}{
+		{eventHistoryQuery{}, []int{1, 2, 3, 4, 5}, 5},
+		{eventHistoryQuery{Folder: "default"}, []int{1, 2, 5}, 3},
+		{eventHistoryQuery{Folders: []string{"other"}}, []int{3, 4}, 2},
+		{eventHistoryQuery{Device: device}, []int{2, 4}, 2},
+		{eventHistoryQuery{Prefix: "a/"}, []int{1, 2, 3}, 3},
+		{eventHistoryQuery{Prefix: "a/b"}, []int{1}, 1},
+		{eventHistoryQuery{Mask: events.ItemFinished | events.FolderPaused}, []int{3, 5}, 2},
+		{eventHistoryQuery{From: day(2), To: day(2)}, []int{2, 3}, 2},
+		{eventHistoryQuery{From: day(3).Add(time.Second)}, nil, 0},
+		{eventHistoryQuery{Page: 2, PerPage: 2}, []int{3, 4}, 5},
+		{eventHistoryQuery{Page: 3, PerPage: 2}, []int{5}, 5},
+	}
+	for i, tc := range cases {
+		if tc.query.Page == 0 {
+			tc.query.Page, tc.query.PerPage = 1, 100
+		}
+		res, err := s.Query(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, e := range res.Events {
+			ids = append(ids, e.GlobalID)
+		}
+		if len(ids) != len(tc.ids) || res.Total != tc.total {
+			t.Errorf("%d: got %v of %d, expected %v of %d", i, ids, res.Total, tc.ids, tc.total)
+			continue
+		}
+		for j := range ids {
+			if ids[j] != tc.ids[j] {
+				t.Errorf("%d: got %v, expected %v", i, ids, tc.ids)
+				break
+			}
+		}
+	}
+
+	// Queries only read the files for the days in their range.
+	if days := s.dayFiles(day(2), day(2).Add(time.Hour)); len(days) != 1 || days[0] != "20191002" {
+		t.Errorf("Unexpected days for the second: %v", days)
+	}
+	if days := s.dayFiles(day(2), time.Time{}); len(days) != 2 || days[0] != "20191002" {
+		t.Errorf("Unexpected days from the second: %v", days)
+	}
+
+	// Keeping two days on the fourth removes the first day.
+	s.expire(day(4))
+	res, err := s.Query(eventHistoryQuery{Page: 1, PerPage: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 4 || res.Events[0].GlobalID != 2 {
+		t.Errorf("Unexpected history after expiry: %+v", res)
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/connections"
 	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/dialer"
 	"github.com/syncthing/syncthing/lib/discover"
 	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/fs"
@@ -60,6 +61,9 @@ const (
 	defaultEventMask   = events.AllEvents &^ events.LocalChangeDetected &^ events.RemoteChangeDetected
 	diskEventMask      = events.LocalChangeDetected | events.RemoteChangeDetected
 	eventSubBufferSize = 1000
+
+	eventStreamBufferSize = 5000
+	eventStreamKeepalive  = 30 * time.Second
 )
 
 type apiService struct {
@@ -71,8 +75,13 @@ type apiService struct {
 	model              modelIntf
 	eventSubs          map[events.EventType]events.BufferedSubscription
 	eventSubsMut       sync.Mutex
+	eventStream        *events.Stream
 	discoverer         discover.CachingMux
+	addressBook        addressBookIntf
 	connectionsService connectionsIntf
+	webhooks           webhookIntf
+	eventHistory       eventHistoryIntf
+	apiKeyUsage        *apiKeyUsage
 	fss                *folderSummaryService
 	systemConfigMut    sync.Mutex    // serializes posts to /rest/system/config
 	stop               chan struct{} // signals intentional stop
@@ -121,11 +130,14 @@ type modelIntf interface {
 	UsageReportingStats(version int, preview bool) map[string]interface{}
 	FolderErrors(folder string) ([]model.FileError, error)
 	WatchError(folder string) error
+	WatchState(folder string) (fs.WatchState, error)
+	CheckDatabase(folder string) error
 }
 
 type configIntf interface {
 	GUI() config.GUIConfiguration
 	LDAP() config.LDAPConfiguration
+	OIDC() config.OIDCConfiguration
 	RawCopy() config.Configuration
 	Options() config.OptionsConfiguration
 	Replace(cfg config.Configuration) (config.Waiter, error)
@@ -134,6 +146,18 @@ type configIntf interface {
 	Devices() map[protocol.DeviceID]config.DeviceConfiguration
 	SetDevice(config.DeviceConfiguration) (config.Waiter, error)
 	SetDevices([]config.DeviceConfiguration) (config.Waiter, error)
+	RemoveDevice(protocol.DeviceID) (config.Waiter, error)
+	SetFolder(config.FolderConfiguration) (config.Waiter, error)
+	RemoveFolder(id string) (config.Waiter, error)
+	SetOptions(config.OptionsConfiguration) (config.Waiter, error)
+	SetGUI(config.GUIConfiguration) (config.Waiter, error)
+	Defaults() config.DefaultsConfiguration
+	SetDefaults(config.DefaultsConfiguration) (config.Waiter, error)
+	Locked() []string
+	SaveBy(author string) error
+	History() ([]config.Revision, error)
+	RevisionDiff(from, to int) (string, error)
+	ReadRevision(rev int) (config.Configuration, error)
 	Save() error
 	ListenAddresses() []string
 	RequiresRestart() bool
@@ -144,11 +168,24 @@ type connectionsIntf interface {
 	NATType() string
 }
 
+type addressBookIntf interface {
+	Export(devices map[protocol.DeviceID][]string) ([]byte, error)
+	Import(book discover.SignedAddressBook) (int, error)
+}
+
+type webhookIntf interface {
+	Status() []webhookStatus
+}
+
+type eventHistoryIntf interface {
+	Query(q eventHistoryQuery) (eventHistoryResult, error)
+}
+
 type rater interface {
 	Rate() float64
 }
 
-func newAPIService(id protocol.DeviceID, cfg configIntf, httpsCertFile, httpsKeyFile, assetDir string, m modelIntf, defaultSub, diskSub events.BufferedSubscription, discoverer discover.CachingMux, connectionsService connectionsIntf, errors, systemLog logger.Recorder, cpu rater) *apiService {
+func newAPIService(id protocol.DeviceID, cfg configIntf, httpsCertFile, httpsKeyFile, assetDir string, m modelIntf, defaultSub, diskSub events.BufferedSubscription, discoverer discover.CachingMux, addressBook addressBookIntf, connectionsService connectionsIntf, webhooks webhookIntf, eventHistory eventHistoryIntf, errors, systemLog logger.Recorder, cpu rater, miscDB *db.NamespacedKV) *apiService {
 	service := &apiService{
 		id:            id,
 		cfg:           cfg,
@@ -161,8 +198,13 @@ func newAPIService(id protocol.DeviceID, cfg configIntf, httpsCertFile, httpsKey
 			diskEventMask:    diskSub,
 		},
 		eventSubsMut:       sync.NewMutex(),
+		eventStream:        events.NewStream(events.Default.Subscribe(events.AllEvents), eventStreamBufferSize),
 		discoverer:         discoverer,
+		addressBook:        addressBook,
 		connectionsService: connectionsService,
+		webhooks:           webhooks,
+		eventHistory:       eventHistory,
+		apiKeyUsage:        newAPIKeyUsage(miscDB),
 		systemConfigMut:    sync.NewMutex(),
 		stop:               make(chan struct{}),
 		configChanged:      make(chan struct{}),
@@ -276,6 +318,8 @@ func (s *apiService) Serve() {
 	getRestMux.HandleFunc("/rest/folder/pullerrors", s.getFolderErrors)          // folder (deprecated)
 	getRestMux.HandleFunc("/rest/events", s.getIndexEvents)                      // [since] [limit] [timeout] [events]
 	getRestMux.HandleFunc("/rest/events/disk", s.getDiskEvents)                  // [since] [limit] [timeout]
+	getRestMux.HandleFunc("/rest/events/stream", s.getEventStream)               // [since] [events]
+	getRestMux.HandleFunc("/rest/events/history", s.getEventHistory)             // [folder] [device] [prefix] [events] [from] [to] [page] [perpage]
 	getRestMux.HandleFunc("/rest/stats/device", s.getDeviceStats)                // -
 	getRestMux.HandleFunc("/rest/stats/folder", s.getFolderStats)                // -
 	getRestMux.HandleFunc("/rest/svc/deviceid", s.getDeviceID)                   // id
@@ -287,6 +331,7 @@ func (s *apiService) Serve() {
 	getRestMux.HandleFunc("/rest/system/config/insync", s.getSystemConfigInsync) // -
 	getRestMux.HandleFunc("/rest/system/connections", s.getSystemConnections)    // -
 	getRestMux.HandleFunc("/rest/system/discovery", s.getSystemDiscovery)        // -
+	getRestMux.HandleFunc("/rest/system/discovery/export", s.getDiscoveryExport) // -
 	getRestMux.HandleFunc("/rest/system/error", s.getSystemError)                // -
 	getRestMux.HandleFunc("/rest/system/ping", s.restPing)                       // -
 	getRestMux.HandleFunc("/rest/system/status", s.getSystemStatus)              // -
@@ -295,6 +340,10 @@ func (s *apiService) Serve() {
 	getRestMux.HandleFunc("/rest/system/debug", s.getSystemDebug)                // -
 	getRestMux.HandleFunc("/rest/system/log", s.getSystemLog)                    // [since]
 	getRestMux.HandleFunc("/rest/system/log.txt", s.getSystemLogTxt)             // [since]
+	getRestMux.HandleFunc("/rest/system/webhooks", s.getSystemWebhooks)          // -
+	getRestMux.HandleFunc("/rest/system/apikeys", s.getSystemAPIKeys)            // -
+	getRestMux.HandleFunc("/rest/system/totp", s.getSystemTOTP)                  // -
+	getRestMux.HandleFunc("/rest/system/lockouts", s.getSystemLockouts)          // -
 
 	// The POST handlers
 	postRestMux := http.NewServeMux()
@@ -315,6 +364,14 @@ func (s *apiService) Serve() {
 	postRestMux.HandleFunc("/rest/system/pause", s.makeDevicePauseHandler(true))   // [device]
 	postRestMux.HandleFunc("/rest/system/resume", s.makeDevicePauseHandler(false)) // [device]
 	postRestMux.HandleFunc("/rest/system/debug", s.postSystemDebug)                // [enable] [disable]
+	postRestMux.HandleFunc("/rest/system/db/check", s.postSystemDBCheck)           // [folder]
+	postRestMux.HandleFunc("/rest/system/apikeys", s.postSystemAPIKeys)            // <body>
+	postRestMux.HandleFunc("/rest/system/apikeys/revoke", s.postAPIKeyRevoke)      // name
+	postRestMux.HandleFunc("/rest/system/totp/enroll", s.postSystemTOTPEnroll)     // <body>
+	postRestMux.HandleFunc("/rest/system/totp/confirm", s.postSystemTOTPConfirm)   // <body>
+	postRestMux.HandleFunc("/rest/system/totp/disable", s.postSystemTOTPDisable)   // [user] <body>
+	postRestMux.HandleFunc("/rest/system/lockouts/clear", s.postLockoutsClear)     // [ip] [user]
+	postRestMux.HandleFunc("/rest/system/discovery/import", s.postDiscoveryImport) // <body>
 
 	// Debug endpoints, not for general use
 	debugMux := http.NewServeMux()
@@ -325,9 +382,14 @@ func (s *apiService) Serve() {
 	debugMux.HandleFunc("/rest/debug/support", s.getSupportBundle)
 	getRestMux.Handle("/rest/debug/", s.whenDebugging(debugMux))
 
-	// A handler that splits requests between the two above and disables
+	// The resource level config endpoints handle their methods themselves
+	methodMux := http.NewServeMux()
+	methodMux.Handle("/rest/", getPostHandler(getRestMux, postRestMux))
+	methodMux.HandleFunc("/rest/config/", s.serveConfig)
+
+	// A handler that splits requests between the ones above and disables
 	// caching
-	restMux := noCacheMiddleware(metricsMiddleware(getPostHandler(getRestMux, postRestMux)))
+	restMux := noCacheMiddleware(metricsMiddleware(s.accessMiddleware(methodMux)))
 
 	// The main routing handler
 	mux := http.NewServeMux()
@@ -351,7 +413,7 @@ func (s *apiService) Serve() {
 
 	// Wrap everything in basic auth, if user/password is set.
 	if guiCfg.IsAuthEnabled() {
-		handler = basicAuthAndSessionMiddleware("sessionid-"+s.id.String()[:5], guiCfg, s.cfg.LDAP(), handler)
+		handler = basicAuthAndSessionMiddleware("sessionid-"+s.id.String()[:5], guiCfg, s.cfg.LDAP(), s.cfg.OIDC(), s.useRecoveryCode, handler)
 	}
 
 	// Redirect to HTTPS if we are supposed to
@@ -449,8 +511,9 @@ func (s *apiService) VerifyConfiguration(from, to config.Configuration) error {
 func (s *apiService) CommitConfiguration(from, to config.Configuration) bool {
 	// No action required when this changes, so mask the fact that it changed at all.
 	from.GUI.Debugging = to.GUI.Debugging
+	from.GUI = maskUsedRecoveryCodes(from.GUI, to.GUI)
 
-	if to.GUI == from.GUI {
+	if reflect.DeepEqual(to.GUI, from.GUI) && reflect.DeepEqual(to.OIDC, from.OIDC) {
 		return true
 	}
 
@@ -517,10 +580,11 @@ func corsMiddleware(next http.Handler, allowFrameLoading bool) http.Handler {
 		if r.Method == "OPTIONS" {
 			// Add a generous access-control-allow-origin header for CORS requests
 			w.Header().Add("Access-Control-Allow-Origin", "*")
-			// Only GET/POST Methods are supported
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			// Only these methods are supported, the last three only for
+			// the config endpoints
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
 			// Only these headers can be set
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, If-Match, If-None-Match, "+secondFactorHeader)
 			// The request is meant to be cached 10 minutes
 			w.Header().Set("Access-Control-Max-Age", "600")
 
@@ -780,6 +844,10 @@ func folderSummary(cfg configIntf, m modelIntf, folder string) (map[string]inter
 		res["watchError"] = err.Error()
 	}
 
+	if state, err := m.WatchState(folder); err == nil && state != (fs.WatchState{}) {
+		res["watchedDirectories"], res["polledDirectories"] = state.Watched, state.Polled
+	}
+
 	return res, nil
 }
 
@@ -867,7 +935,11 @@ func (s *apiService) getDBLocalChanged(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *apiService) getSystemConnections(w http.ResponseWriter, r *http.Request) {
-	sendJSON(w, s.model.ConnectionStats())
+	res := s.model.ConnectionStats()
+	// The proxy outgoing connections go through, unless the device has
+	// its own.
+	res["proxy"] = dialer.Proxy()
+	sendJSON(w, res)
 }
 
 func (s *apiService) getDeviceStats(w http.ResponseWriter, r *http.Request) {
@@ -875,7 +947,15 @@ func (s *apiService) getDeviceStats(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *apiService) getFolderStats(w http.ResponseWriter, r *http.Request) {
-	sendJSON(w, s.model.FolderStatistics())
+	stats := s.model.FolderStatistics()
+	if id, ok := guiIdentityFrom(r); ok {
+		for folder := range stats {
+			if !id.canAccessFolder(folder) {
+				delete(stats, folder)
+			}
+		}
+	}
+	sendJSON(w, stats)
 }
 
 func (s *apiService) getDBFile(w http.ResponseWriter, r *http.Request) {
@@ -900,7 +980,11 @@ func (s *apiService) getDBFile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *apiService) getSystemConfig(w http.ResponseWriter, r *http.Request) {
-	sendJSON(w, s.cfg.RawCopy())
+	cfg := s.cfg.RawCopy()
+	if id, ok := guiIdentityFrom(r); ok {
+		cfg = redactedConfig(cfg, id)
+	}
+	sendJSON(w, cfg)
 }
 
 func (s *apiService) postSystemConfig(w http.ResponseWriter, r *http.Request) {
@@ -915,35 +999,40 @@ func (s *apiService) postSystemConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if to.GUI.Password != s.cfg.GUI().Password {
-		if to.GUI.Password != "" && !bcryptExpr.MatchString(to.GUI.Password) {
-			hash, err := bcrypt.GenerateFromPassword([]byte(to.GUI.Password), 0)
+	if err := hashGUIPasswords(&to.GUI, s.cfg.GUI().Password); err != nil {
+		l.Warnln("bcrypting password:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.replaceAndSaveConfig(w, r, to)
+}
+
+// hashGUIPasswords replaces the passwords given in plain text by their
+// bcrypt hashes. The password of the administrator is left alone when it's
+// unchanged from the current one.
+func hashGUIPasswords(gui *config.GUIConfiguration, currentPassword string) error {
+	if gui.Password != currentPassword {
+		if gui.Password != "" && !bcryptExpr.MatchString(gui.Password) {
+			hash, err := bcrypt.GenerateFromPassword([]byte(gui.Password), 0)
 			if err != nil {
-				l.Warnln("bcrypting password:", err)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+				return err
 			}
 
-			to.GUI.Password = string(hash)
+			gui.Password = string(hash)
 		}
 	}
+	for i, user := range gui.Users {
+		if user.Password != "" && !bcryptExpr.MatchString(user.Password) {
+			hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), 0)
+			if err != nil {
+				return err
+			}
 
-	// Activate and save. Wait for the configuration to become active before
-	// completing the request.
-
-	if wg, err := s.cfg.Replace(to); err != nil {
-		l.Warnln("Replacing config:", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	} else {
-		wg.Wait()
-	}
-
-	if err := s.cfg.Save(); err != nil {
-		l.Warnln("Saving config:", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+			gui.Users[i].Password = string(hash)
+		}
 	}
+	return nil
 }
 
 func (s *apiService) getSystemConfigInsync(w http.ResponseWriter, r *http.Request) {
@@ -981,6 +1070,16 @@ func (s *apiService) postSystemReset(w http.ResponseWriter, r *http.Request) {
 	go exit.Restart()
 }
 
+func (s *apiService) postSystemDBCheck(w http.ResponseWriter, r *http.Request) {
+	folder := r.URL.Query().Get("folder")
+	if err := s.model.CheckDatabase(folder); err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	// The results follow as DatabaseCheckCompleted events.
+	w.WriteHeader(http.StatusAccepted)
+}
+
 func (s *apiService) postSystemShutdown(w http.ResponseWriter, r *http.Request) {
 	s.flushResponse(`{"ok": "shutting down"}`, w)
 	go exit.Shutdown()
@@ -1003,7 +1102,7 @@ func (s *apiService) getSystemStatus(w http.ResponseWriter, r *http.Request) {
 	res["alloc"] = m.Alloc
 	res["sys"] = m.Sys - m.HeapReleased
 	res["tilde"] = tilde
-	if s.cfg.Options().LocalAnnEnabled || s.cfg.Options().GlobalAnnEnabled {
+	if opts := s.cfg.Options(); opts.LocalAnnEnabled || opts.GlobalAnnEnabled || opts.MDNSEnabled || len(opts.DNSDiscoveryDomains) > 0 {
 		res["discoveryEnabled"] = true
 		discoErrors := make(map[string]string)
 		discoMethods := 0
@@ -1212,6 +1311,354 @@ func (s *apiService) getSystemDiscovery(w http.ResponseWriter, r *http.Request)
 	sendJSON(w, devices)
 }
 
+// getDiscoveryExport returns an address book file, signed by us, with the
+// addresses of the devices we know and our own, to be imported elsewhere.
+func (s *apiService) getDiscoveryExport(w http.ResponseWriter, r *http.Request) {
+	if s.addressBook == nil {
+		http.Error(w, "Address book not available", http.StatusNotFound)
+		return
+	}
+
+	devices := make(map[protocol.DeviceID][]string)
+	if s.discoverer != nil {
+		for device, entry := range s.discoverer.Cache() {
+			devices[device] = entry.Addresses
+		}
+	}
+	bs, err := s.addressBook.Export(devices)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=syncthing-addressbook-"+s.id.Short().String()+".json")
+	w.Write(bs)
+}
+
+// postDiscoveryImport adds the addresses in an address book file to ours.
+// It must be signed by us or by one of our introducers, the devices we
+// trust to tell us about others.
+func (s *apiService) postDiscoveryImport(w http.ResponseWriter, r *http.Request) {
+	if s.addressBook == nil {
+		http.Error(w, "Address book not available", http.StatusNotFound)
+		return
+	}
+
+	bs, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	book, err := discover.ReadAddressBook(bs)
+	if err != nil {
+		http.Error(w, "Invalid address book: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if signer, ok := s.cfg.Devices()[book.Signer]; book.Signer != s.id && !(ok && signer.Introducer) {
+		http.Error(w, "Address book not signed by us or an introducer", http.StatusForbidden)
+		return
+	}
+
+	n, err := s.addressBook.Import(book)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, map[string]interface{}{
+		"signer":  book.Signer.String(),
+		"devices": n,
+	})
+}
+
+func (s *apiService) getSystemWebhooks(w http.ResponseWriter, r *http.Request) {
+	statuses := []webhookStatus{}
+	if s.webhooks != nil {
+		statuses = s.webhooks.Status()
+	}
+	sendJSON(w, statuses)
+}
+
+type apiKeyStatus struct {
+	Name      string         `json:"name"`
+	Role      config.GUIRole `json:"role"`
+	Folders   []string       `json:"folders"`
+	Endpoints []string       `json:"endpoints"`
+	Expires   time.Time      `json:"expires"`
+	Expired   bool           `json:"expired"`
+	LastUsed  time.Time      `json:"lastUsed"`
+}
+
+func (s *apiService) getSystemAPIKeys(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	statuses := []apiKeyStatus{}
+	for _, k := range s.cfg.GUI().APIKeys {
+		statuses = append(statuses, apiKeyStatus{
+			Name:      k.Name,
+			Role:      k.Role,
+			Folders:   k.Folders,
+			Endpoints: k.Endpoints,
+			Expires:   k.Expires,
+			Expired:   k.Expired(now),
+			LastUsed:  s.apiKeyUsage.LastUsed(k.Name),
+		})
+	}
+	sendJSON(w, statuses)
+}
+
+// postSystemAPIKeys adds the posted API key, generating the key itself
+// unless given. The response is the added key, which is the only time a
+// generated key is returned.
+func (s *apiService) postSystemAPIKeys(w http.ResponseWriter, r *http.Request) {
+	var key config.APIKeyConfiguration
+	err := json.NewDecoder(r.Body).Decode(&key)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if key.Name == "" {
+		http.Error(w, "API key name must be given", http.StatusBadRequest)
+		return
+	}
+	if key.Key == "" {
+		key.Key = rand.String(32)
+	}
+
+	s.systemConfigMut.Lock()
+	defer s.systemConfigMut.Unlock()
+
+	to := s.cfg.RawCopy()
+	for _, k := range to.GUI.APIKeys {
+		if k.Name == key.Name {
+			http.Error(w, "API key name already in use", http.StatusBadRequest)
+			return
+		}
+	}
+	to.GUI.APIKeys = append(to.GUI.APIKeys, key)
+	if !s.replaceAndSaveConfig(w, r, to) {
+		return
+	}
+
+	s.apiKeyUsage.forget(key.Name)
+	sendJSON(w, key)
+}
+
+func (s *apiService) postAPIKeyRevoke(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	s.systemConfigMut.Lock()
+	defer s.systemConfigMut.Unlock()
+
+	to := s.cfg.RawCopy()
+	found := false
+	keys := to.GUI.APIKeys[:0]
+	for _, k := range to.GUI.APIKeys {
+		if k.Name == name {
+			found = true
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if !found {
+		http.Error(w, "No such API key", http.StatusNotFound)
+		return
+	}
+	to.GUI.APIKeys = keys
+	if !s.replaceAndSaveConfig(w, r, to) {
+		return
+	}
+
+	s.apiKeyUsage.forget(name)
+}
+
+func (s *apiService) getSystemTOTP(w http.ResponseWriter, r *http.Request) {
+	id, _ := guiIdentityFrom(r)
+	secret, recoveryCodes := s.cfg.GUI().SecondFactor(id.User)
+	sendJSON(w, map[string]interface{}{
+		"enabled":           secret != "",
+		"recoveryCodesLeft": len(recoveryCodes),
+	})
+}
+
+// totpReauthRequest is the body of requests changing the second factor,
+// which need the password or a current code of the logged in user, so that
+// a session alone isn't enough.
+type totpReauthRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// readTOTPReauth checks the password or code in the request body against
+// the logged in user, returning false after responding with the error if
+// neither is valid.
+func (s *apiService) readTOTPReauth(w http.ResponseWriter, r *http.Request) bool {
+	var req totpReauthRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	id, _ := guiIdentityFrom(r)
+	guiCfg := s.cfg.GUI()
+	if req.Password != "" && id.User != "" && auth(id.User, req.Password, guiCfg, s.cfg.LDAP()) {
+		return true
+	}
+	if secret, _ := guiCfg.SecondFactor(id.User); secret != "" && useTOTPCode(id.User, secret, req.Code, time.Now()) {
+		return true
+	}
+	http.Error(w, "Invalid password or code", http.StatusForbidden)
+	return false
+}
+
+// postSystemTOTPEnroll returns a new TOTP secret for the logged in user,
+// which becomes their second factor once confirmed with a code. The
+// request has their password or a code of their current second factor.
+func (s *apiService) postSystemTOTPEnroll(w http.ResponseWriter, r *http.Request) {
+	id, _ := guiIdentityFrom(r)
+	guiCfg := s.cfg.GUI()
+	if _, ok := guiCfg.GUIUser(id.User); !ok && (id.User == "" || id.User != guiCfg.User) {
+		http.Error(w, "No such GUI user", http.StatusNotFound)
+		return
+	}
+	if !s.readTOTPReauth(w, r) {
+		return
+	}
+	secret := newPendingTOTPSecret(id.User, time.Now())
+	sendJSON(w, map[string]string{
+		"secret": secret,
+		"uri":    totpURI(id.User, secret),
+	})
+}
+
+// postSystemTOTPConfirm sets the secret from the last enrolment as the
+// second factor of the logged in user, if the posted code is valid for it.
+// The response has the recovery codes, which are not shown again.
+func (s *apiService) postSystemTOTPConfirm(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, _ := guiIdentityFrom(r)
+	secret, ok := pendingTOTPSecret(id.User, time.Now())
+	if !ok {
+		http.Error(w, "No enrolment in progress", http.StatusBadRequest)
+		return
+	}
+	if !useTOTPCode(id.User, secret, req.Code, time.Now()) {
+		http.Error(w, "Invalid code", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes := newRecoveryCodes()
+	if !s.setSecondFactor(w, r, id.User, secret, hashes) {
+		return
+	}
+	forgetPendingTOTPSecret(id.User)
+	sendJSON(w, map[string][]string{"recoveryCodes": codes})
+}
+
+// postSystemTOTPDisable removes the second factor of the logged in user
+// or, for admins, of the given user. The request has the password or a
+// current code of the logged in user.
+func (s *apiService) postSystemTOTPDisable(w http.ResponseWriter, r *http.Request) {
+	id, _ := guiIdentityFrom(r)
+	username := r.URL.Query().Get("user")
+	if username == "" {
+		username = id.User
+	}
+	if username != id.User && !id.Role.Allows(config.GUIRoleAdmin) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if !s.readTOTPReauth(w, r) {
+		return
+	}
+	s.setSecondFactor(w, r, username, "", nil)
+}
+
+// setSecondFactor sets and saves the second factor of the user, returning
+// false after responding with the error if that fails.
+func (s *apiService) setSecondFactor(w http.ResponseWriter, r *http.Request, username, secret string, recoveryCodes []string) bool {
+	s.systemConfigMut.Lock()
+	defer s.systemConfigMut.Unlock()
+
+	to := s.cfg.RawCopy()
+	if !to.GUI.SetSecondFactor(username, secret, recoveryCodes) {
+		http.Error(w, "No such GUI user", http.StatusNotFound)
+		return false
+	}
+	return s.replaceAndSaveConfig(w, r, to)
+}
+
+// useRecoveryCode removes a recovery code used to log in from the
+// configuration. It's called by the middleware during the login. The
+// middleware already refuses the used code, so the change doesn't restart
+// the GUI (see maskUsedRecoveryCodes) and isn't waited for.
+func (s *apiService) useRecoveryCode(username, hash string) {
+	s.systemConfigMut.Lock()
+	defer s.systemConfigMut.Unlock()
+
+	to := s.cfg.RawCopy()
+	secret, hashes := to.GUI.SecondFactor(username)
+	remaining := hashes[:0]
+	for _, h := range hashes {
+		if h != hash {
+			remaining = append(remaining, h)
+		}
+	}
+	to.GUI.SetSecondFactor(username, secret, remaining)
+
+	if _, err := s.cfg.Replace(to); err != nil {
+		l.Warnln("Removing used recovery code:", err)
+		return
+	}
+	if err := s.cfg.SaveBy(username); err != nil {
+		l.Warnln("Saving config:", err)
+	}
+}
+
+// getSystemLockouts returns the recent failed logins by IP address and by
+// user name, with the lockouts they caused.
+func (s *apiService) getSystemLockouts(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, loginLimits.list())
+}
+
+// postLockoutsClear forgets the failed logins from the given IP address
+// and for the given user name, or all of them when neither is given.
+func (s *apiService) postLockoutsClear(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	loginLimits.clear(qs.Get("ip"), qs.Get("user"))
+}
+
+// replaceAndSaveConfig activates and saves the configuration, returning
+// false after responding with the error if that fails. It waits for the
+// configuration to become active before returning.
+func (s *apiService) replaceAndSaveConfig(w http.ResponseWriter, r *http.Request, to config.Configuration) bool {
+	if wg, err := s.cfg.Replace(to); err != nil {
+		l.Warnln("Replacing config:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	} else {
+		wg.Wait()
+	}
+
+	if err := s.cfg.SaveBy(configAuthor(r)); err != nil {
+		l.Warnln("Saving config:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
 func (s *apiService) getReport(w http.ResponseWriter, r *http.Request) {
 	version := usageReportVersion
 	if val, _ := strconv.Atoi(r.URL.Query().Get("version")); val > 0 {
@@ -1310,10 +1757,133 @@ func (s *apiService) getEvents(w http.ResponseWriter, r *http.Request, eventSub
 	if 0 < limit && limit < len(evs) {
 		evs = evs[len(evs)-limit:]
 	}
+	if id, ok := guiIdentityFrom(r); ok {
+		evs = redactedEvents(evs, id)
+	}
 
 	sendJSON(w, evs)
 }
 
+// getEventStream sends events as Server-Sent Events as they happen, until
+// the client goes away. Each event carries its GlobalID as the SSE event ID,
+// so clients can resume using the Last-Event-ID header or the since
+// parameter. Without either, only events from now on are sent. Events the
+// client can no longer get are announced by an EventsDropped event.
+func (s *apiService) getEventStream(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	qs := r.URL.Query()
+	mask := s.getEventMask(qs.Get("events"))
+	since := s.eventStream.Last()
+	sinceStr := r.Header.Get("Last-Event-ID")
+	if sinceStr == "" {
+		sinceStr = qs.Get("since")
+	}
+	if sinceStr != "" {
+		var err error
+		if since, err = strconv.Atoi(sinceStr); err != nil || since < 0 {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	f.Flush()
+
+	var evs []events.Event
+	for {
+		s.fss.gotEventRequest()
+
+		evs, since = s.eventStream.Since(since, mask, evs[:0], eventStreamKeepalive)
+		if len(evs) == 0 {
+			// A comment line keeps intermediaries from timing out the
+			// connection and lets us notice a client that went away.
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		if id, ok := guiIdentityFrom(r); ok {
+			evs = redactedEvents(evs, id)
+		}
+		for _, ev := range evs {
+			bs, err := json.Marshal(ev)
+			if err != nil {
+				l.Debugln("event stream:", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.GlobalID, ev.Type, bs); err != nil {
+				return
+			}
+		}
+		f.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		default:
+		}
+	}
+}
+
+func (s *apiService) getEventHistory(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	q := eventHistoryQuery{
+		Folder: qs.Get("folder"),
+		Prefix: qs.Get("prefix"),
+	}
+	q.Page, q.PerPage = getPagingParams(qs)
+	if evs := qs.Get("events"); evs != "" {
+		q.Mask = s.getEventMask(evs)
+	}
+	if device := qs.Get("device"); device != "" {
+		id, err := protocol.DeviceIDFromString(device)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.Device = id
+	}
+	for _, param := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if v := qs.Get(param.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			*param.t = t
+		}
+	}
+
+	id, hasID := guiIdentityFrom(r)
+	if hasID && id.isScoped() {
+		q.Folders = id.Folders
+	}
+
+	if s.eventHistory == nil {
+		sendJSON(w, eventHistoryResult{Events: []eventHistoryEntry{}, Page: q.Page, PerPage: q.PerPage})
+		return
+	}
+	res, err := s.eventHistory.Query(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if hasID {
+		res.Events = redactedHistory(res.Events, id)
+	}
+	sendJSON(w, res)
+}
+
 func (s *apiService) getEventMask(evs string) events.EventType {
 	eventMask := defaultEventMask
 	if evs != "" {
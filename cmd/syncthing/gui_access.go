@@ -0,0 +1,421 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+// How often the use of each named API key is reported as an event, and
+// saved to the database.
+const apiKeyUsageInterval = time.Minute
+
+// A guiIdentity is who is making a request and what they may do.
+type guiIdentity struct {
+	User        string // user name, "apikey:" and the key name, or empty when authentication is disabled
+	Role        config.GUIRole
+	Folders     []string                    // folders the identity is restricted to, or nil for all
+	FolderRoles map[string]config.GUIRole   // folders on which the identity has a higher role than Role
+	APIKey      *config.APIKeyConfiguration // the named API key used, if any
+}
+
+var anonymousAdmin = guiIdentity{Role: config.GUIRoleAdmin}
+
+func (id guiIdentity) isScoped() bool {
+	return len(id.Folders) > 0
+}
+
+// folderRole returns the role of the identity for requests about the
+// folder, or about none if it's empty.
+func (id guiIdentity) folderRole(folder string) config.GUIRole {
+	if role, ok := id.FolderRoles[folder]; ok && role > id.Role {
+		return role
+	}
+	return id.Role
+}
+
+func (id guiIdentity) canAccessFolder(folder string) bool {
+	if !id.isScoped() {
+		return true
+	}
+	for _, f := range id.Folders {
+		if f == folder {
+			return true
+		}
+	}
+	return false
+}
+
+type guiIdentityKey struct{}
+
+func withGUIIdentity(r *http.Request, id guiIdentity) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), guiIdentityKey{}, id))
+}
+
+func guiIdentityFrom(r *http.Request) (guiIdentity, bool) {
+	id, ok := r.Context().Value(guiIdentityKey{}).(guiIdentity)
+	return id, ok
+}
+
+// userIdentity returns the identity of an authenticated user. Users not
+// listed in the configuration are the administrator or LDAP users, which
+// have admin access.
+func userIdentity(username string, guiCfg config.GUIConfiguration) (guiIdentity, bool) {
+	if u, ok := guiCfg.GUIUser(username); ok {
+		return guiIdentity{User: username, Role: u.Role, Folders: u.Folders}, true
+	}
+	if guiCfg.AuthMode == config.AuthModeLDAP || username == guiCfg.User {
+		return guiIdentity{User: username, Role: config.GUIRoleAdmin}, true
+	}
+	return guiIdentity{}, false
+}
+
+// apiKeyIdentity returns the identity for the given API key, if it's valid.
+func apiKeyIdentity(apiKey string, guiCfg config.GUIConfiguration) (guiIdentity, bool) {
+	if k, ok := guiCfg.NamedAPIKey(apiKey); ok {
+		return guiIdentity{User: "apikey:" + k.Name, Role: k.Role, Folders: k.Folders, APIKey: &k}, true
+	}
+	if guiCfg.IsValidAPIKey(apiKey) {
+		return guiIdentity{User: "apikey", Role: config.GUIRoleAdmin}, true
+	}
+	return guiIdentity{}, false
+}
+
+// The role needed for REST endpoints, by method and path. GET requests need
+// read-only access and other requests admin access, unless listed here.
+var restEndpointRoles = map[string]config.GUIRole{
+	"GET /rest/config/gui":              config.GUIRoleAdmin,
+	"GET /rest/config/history/diff":     config.GUIRoleAdmin,
+	"GET /rest/system/apikeys":          config.GUIRoleAdmin,
+	"GET /rest/system/browse":           config.GUIRoleAdmin,
+	"GET /rest/system/debug":            config.GUIRoleAdmin,
+	"GET /rest/system/discovery/export": config.GUIRoleAdmin,
+	"GET /rest/system/lockouts":         config.GUIRoleAdmin,
+	"POST /rest/db/override":            config.GUIRoleOperator,
+	"POST /rest/db/prio":                config.GUIRoleOperator,
+	"POST /rest/db/revert":              config.GUIRoleOperator,
+	"POST /rest/db/scan":                config.GUIRoleOperator,
+	"POST /rest/system/db/check":        config.GUIRoleOperator,
+	"POST /rest/system/error":           config.GUIRoleReadOnly,
+	"POST /rest/system/error/clear":     config.GUIRoleOperator,
+	"POST /rest/system/pause":           config.GUIRoleOperator,
+	"POST /rest/system/ping":            config.GUIRoleReadOnly,
+	"POST /rest/system/resume":          config.GUIRoleOperator,
+	"POST /rest/system/totp/confirm":    config.GUIRoleReadOnly,
+	"POST /rest/system/totp/disable":    config.GUIRoleReadOnly,
+	"POST /rest/system/totp/enroll":     config.GUIRoleReadOnly,
+}
+
+// REST endpoints that act on all folders when no folder is given, which
+// identities restricted to some folders must therefore give.
+var restAllFoldersEndpoints = map[string]bool{
+	"GET /rest/events/history":   true,
+	"POST /rest/db/scan":         true,
+	"POST /rest/system/db/check": true,
+}
+
+// REST endpoints that act on devices, which are shared by folders outside
+// the scope of identities restricted to some folders.
+var restUnscopedEndpoints = map[string]bool{
+	"POST /rest/system/pause":  true,
+	"POST /rest/system/resume": true,
+}
+
+func restEndpointRole(method, path string) config.GUIRole {
+	if role, ok := restEndpointRoles[method+" "+path]; ok {
+		return role
+	}
+	if method == "GET" && !strings.HasPrefix(path, "/rest/debug/") {
+		return config.GUIRoleReadOnly
+	}
+	return config.GUIRoleAdmin
+}
+
+// requestFolder returns the folder a REST request concerns, if any.
+func requestFolder(r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, configFoldersPath) {
+		return strings.TrimPrefix(r.URL.Path, configFoldersPath)
+	}
+	return r.URL.Query().Get("folder")
+}
+
+// accessMiddleware only lets requests through when the identity making
+// them has the role required for the endpoint and access to the folder
+// concerned, and named API keys are restricted to their endpoints. Requests
+// that change something, and those denied, are reported as UserAction
+// events.
+func (s *apiService) accessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := guiIdentityFrom(r)
+		if !ok {
+			// Authentication is disabled, but API keys still have the
+			// role they were given.
+			if id, ok = apiKeyIdentity(r.Header.Get("X-API-Key"), s.cfg.GUI()); !ok {
+				id = anonymousAdmin
+			}
+			r = withGUIIdentity(r, id)
+		}
+
+		endpoint := r.Method + " " + r.URL.Path
+		folder := requestFolder(r)
+		role := id.folderRole(folder)
+		allowed := role.Allows(restEndpointRole(r.Method, r.URL.Path)) &&
+			id.canAccessFolder(folder) &&
+			!(folder == "" && id.isScoped() && restAllFoldersEndpoints[endpoint]) &&
+			!(id.isScoped() && restUnscopedEndpoints[endpoint]) &&
+			(id.APIKey == nil || id.APIKey.AllowsEndpoint(r.Method, r.URL.Path))
+
+		if id.APIKey != nil && s.apiKeyUsage.used(id.APIKey.Name, time.Now()) {
+			events.Default.Log(events.APIKeyUsed, map[string]interface{}{
+				"name":          id.APIKey.Name,
+				"method":        r.Method,
+				"path":          r.URL.Path,
+				"remoteAddress": r.RemoteAddr,
+			})
+		}
+
+		if !allowed || r.Method != "GET" {
+			events.Default.Log(events.UserAction, map[string]interface{}{
+				"user":    id.User,
+				"role":    role.String(),
+				"method":  r.Method,
+				"path":    r.URL.Path,
+				"folder":  folder,
+				"allowed": allowed,
+			})
+		}
+		if !allowed {
+			httpl.Debugf("denied %s for %q (%v)", endpoint, id.User, role)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiKeyUsage keeps track of when each named API key was last used. The
+// times are kept in the database, if given, so that they survive restarts.
+type apiKeyUsage struct {
+	lastUsed     map[string]time.Time
+	lastReported map[string]time.Time
+	miscDB       *db.NamespacedKV
+	mut          sync.Mutex
+}
+
+func newAPIKeyUsage(miscDB *db.NamespacedKV) *apiKeyUsage {
+	return &apiKeyUsage{
+		lastUsed:     make(map[string]time.Time),
+		lastReported: make(map[string]time.Time),
+		miscDB:       miscDB,
+		mut:          sync.NewMutex(),
+	}
+}
+
+// used records that the named key was used, returning true if the use
+// should be reported: the first time and then at most once per
+// apiKeyUsageInterval. Reported uses are saved.
+func (u *apiKeyUsage) used(name string, now time.Time) bool {
+	u.mut.Lock()
+	u.lastUsed[name] = now
+	report := now.Sub(u.lastReported[name]) >= apiKeyUsageInterval
+	if report {
+		u.lastReported[name] = now
+	}
+	u.mut.Unlock()
+
+	if report && u.miscDB != nil {
+		u.miscDB.PutTime(apiKeyUsageKey(name), now)
+	}
+	return report
+}
+
+// LastUsed returns when the named key was last used, or the zero time if
+// it hasn't been. Uses since the last report are only known since startup.
+func (u *apiKeyUsage) LastUsed(name string) time.Time {
+	u.mut.Lock()
+	t, ok := u.lastUsed[name]
+	u.mut.Unlock()
+	if !ok && u.miscDB != nil {
+		t, _ = u.miscDB.Time(apiKeyUsageKey(name))
+	}
+	return t
+}
+
+func (u *apiKeyUsage) forget(name string) {
+	u.mut.Lock()
+	delete(u.lastUsed, name)
+	delete(u.lastReported, name)
+	u.mut.Unlock()
+	if u.miscDB != nil {
+		u.miscDB.Delete(apiKeyUsageKey(name))
+	}
+}
+
+func apiKeyUsageKey(name string) string {
+	return "apiKeyLastUsed/" + name
+}
+
+// redactedConfig returns the configuration as it may be seen by the
+// identity: without secrets unless it's an admin, and without the folders
+// it has no access to.
+func redactedConfig(cfg config.Configuration, id guiIdentity) config.Configuration {
+	if !id.Role.Allows(config.GUIRoleAdmin) {
+		cfg.GUI.Password = ""
+		cfg.GUI.APIKey = ""
+		cfg.GUI.TOTPSecret = ""
+		cfg.GUI.RecoveryCodes = nil
+		for i := range cfg.GUI.Users {
+			cfg.GUI.Users[i].Password = ""
+			cfg.GUI.Users[i].TOTPSecret = ""
+			cfg.GUI.Users[i].RecoveryCodes = nil
+		}
+		for i := range cfg.GUI.APIKeys {
+			cfg.GUI.APIKeys[i].Key = ""
+		}
+		for i := range cfg.Webhooks {
+			cfg.Webhooks[i].Secret = ""
+		}
+		cfg.OIDC.ClientSecret = ""
+		cfg.Options.ProxyURL = redactedProxyURL(cfg.Options.ProxyURL)
+		for i := range cfg.Devices {
+			cfg.Devices[i].ProxyURL = redactedProxyURL(cfg.Devices[i].ProxyURL)
+		}
+		cfg.Defaults.Device.ProxyURL = redactedProxyURL(cfg.Defaults.Device.ProxyURL)
+	}
+
+	if id.isScoped() {
+		folders := cfg.Folders[:0]
+		for _, folder := range cfg.Folders {
+			if id.canAccessFolder(folder.ID) {
+				folders = append(folders, folder)
+			}
+		}
+		cfg.Folders = folders
+	}
+
+	return cfg
+}
+
+// redactedConfigResource returns a single part of the configuration, as
+// served by the config resource endpoints, as it may be seen by the
+// identity.
+func redactedConfigResource(v interface{}, id guiIdentity) interface{} {
+	if id.Role.Allows(config.GUIRoleAdmin) {
+		return v
+	}
+	switch v := v.(type) {
+	case *config.OptionsConfiguration:
+		opts := *v
+		opts.ProxyURL = redactedProxyURL(opts.ProxyURL)
+		return &opts
+	case *config.DeviceConfiguration:
+		dev := *v
+		dev.ProxyURL = redactedProxyURL(dev.ProxyURL)
+		return &dev
+	}
+	return v
+}
+
+// redactedProxyURL returns the proxy URL without the user name and
+// password it may carry.
+func redactedProxyURL(proxyURL string) string {
+	if !strings.Contains(proxyURL, "@") {
+		return proxyURL
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	return u.String()
+}
+
+// redactedEvents returns the events with the configurations they carry
+// redacted for the identity, and without those about folders it has no
+// access to.
+func redactedEvents(evs []events.Event, id guiIdentity) []events.Event {
+	if id.Role.Allows(config.GUIRoleAdmin) && !id.isScoped() {
+		return evs
+	}
+	// The events may be shared with other requests, so neither they nor
+	// the slice are changed in place.
+	res := make([]events.Event, 0, len(evs))
+	for _, ev := range evs {
+		if folder, ok := eventFolder(ev.Data); ok && !id.canAccessFolder(folder) {
+			continue
+		}
+		if cfg, ok := ev.Data.(config.Configuration); ok {
+			ev.Data = redactedConfig(cfg.Copy(), id)
+		} else if ev.Type == events.DownloadProgress && id.isScoped() {
+			// Download progress is a map keyed by folder.
+			progress := reflect.ValueOf(ev.Data)
+			filtered := reflect.MakeMap(progress.Type())
+			for _, folder := range progress.MapKeys() {
+				if id.canAccessFolder(folder.String()) {
+					filtered.SetMapIndex(folder, progress.MapIndex(folder))
+				}
+			}
+			ev.Data = filtered.Interface()
+		}
+		res = append(res, ev)
+	}
+	return res
+}
+
+// redactedHistory redacts the configurations carried by the event history
+// entries for the identity, in place. The entries are read back as generic
+// JSON, so those are decoded into a configuration again first.
+func redactedHistory(entries []eventHistoryEntry, id guiIdentity) []eventHistoryEntry {
+	if id.Role.Allows(config.GUIRoleAdmin) && !id.isScoped() {
+		return entries
+	}
+	for i, e := range entries {
+		if e.Type != events.ConfigSaved {
+			continue
+		}
+		var cfg config.Configuration
+		bs, err := json.Marshal(e.Data)
+		if err == nil {
+			err = json.Unmarshal(bs, &cfg)
+		}
+		if err != nil {
+			entries[i].Data = nil
+			continue
+		}
+		entries[i].Data = redactedConfig(cfg, id)
+	}
+	return entries
+}
+
+// eventFolder returns the folder an event is about, if any.
+func eventFolder(data interface{}) (string, bool) {
+	for _, key := range []string{"folder", "folderID"} {
+		switch data := data.(type) {
+		case map[string]string:
+			if folder, ok := data[key]; ok {
+				return folder, true
+			}
+		case map[string]interface{}:
+			if folder, ok := data[key].(string); ok {
+				return folder, true
+			}
+		}
+	}
+	return "", false
+}
@@ -11,7 +11,10 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,35 +27,88 @@ import (
 )
 
 var (
-	sessions    = make(map[string]bool)
+	sessions    = make(map[string]guiSession) // by session ID
 	sessionsMut = sync.NewMutex()
+	loginLimits = newLoginLimiter()
 )
 
-func emitLoginAttempt(success bool, username string) {
-	events.Default.Log(events.LoginAttempt, map[string]interface{}{
+// A guiSession is a logged in user. The identity is looked up again on each
+// request, so that configuration changes apply to existing sessions.
+type guiSession struct {
+	username string
+	claims   map[string]interface{} // from the OIDC provider, when logged in that way
+}
+
+func (s guiSession) identity(guiCfg config.GUIConfiguration, oidcCfg config.OIDCConfiguration) (guiIdentity, bool) {
+	if s.claims != nil {
+		if guiCfg.AuthMode != config.AuthModeOIDC {
+			return guiIdentity{}, false
+		}
+		return oidcIdentity(s.claims, oidcCfg)
+	}
+	return userIdentity(s.username, guiCfg)
+}
+
+func createSession(w http.ResponseWriter, cookieName string, session guiSession) {
+	sessionid := rand.String(32)
+	sessionsMut.Lock()
+	sessions[sessionid] = session
+	sessionsMut.Unlock()
+	http.SetCookie(w, &http.Cookie{
+		Name:   cookieName,
+		Value:  sessionid,
+		Path:   "/",
+		MaxAge: 0,
+	})
+}
+
+// emitLoginAttempt reports a login, with the reason it failed if it did.
+func emitLoginAttempt(success bool, username, reason string) {
+	data := map[string]interface{}{
 		"success":  success,
 		"username": username,
-	})
+	}
+	if !success {
+		data["reason"] = reason
+	}
+	events.Default.Log(events.LoginAttempt, data)
 }
 
-func basicAuthAndSessionMiddleware(cookieName string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, next http.Handler) http.Handler {
+// basicAuthAndSessionMiddleware lets requests through with a valid API key,
+// session or login. Users with a second factor give the code in the
+// X-Syncthing-OTP header or after their password, separated by a colon.
+// Recovery codes used are passed to useRecoveryCode.
+func basicAuthAndSessionMiddleware(cookieName string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, oidcCfg config.OIDCConfiguration, useRecoveryCode func(username, hash string), next http.Handler) http.Handler {
+	var oidc *oidcProvider
+	if guiCfg.AuthMode == config.AuthModeOIDC {
+		oidc = newOIDCProvider(oidcCfg, guiCfg)
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if guiCfg.IsValidAPIKey(r.Header.Get("X-API-Key")) {
-			next.ServeHTTP(w, r)
+		if id, ok := apiKeyIdentity(r.Header.Get("X-API-Key"), guiCfg); ok {
+			next.ServeHTTP(w, withGUIIdentity(r, id))
 			return
 		}
 
 		cookie, err := r.Cookie(cookieName)
 		if err == nil && cookie != nil {
 			sessionsMut.Lock()
-			_, ok := sessions[cookie.Value]
+			session, ok := sessions[cookie.Value]
 			sessionsMut.Unlock()
 			if ok {
-				next.ServeHTTP(w, r)
-				return
+				// The user may have been removed since logging in.
+				if id, ok := session.identity(guiCfg, oidcCfg); ok {
+					next.ServeHTTP(w, withGUIIdentity(r, id))
+					return
+				}
 			}
 		}
 
+		if oidc != nil {
+			oidc.handle(w, r, cookieName, guiCfg)
+			return
+		}
+
 		httpl.Debugln("Sessionless HTTP request with authentication; this is expensive.")
 
 		error := func() {
@@ -83,6 +139,17 @@ func basicAuthAndSessionMiddleware(cookieName string, guiCfg config.GUIConfigura
 		username := string(fields[0])
 		password := string(fields[1])
 
+		ip := remoteIP(r)
+		if until := loginLimits.lockedUntil(ip, username, time.Now()); !until.IsZero() {
+			lockedOut(w, username, until)
+			return
+		}
+
+		code := r.Header.Get(secondFactorHeader)
+		if secret, _ := guiCfg.SecondFactor(username); secret != "" && code == "" {
+			password, code = splitSecondFactor(password)
+		}
+
 		authOk := auth(username, password, guiCfg, ldapCfg)
 		if !authOk {
 			usernameIso := string(iso88591ToUTF8([]byte(username)))
@@ -93,33 +160,195 @@ func basicAuthAndSessionMiddleware(cookieName string, guiCfg config.GUIConfigura
 			}
 		}
 
+		var id guiIdentity
+		if authOk {
+			id, authOk = userIdentity(username, guiCfg)
+		}
 		if !authOk {
-			emitLoginAttempt(false, username)
+			loginLimits.failed(ip, username, guiCfg, time.Now())
+			emitLoginAttempt(false, username, "invalid credentials")
 			error()
 			return
 		}
+		if !checkSecondFactor(username, code, guiCfg, useRecoveryCode) {
+			loginLimits.failed(ip, username, guiCfg, time.Now())
+			emitLoginAttempt(false, username, "invalid second factor")
+			error()
+			return
+		}
+		loginLimits.succeeded(username)
+
+		createSession(w, cookieName, guiSession{username: username})
+		emitLoginAttempt(true, username, "")
+		next.ServeHTTP(w, withGUIIdentity(r, id))
+	})
+}
 
-		sessionid := rand.String(32)
-		sessionsMut.Lock()
-		sessions[sessionid] = true
-		sessionsMut.Unlock()
-		http.SetCookie(w, &http.Cookie{
-			Name:   cookieName,
-			Value:  sessionid,
-			MaxAge: 0,
-		})
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
 
-		emitLoginAttempt(true, username)
-		next.ServeHTTP(w, r)
+// A loginRecord is the recent failed logins from an IP address or for a
+// user name.
+type loginRecord struct {
+	IP          string    `json:"ip,omitempty"`
+	User        string    `json:"user,omitempty"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"lastFailure"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// loginLimiter locks out logins from an IP address, and logins for a user
+// name, after a number of failed ones, for a time that doubles with each
+// further failure. The two are counted separately, such that neither
+// trying many user names from one address nor one user name from many
+// addresses gets around the limit. The failures for a user name are
+// forgotten after a successful login, those from an address only when
+// there has been none for the maximum lockout time, so that logging in to
+// one account doesn't allow trying further ones.
+type loginLimiter struct {
+	byIP   map[string]*loginRecord
+	byUser map[string]*loginRecord
+	mut    sync.Mutex
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{
+		byIP:   make(map[string]*loginRecord),
+		byUser: make(map[string]*loginRecord),
+		mut:    sync.NewMutex(),
+	}
+}
+
+// lockedUntil returns when the lockout of the IP address or the user name,
+// whichever is later, ends, or the zero time when neither is locked out.
+// An empty user name only checks the IP address.
+func (lim *loginLimiter) lockedUntil(ip, username string, now time.Time) time.Time {
+	lim.mut.Lock()
+	defer lim.mut.Unlock()
+	var until time.Time
+	if rec, ok := lim.byIP[ip]; ok && rec.LockedUntil.After(now) {
+		until = rec.LockedUntil
+	}
+	if rec, ok := lim.byUser[username]; ok && username != "" && rec.LockedUntil.After(until) && rec.LockedUntil.After(now) {
+		until = rec.LockedUntil
+	}
+	return until
+}
+
+// failed counts a failed login from the IP address, and for the user name
+// unless it is empty.
+func (lim *loginLimiter) failed(ip, username string, guiCfg config.GUIConfiguration, now time.Time) {
+	if guiCfg.LoginFailureLimit <= 0 {
+		return
+	}
+	maxLockout := time.Duration(guiCfg.LoginLockoutMaxS) * time.Second
+
+	lim.mut.Lock()
+	defer lim.mut.Unlock()
+
+	for _, recs := range []map[string]*loginRecord{lim.byIP, lim.byUser} {
+		for key, rec := range recs {
+			if now.Sub(rec.LastFailure) > maxLockout && !rec.LockedUntil.After(now) {
+				delete(recs, key)
+			}
+		}
+	}
+
+	rec, ok := lim.byIP[ip]
+	if !ok {
+		rec = &loginRecord{IP: ip}
+		lim.byIP[ip] = rec
+	}
+	rec.failed(guiCfg, now)
+
+	if username != "" {
+		rec, ok := lim.byUser[username]
+		if !ok {
+			rec = &loginRecord{User: username}
+			lim.byUser[username] = rec
+		}
+		rec.failed(guiCfg, now)
+	}
+}
+
+func (rec *loginRecord) failed(guiCfg config.GUIConfiguration, now time.Time) {
+	rec.Failures++
+	rec.LastFailure = now
+	if excess := rec.Failures - guiCfg.LoginFailureLimit; excess >= 0 {
+		maxLockout := time.Duration(guiCfg.LoginLockoutMaxS) * time.Second
+		lockout := time.Duration(guiCfg.LoginLockoutS) * time.Second
+		for i := 0; i < excess && lockout < maxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > maxLockout {
+			lockout = maxLockout
+		}
+		rec.LockedUntil = now.Add(lockout)
+	}
+}
+
+// succeeded forgets the failures for the user name.
+func (lim *loginLimiter) succeeded(username string) {
+	lim.mut.Lock()
+	delete(lim.byUser, username)
+	lim.mut.Unlock()
+}
+
+// list returns copies of the current records, those of IP addresses
+// first, ordered by address and user name.
+func (lim *loginLimiter) list() []loginRecord {
+	lim.mut.Lock()
+	defer lim.mut.Unlock()
+	recs := make([]loginRecord, 0, len(lim.byIP)+len(lim.byUser))
+	for _, rec := range lim.byIP {
+		recs = append(recs, *rec)
+	}
+	for _, rec := range lim.byUser {
+		recs = append(recs, *rec)
+	}
+	sort.Slice(recs, func(a, b int) bool {
+		if recs[a].User != recs[b].User {
+			return recs[a].User < recs[b].User
+		}
+		return recs[a].IP < recs[b].IP
 	})
+	return recs
+}
+
+// clear forgets the failures from the IP address and for the user name,
+// or all failures if neither is given.
+func (lim *loginLimiter) clear(ip, username string) {
+	lim.mut.Lock()
+	defer lim.mut.Unlock()
+	if ip == "" && username == "" {
+		lim.byIP = make(map[string]*loginRecord)
+		lim.byUser = make(map[string]*loginRecord)
+		return
+	}
+	delete(lim.byIP, ip)
+	delete(lim.byUser, username)
+}
+
+// lockedOut responds to a login that's locked out until the given time.
+func lockedOut(w http.ResponseWriter, username string, until time.Time) {
+	emitLoginAttempt(false, username, "locked out")
+	w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until)/time.Second)+1))
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 }
 
 func auth(username string, password string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration) bool {
 	if guiCfg.AuthMode == config.AuthModeLDAP {
 		return authLDAP(username, password, ldapCfg)
-	} else {
-		return authStatic(username, password, guiCfg.User, guiCfg.Password)
 	}
+	if user, ok := guiCfg.GUIUser(username); ok {
+		return authStatic(username, password, user.Name, user.Password)
+	}
+	return authStatic(username, password, guiCfg.User, guiCfg.Password)
 }
 
 func authStatic(username string, password string, configUser string, configPassword string) bool {
@@ -7,8 +7,13 @@
 package main
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/syncthing/syncthing/lib/config"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -38,3 +43,132 @@ func TestStaticAuthPasswordFail(t *testing.T) {
 		t.Fatalf("should fail auth")
 	}
 }
+
+func TestLoginLimiter(t *testing.T) {
+	lim := newLoginLimiter()
+	guiCfg := config.GUIConfiguration{
+		LoginFailureLimit: 3,
+		LoginLockoutS:     60,
+		LoginLockoutMaxS:  300,
+	}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		lim.failed("192.0.2.1", "user", guiCfg, now)
+	}
+	if until := lim.lockedUntil("192.0.2.1", "user", now); !until.IsZero() {
+		t.Fatal("Should not be locked out before the limit, until", until)
+	}
+
+	// The lockout doubles with each failure, up to the maximum, and
+	// applies to the IP address and to the user name.
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		lim.failed("192.0.2.1", "user", guiCfg, now)
+		if until := lim.lockedUntil("192.0.2.1", "user", now); until.Sub(now) != expected {
+			t.Errorf("User should be locked out for %v, not %v", expected, until.Sub(now))
+		}
+		if until := lim.lockedUntil("192.0.2.1", "other", now); until.Sub(now) != expected {
+			t.Errorf("Other users from the IP address should be locked out for %v, not %v", expected, until.Sub(now))
+		}
+		if until := lim.lockedUntil("192.0.2.2", "user", now); until.Sub(now) != expected {
+			t.Errorf("The user from other IP addresses should be locked out for %v, not %v", expected, until.Sub(now))
+		}
+		if until := lim.lockedUntil("192.0.2.2", "other", now); !until.IsZero() {
+			t.Error("Other users from other IP addresses should not be locked out, until", until)
+		}
+	}
+	if until := lim.lockedUntil("192.0.2.1", "user", now.Add(6*time.Minute)); !until.IsZero() {
+		t.Error("Lockout should have ended, not last until", until)
+	}
+
+	lim.failed("192.0.2.2", "other", guiCfg, now)
+	if recs := lim.list(); len(recs) != 4 || recs[0].IP != "192.0.2.1" || recs[1].IP != "192.0.2.2" || recs[2].User != "other" || recs[3].User != "user" {
+		t.Errorf("Unexpected records %v", recs)
+	}
+	lim.clear("192.0.2.1", "user")
+	if recs := lim.list(); len(recs) != 2 || recs[0].IP != "192.0.2.2" || recs[1].User != "other" {
+		t.Errorf("Unexpected records %v", recs)
+	}
+
+	// A successful login forgets the failures for the user name, but not
+	// those from the IP address.
+	lim.succeeded("other")
+	if recs := lim.list(); len(recs) != 1 || recs[0].IP != "192.0.2.2" {
+		t.Errorf("Unexpected records %v", recs)
+	}
+	lim.clear("", "")
+	if recs := lim.list(); len(recs) != 0 {
+		t.Errorf("Unexpected records %v", recs)
+	}
+}
+
+func TestLoginLimiterSpray(t *testing.T) {
+	guiCfg := config.GUIConfiguration{
+		LoginFailureLimit: 3,
+		LoginLockoutS:     60,
+		LoginLockoutMaxS:  300,
+	}
+	now := time.Now()
+
+	// One IP address trying many user names.
+	lim := newLoginLimiter()
+	for i := 0; i < 10; i++ {
+		lim.failed("192.0.2.1", fmt.Sprintf("user%d", i), guiCfg, now)
+	}
+	if until := lim.lockedUntil("192.0.2.1", "user100", now); until.IsZero() {
+		t.Error("The IP address should be locked out for further user names")
+	}
+	if until := lim.lockedUntil("192.0.2.2", "user100", now); !until.IsZero() {
+		t.Error("Other IP addresses should not be locked out, until", until)
+	}
+
+	// Many IP addresses trying one user name.
+	lim = newLoginLimiter()
+	for i := 0; i < 10; i++ {
+		lim.failed(fmt.Sprintf("192.0.2.%d", i), "admin", guiCfg, now)
+	}
+	if until := lim.lockedUntil("192.0.2.100", "admin", now); until.IsZero() {
+		t.Error("The user name should be locked out from further IP addresses")
+	}
+	if until := lim.lockedUntil("192.0.2.100", "other", now); !until.IsZero() {
+		t.Error("Other user names should not be locked out, until", until)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	guiCfg := config.GUIConfiguration{
+		User:              "lockeduser",
+		Password:          string(passwordHashBytes),
+		LoginFailureLimit: 2,
+		LoginLockoutS:     60,
+		LoginLockoutMaxS:  3600,
+	}
+	handler := basicAuthAndSessionMiddleware("sessionid-test", guiCfg, config.LDAPConfiguration{}, config.OIDCConfiguration{}, nil,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer loginLimits.clear("", "")
+
+	login := func(password string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.SetBasicAuth("lockeduser", password)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := login("wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatal("Wrong password should fail, not", w.Code)
+		}
+	}
+
+	// Locked out, even with the right password.
+	w := login("pass")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatal("Should be locked out, not", w.Code)
+	}
+
+	loginLimits.clear(remoteIP(httptest.NewRequest("GET", "/", nil)), "lockeduser")
+	if w := login("pass"); w.Code != http.StatusOK {
+		t.Fatal("Login should succeed after clearing, not", w.Code)
+	}
+}
@@ -0,0 +1,714 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/util"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+const (
+	configPath        = "/rest/config/"
+	configFoldersPath = configPath + "folders/"
+	configDevicesPath = configPath + "devices/"
+	configLockedPath  = configPath + "locked"
+	configHistoryPath = configPath + "history"
+)
+
+var unknownFieldExpr = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+
+// A configFieldError is a problem with the value of a single field, named
+// as in the JSON representation, e.g. "devices[1].deviceID".
+type configFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// A configResource is a part of the configuration that is read and changed
+// on its own through the config endpoints. The values passed around are
+// pointers to the configuration struct of the resource.
+type configResource struct {
+	locked   string                                     // the prefix of its fields in config.Wrapper.Locked
+	current  interface{}                                // the current value, or nil if it doesn't exist
+	fresh    func() interface{}                         // a new value with the defaults set, for PUT
+	edit     func() interface{}                         // a copy of the current value, for PATCH
+	validate func(v interface{}) []configFieldError     // problems with the value to set
+	set      func(v interface{}) (config.Waiter, error) // makes the value active
+	remove   func() (config.Waiter, error)              // removes the resource, nil if that's not possible
+}
+
+// serveConfig handles the resource level config endpoints. The folders and
+// devices are listed at /rest/config/folders and /rest/config/devices, and
+// each can be read, put, patched and deleted at the same path followed by
+// its ID. The options, GUI settings and templates for new folders and
+// devices at /rest/config/options, /rest/config/gui and
+// /rest/config/defaults/{folder,device} can be read, put and patched. PUT
+// replaces or creates the resource, while PATCH only changes the fields
+// given. A list given in a PATCH, like the devices of a folder, replaces the
+// whole list; it's not merged with the current one. Responses carry an
+// ETag which can be given in If-Match to only make the change when nobody
+// else changed the resource in between. Secrets such as proxy credentials
+// are left out for non-admins, as in the full configuration. The fields
+// set by the configuration overlay, listed at /rest/config/locked, can't
+// be changed.
+// The saved configurations are listed at /rest/config/history, see
+// getConfigHistory.
+func (s *apiService) serveConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case configLockedPath:
+		s.getConfigLocked(w, r)
+		return
+	case configHistoryPath:
+		s.getConfigHistory(w, r)
+		return
+	case configHistoryPath + "/diff":
+		s.getConfigHistoryDiff(w, r)
+		return
+	case configHistoryPath + "/rollback":
+		s.postConfigHistoryRollback(w, r)
+		return
+	case strings.TrimSuffix(configFoldersPath, "/"):
+		s.getConfigFolders(w, r)
+		return
+	case strings.TrimSuffix(configDevicesPath, "/"):
+		s.getConfigDevices(w, r)
+		return
+	}
+
+	// Changes are serialized with those to the whole config, so that the
+	// precondition still holds when the change is made.
+	if r.Method != "GET" {
+		s.systemConfigMut.Lock()
+		defer s.systemConfigMut.Unlock()
+	}
+
+	res, ok, err := s.configResource(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	etag := ""
+	if res.current != nil {
+		etag = configETag(res.current)
+	}
+
+	switch r.Method {
+	case "GET":
+		if res.current == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		current := res.current
+		if id, ok := guiIdentityFrom(r); ok {
+			current = redactedConfigResource(current, id)
+		}
+		sendJSON(w, current)
+		return
+	case "PUT", "PATCH":
+	case "DELETE":
+		if res.remove == nil {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !configPreconditions(r, etag) {
+		http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	}
+
+	if r.Method == "DELETE" {
+		if res.current == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		if errs := lockedFieldErrors(s.cfg.Locked(), res.locked, res.current, nil); len(errs) > 0 {
+			sendConfigErrors(w, "Locked configuration", errs)
+			return
+		}
+		s.applyConfigChange(w, r, res.remove)
+		return
+	}
+
+	var to interface{}
+	switch {
+	case r.Method == "PUT":
+		to = res.fresh()
+	case res.current == nil:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	default:
+		to = res.edit()
+	}
+
+	bs, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(to); err != nil {
+		sendConfigErrors(w, "Decoding request", []configFieldError{decodeFieldError(err)})
+		return
+	}
+	if errs := res.validate(to); len(errs) > 0 {
+		sendConfigErrors(w, "Invalid configuration", errs)
+		return
+	}
+	if errs := lockedFieldErrors(s.cfg.Locked(), res.locked, res.current, to); len(errs) > 0 {
+		sendConfigErrors(w, "Locked configuration", errs)
+		return
+	}
+
+	if !s.applyConfigChange(w, r, func() (config.Waiter, error) { return res.set(to) }) {
+		return
+	}
+
+	// Answer with the resource as it ended up in the config, which may
+	// differ from what was given, e.g. in the order of devices.
+	res, _, _ = s.configResource(r.URL.Path)
+	w.Header().Set("ETag", configETag(res.current))
+	if etag == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+	}
+	sendJSON(w, res.current)
+}
+
+// configResource returns the resource for the path, which need not exist
+// yet when it's a folder or device, and false if the path is not a
+// resource.
+func (s *apiService) configResource(path string) (configResource, bool, error) {
+	switch {
+	case strings.HasPrefix(path, configFoldersPath):
+		id := strings.TrimPrefix(path, configFoldersPath)
+		if id == "" {
+			return configResource{}, false, nil
+		}
+		return s.folderResource(id), true, nil
+
+	case strings.HasPrefix(path, configDevicesPath):
+		id, err := protocol.DeviceIDFromString(strings.TrimPrefix(path, configDevicesPath))
+		if err != nil {
+			return configResource{}, false, err
+		}
+		return s.deviceResource(id), true, nil
+
+	case path == configPath+"options":
+		opts := s.cfg.Options()
+		return configResource{
+			locked:  "options",
+			current: &opts,
+			fresh: func() interface{} {
+				var opts config.OptionsConfiguration
+				util.SetDefaults(&opts)
+				return &opts
+			},
+			edit: func() interface{} {
+				c := opts.Copy()
+				return &c
+			},
+			validate: func(v interface{}) []configFieldError {
+				return validateOptions(*v.(*config.OptionsConfiguration))
+			},
+			set: func(v interface{}) (config.Waiter, error) {
+				return s.cfg.SetOptions(*v.(*config.OptionsConfiguration))
+			},
+		}, true, nil
+
+	case path == configPath+"defaults/folder":
+		defaults := s.cfg.Defaults()
+		return configResource{
+			current: &defaults.Folder,
+			fresh: func() interface{} {
+				fld := config.NewFolderConfiguration(protocol.EmptyDeviceID, "", "", fs.FilesystemTypeBasic, "")
+				fld.Devices = nil
+				return &fld
+			},
+			edit: func() interface{} {
+				c := defaults.Folder.Copy()
+				return &c
+			},
+			validate: func(v interface{}) []configFieldError {
+				return validateFolder(*v.(*config.FolderConfiguration), s.cfg.Devices())
+			},
+			set: func(v interface{}) (config.Waiter, error) {
+				defaults.Folder = *v.(*config.FolderConfiguration)
+				return s.cfg.SetDefaults(defaults)
+			},
+		}, true, nil
+
+	case path == configPath+"defaults/device":
+		defaults := s.cfg.Defaults()
+		return configResource{
+			current: &defaults.Device,
+			fresh: func() interface{} {
+				dev := config.NewDeviceConfiguration(protocol.EmptyDeviceID, "")
+				return &dev
+			},
+			edit: func() interface{} {
+				c := defaults.Device.Copy()
+				return &c
+			},
+			validate: func(v interface{}) []configFieldError {
+				return validateDevice(*v.(*config.DeviceConfiguration))
+			},
+			set: func(v interface{}) (config.Waiter, error) {
+				defaults.Device = *v.(*config.DeviceConfiguration)
+				return s.cfg.SetDefaults(defaults)
+			},
+		}, true, nil
+
+	case path == configPath+"gui":
+		gui := s.cfg.GUI()
+		return configResource{
+			locked:  "gui",
+			current: &gui,
+			fresh: func() interface{} {
+				var gui config.GUIConfiguration
+				util.SetDefaults(&gui)
+				return &gui
+			},
+			edit: func() interface{} {
+				c := gui.Copy()
+				return &c
+			},
+			validate: func(v interface{}) []configFieldError {
+				return validateGUI(*v.(*config.GUIConfiguration))
+			},
+			set: func(v interface{}) (config.Waiter, error) {
+				to := v.(*config.GUIConfiguration)
+				if err := hashGUIPasswords(to, gui.Password); err != nil {
+					return nil, err
+				}
+				return s.cfg.SetGUI(*to)
+			},
+		}, true, nil
+	}
+
+	return configResource{}, false, nil
+}
+
+func (s *apiService) folderResource(id string) configResource {
+	res := configResource{
+		locked: "folders." + id,
+		fresh: func() interface{} {
+			fld := s.cfg.Defaults().NewFolder(s.id, id, "", fs.FilesystemTypeBasic, "")
+			return &fld
+		},
+		validate: func(v interface{}) []configFieldError {
+			fld := *v.(*config.FolderConfiguration)
+			errs := validateFolder(fld, s.cfg.Devices())
+			if fld.ID != id {
+				errs = append(errs, configFieldError{"id", "must be the folder ID in the URL"})
+			}
+			if fld.Path == "" {
+				errs = append(errs, configFieldError{"path", "must be set"})
+			}
+			return errs
+		},
+		set: func(v interface{}) (config.Waiter, error) {
+			fld := *v.(*config.FolderConfiguration)
+			// The folder is always shared with ourselves.
+			for _, dev := range fld.Devices {
+				if dev.DeviceID == s.id {
+					return s.cfg.SetFolder(fld)
+				}
+			}
+			fld.Devices = append(fld.Devices, config.FolderDeviceConfiguration{DeviceID: s.id})
+			return s.cfg.SetFolder(fld)
+		},
+		remove: func() (config.Waiter, error) {
+			return s.cfg.RemoveFolder(id)
+		},
+	}
+	if fld, ok := s.cfg.Folders()[id]; ok {
+		res.current = &fld
+		res.edit = func() interface{} {
+			c := fld.Copy()
+			return &c
+		}
+	}
+	return res
+}
+
+func (s *apiService) deviceResource(id protocol.DeviceID) configResource {
+	res := configResource{
+		locked: "devices." + id.String(),
+		fresh: func() interface{} {
+			dev := s.cfg.Defaults().NewDevice(id, "")
+			return &dev
+		},
+		validate: func(v interface{}) []configFieldError {
+			dev := *v.(*config.DeviceConfiguration)
+			errs := validateDevice(dev)
+			if dev.DeviceID != id {
+				errs = append(errs, configFieldError{"deviceID", "must be the device ID in the URL"})
+			}
+			return errs
+		},
+		set: func(v interface{}) (config.Waiter, error) {
+			return s.cfg.SetDevice(*v.(*config.DeviceConfiguration))
+		},
+		remove: func() (config.Waiter, error) {
+			return s.cfg.RemoveDevice(id)
+		},
+	}
+	if dev, ok := s.cfg.Devices()[id]; ok {
+		res.current = &dev
+		res.edit = func() interface{} {
+			c := dev.Copy()
+			return &c
+		}
+	}
+	return res
+}
+
+func (s *apiService) getConfigLocked(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	locked := s.cfg.Locked()
+	if locked == nil {
+		locked = []string{}
+	}
+	sendJSON(w, locked)
+}
+
+// getConfigHistory lists the revisions of the saved configuration, newest
+// first. Each can be compared to the one before it, or the one given as
+// from, at /rest/config/history/diff?revision=... and be made the current
+// configuration again by posting to /rest/config/history/rollback?revision=...
+func (s *apiService) getConfigHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	revs, err := s.cfg.History()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	newestFirst := make([]config.Revision, len(revs))
+	for i, rev := range revs {
+		newestFirst[len(revs)-1-i] = rev
+	}
+	sendJSON(w, newestFirst)
+}
+
+func (s *apiService) getConfigHistoryDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	qs := r.URL.Query()
+	rev, err := strconv.Atoi(qs.Get("revision"))
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+	from := 0
+	if qs.Get("from") != "" {
+		if from, err = strconv.Atoi(qs.Get("from")); err != nil {
+			http.Error(w, "Invalid revision", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := s.cfg.RevisionDiff(from, rev)
+	if err == config.ErrNoSuchRevision {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, diff)
+}
+
+// postConfigHistoryRollback makes the given revision the current
+// configuration, which is checked and committed like any other change and
+// becomes a new revision of its own.
+func (s *apiService) postConfigHistoryRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rev, err := strconv.Atoi(r.URL.Query().Get("revision"))
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	s.systemConfigMut.Lock()
+	defer s.systemConfigMut.Unlock()
+
+	to, err := s.cfg.ReadRevision(rev)
+	if err == config.ErrNoSuchRevision {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.replaceAndSaveConfig(w, r, to)
+}
+
+func (s *apiService) getConfigFolders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, _ := guiIdentityFrom(r)
+	folders := make([]config.FolderConfiguration, 0, len(s.cfg.Folders()))
+	for _, fld := range s.cfg.Folders() {
+		if id.canAccessFolder(fld.ID) {
+			folders = append(folders, fld)
+		}
+	}
+	sort.Slice(folders, func(a, b int) bool {
+		return folders[a].ID < folders[b].ID
+	})
+	sendJSON(w, folders)
+}
+
+func (s *apiService) getConfigDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	devices := make([]config.DeviceConfiguration, 0, len(s.cfg.Devices()))
+	for _, dev := range s.cfg.Devices() {
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(a, b int) bool {
+		return devices[a].DeviceID.Compare(devices[b].DeviceID) == -1
+	})
+	if id, ok := guiIdentityFrom(r); ok && !id.Role.Allows(config.GUIRoleAdmin) {
+		for i := range devices {
+			devices[i].ProxyURL = redactedProxyURL(devices[i].ProxyURL)
+		}
+	}
+	sendJSON(w, devices)
+}
+
+// applyConfigChange makes and saves a change to the configuration,
+// returning false after responding with the error if that fails. The
+// configuration refusing the change is the client's fault.
+func (s *apiService) applyConfigChange(w http.ResponseWriter, r *http.Request, change func() (config.Waiter, error)) bool {
+	if wg, err := change(); err != nil {
+		l.Debugln("Changing config:", err)
+		sendConfigErrors(w, err.Error(), nil)
+		return false
+	} else {
+		wg.Wait()
+	}
+
+	if err := s.cfg.SaveBy(configAuthor(r)); err != nil {
+		l.Warnln("Saving config:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+func sendConfigErrors(w http.ResponseWriter, msg string, errs []configFieldError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	sendJSON(w, map[string]interface{}{
+		"error":  msg,
+		"fields": errs,
+	})
+}
+
+// configAuthor returns who makes a change to the configuration, for the
+// history: the user or API key, or the address when there is no
+// authentication.
+func configAuthor(r *http.Request) string {
+	if id, ok := guiIdentityFrom(r); ok && id.User != "" {
+		return id.User
+	}
+	return remoteIP(r)
+}
+
+// lockedFieldErrors returns the locked fields of the resource that differ
+// between from and to, where to is nil when the resource is to be removed.
+// The whole resource is locked when prefix itself is among the locked.
+func lockedFieldErrors(locked []string, prefix string, from, to interface{}) []configFieldError {
+	fromBs, _ := json.Marshal(from)
+	toBs, _ := json.Marshal(to)
+	var fromFields, toFields map[string]json.RawMessage
+	json.Unmarshal(fromBs, &fromFields)
+	json.Unmarshal(toBs, &toFields)
+
+	var errs []configFieldError
+	for _, path := range locked {
+		switch {
+		case path == prefix:
+			if !bytes.Equal(fromBs, toBs) {
+				errs = append(errs, configFieldError{"", "is set by the configuration overlay"})
+			}
+		case strings.HasPrefix(path, prefix+"."):
+			field := strings.TrimPrefix(path, prefix+".")
+			if to == nil || !bytes.Equal(fromFields[field], toFields[field]) {
+				errs = append(errs, configFieldError{field, "is set by the configuration overlay"})
+			}
+		}
+	}
+	return errs
+}
+
+// configETag returns the entity tag for the value, which changes whenever
+// the value does.
+func configETag(v interface{}) string {
+	bs, _ := json.Marshal(v)
+	sum := sha256.Sum256(bs)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// configPreconditions returns true if the If-Match and If-None-Match
+// headers of the request allow a change to the resource with the given
+// entity tag, which is empty when the resource doesn't exist.
+func configPreconditions(r *http.Request, etag string) bool {
+	if h := r.Header.Get("If-Match"); h != "" && !etagListMatches(h, etag) {
+		return false
+	}
+	if h := r.Header.Get("If-None-Match"); h != "" && etagListMatches(h, etag) {
+		return false
+	}
+	return true
+}
+
+func etagListMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeFieldError returns the field the JSON decoding error is about, as
+// far as it's known.
+func decodeFieldError(err error) configFieldError {
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return configFieldError{Field: typeErr.Field, Message: fmt.Sprintf("cannot be %s", typeErr.Value)}
+	}
+	if m := unknownFieldExpr.FindStringSubmatch(err.Error()); m != nil {
+		return configFieldError{Field: m[1], Message: "unknown field"}
+	}
+	return configFieldError{Message: err.Error()}
+}
+
+// validateFolder checks the settings shared by folders and the default
+// folder, while the ID and path are left to the caller.
+func validateFolder(fld config.FolderConfiguration, devices map[protocol.DeviceID]config.DeviceConfiguration) []configFieldError {
+	var errs []configFieldError
+	if fld.RescanIntervalS < 0 {
+		errs = append(errs, configFieldError{"rescanIntervalS", "must not be negative"})
+	}
+	if fld.FSWatcherDelayS < 0 {
+		errs = append(errs, configFieldError{"fsWatcherDelayS", "must not be negative"})
+	}
+	for i, dev := range fld.Devices {
+		if _, ok := devices[dev.DeviceID]; !ok {
+			errs = append(errs, configFieldError{fmt.Sprintf("devices[%d].deviceID", i), "must be a configured device"})
+		}
+	}
+	if _, ok := versioner.Factories[fld.Versioning.Type]; fld.Versioning.Type != "" && !ok {
+		errs = append(errs, configFieldError{"versioning.type", fmt.Sprintf("unknown versioning type %q", fld.Versioning.Type)})
+	}
+	return errs
+}
+
+// validateDevice checks the settings shared by devices and the default
+// device, while the ID is left to the caller.
+func validateDevice(dev config.DeviceConfiguration) []configFieldError {
+	var errs []configFieldError
+	for i, addr := range dev.Addresses {
+		if addr != "dynamic" && !isAddressURL(addr) {
+			errs = append(errs, configFieldError{fmt.Sprintf("addresses[%d]", i), `must be "dynamic" or an address like tcp://192.0.2.42:22000`})
+		}
+	}
+	if dev.MaxSendKbps < 0 {
+		errs = append(errs, configFieldError{"maxSendKbps", "must not be negative"})
+	}
+	if dev.MaxRecvKbps < 0 {
+		errs = append(errs, configFieldError{"maxRecvKbps", "must not be negative"})
+	}
+	return errs
+}
+
+func validateOptions(opts config.OptionsConfiguration) []configFieldError {
+	var errs []configFieldError
+	for i, addr := range opts.ListenAddresses {
+		if addr != "default" && !isAddressURL(addr) {
+			errs = append(errs, configFieldError{fmt.Sprintf("listenAddresses[%d]", i), `must be "default" or an address like tcp://0.0.0.0:22000`})
+		}
+	}
+	if opts.MaxSendKbps < 0 {
+		errs = append(errs, configFieldError{"maxSendKbps", "must not be negative"})
+	}
+	if opts.MaxRecvKbps < 0 {
+		errs = append(errs, configFieldError{"maxRecvKbps", "must not be negative"})
+	}
+	return errs
+}
+
+func validateGUI(gui config.GUIConfiguration) []configFieldError {
+	var errs []configFieldError
+	if gui.RawAddress == "" {
+		errs = append(errs, configFieldError{"address", "must be set"})
+	}
+	names := make(map[string]bool)
+	for i, user := range gui.Users {
+		if user.Name == "" {
+			errs = append(errs, configFieldError{fmt.Sprintf("users[%d].name", i), "must be set"})
+		} else if names[user.Name] || user.Name == gui.User {
+			errs = append(errs, configFieldError{fmt.Sprintf("users[%d].name", i), "must be unique"})
+		}
+		names[user.Name] = true
+	}
+	return errs
+}
+
+func isAddressURL(addr string) bool {
+	uri, err := url.Parse(addr)
+	return err == nil && uri.Scheme != "" && uri.Host != ""
+}
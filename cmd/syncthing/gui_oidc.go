@@ -0,0 +1,553 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/rand"
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+const (
+	oidcCallbackPath = "/oidc/callback"
+	oidcLoginTimeout = 10 * time.Minute // how long a login at the provider may take
+	oidcMaxLogins    = 1000             // pending logins kept, anyone can start one
+)
+
+var (
+	errOIDCState     = errors.New("unknown or expired login state")
+	errOIDCBrowser   = errors.New("login state from another browser")
+	errOIDCNoRole    = errors.New("no role mapping matches the user")
+	errOIDCBadToken  = errors.New("malformed ID token")
+	errOIDCNoIDToken = errors.New("no ID token in token response")
+)
+
+// The parts of the provider's discovery document that we use.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// A pending login, from the redirect to the provider until the callback.
+type oidcLogin struct {
+	nonce    string
+	verifier string // the PKCE code verifier
+	next     string // where to go after logging in
+	expires  time.Time
+}
+
+var (
+	// The pending logins by state. They're kept outside of the providers,
+	// which are created again whenever the GUI configuration changes.
+	oidcLogins    = make(map[string]oidcLogin)
+	oidcLoginsMut = sync.NewMutex()
+)
+
+// oidcProvider logs in users with the authorization code flow against an
+// OpenID Connect provider. Only RS256 signed ID tokens are supported.
+type oidcProvider struct {
+	cfg         config.OIDCConfiguration
+	redirectURL string
+	client      *http.Client
+
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey // by key ID
+	mut       sync.Mutex
+}
+
+func newOIDCProvider(cfg config.OIDCConfiguration, guiCfg config.GUIConfiguration) *oidcProvider {
+	redirectURL := cfg.RedirectURL
+	if redirectURL == "" {
+		redirectURL = strings.TrimSuffix(guiCfg.URL(), "/") + oidcCallbackPath
+	}
+	return &oidcProvider{
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		keys:        make(map[string]*rsa.PublicKey),
+		mut:         sync.NewMutex(),
+	}
+}
+
+// handle takes care of requests without a session: the callback from the
+// provider creates one, REST requests are refused and everything else is
+// redirected to the provider to log in.
+func (p *oidcProvider) handle(w http.ResponseWriter, r *http.Request, cookieName string, guiCfg config.GUIConfiguration) {
+	switch {
+	case r.URL.Path == oidcCallbackPath:
+		// Failures before we know who logs in only count for the IP
+		// address.
+		ip := remoteIP(r)
+		if until := loginLimits.lockedUntil(ip, "", time.Now()); !until.IsZero() {
+			lockedOut(w, "", until)
+			return
+		}
+
+		claims, next, err := p.callback(w, r, cookieName)
+		var id guiIdentity
+		if err == nil {
+			var ok bool
+			if id, ok = oidcIdentity(claims, p.cfg); !ok {
+				err = errOIDCNoRole
+			}
+		}
+		if err == nil {
+			if until := loginLimits.lockedUntil(ip, id.User, time.Now()); !until.IsZero() {
+				lockedOut(w, id.User, until)
+				return
+			}
+		}
+		if err != nil {
+			l.Infof("OIDC login for %q: %v", id.User, err)
+			loginLimits.failed(ip, id.User, guiCfg, time.Now())
+			emitLoginAttempt(false, id.User, err.Error())
+			http.Error(w, "Not Authorized", http.StatusUnauthorized)
+			return
+		}
+		loginLimits.succeeded(id.User)
+
+		createSession(w, cookieName, guiSession{username: id.User, claims: claims})
+		emitLoginAttempt(true, id.User, "")
+		http.Redirect(w, r, next, http.StatusFound)
+
+	case strings.HasPrefix(r.URL.Path, "/rest/"):
+		http.Error(w, "Not Authorized", http.StatusUnauthorized)
+
+	default:
+		p.redirectToLogin(w, r, cookieName)
+	}
+}
+
+// oidcStateCookie returns the name of the cookie that ties a login to the
+// browser that started it, so that nobody else can complete it.
+func oidcStateCookie(cookieName string) string {
+	return cookieName + "-oidcstate"
+}
+
+// addOIDCLoginLocked adds a pending login, after removing the expired ones
+// and, when there are too many, the oldest.
+func addOIDCLoginLocked(state string, login oidcLogin, now time.Time) {
+	for s, pending := range oidcLogins {
+		if now.After(pending.expires) {
+			delete(oidcLogins, s)
+		}
+	}
+	for len(oidcLogins) >= oidcMaxLogins {
+		var oldest string
+		for s, pending := range oidcLogins {
+			if oldest == "" || pending.expires.Before(oidcLogins[oldest].expires) {
+				oldest = s
+			}
+		}
+		delete(oidcLogins, oldest)
+	}
+	oidcLogins[state] = login
+}
+
+func (p *oidcProvider) redirectToLogin(w http.ResponseWriter, r *http.Request, cookieName string) {
+	disc, err := p.getDiscovery()
+	if err != nil {
+		l.Warnln("OIDC discovery:", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	// Only ever return to a path on this server.
+	next := r.URL.RequestURI()
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/"
+	}
+
+	state := rand.String(32)
+	now := time.Now()
+	login := oidcLogin{
+		nonce:    rand.String(32),
+		verifier: rand.String(64),
+		next:     next,
+		expires:  now.Add(oidcLoginTimeout),
+	}
+	oidcLoginsMut.Lock()
+	addOIDCLoginLocked(state, login, now)
+	oidcLoginsMut.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie(cookieName),
+		Value:    state,
+		Path:     oidcCallbackPath,
+		MaxAge:   int(oidcLoginTimeout / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	scopes := []string{"openid"}
+	for _, scope := range p.cfg.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {login.nonce},
+		"code_challenge":        {pkceChallenge(login.verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, disc.AuthorizationEndpoint+sep+params.Encode(), http.StatusFound)
+}
+
+// callback completes a login started in the same browser, returning the
+// claims of the verified ID token and where to go next.
+func (p *oidcProvider) callback(w http.ResponseWriter, r *http.Request, cookieName string) (map[string]interface{}, string, error) {
+	qs := r.URL.Query()
+	state := qs.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie(cookieName))
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return nil, "", errOIDCBrowser
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   oidcStateCookie(cookieName),
+		Path:   oidcCallbackPath,
+		MaxAge: -1,
+	})
+
+	oidcLoginsMut.Lock()
+	login, ok := oidcLogins[state]
+	delete(oidcLogins, state)
+	oidcLoginsMut.Unlock()
+
+	if e := qs.Get("error"); e != "" {
+		return nil, "", fmt.Errorf("provider returned %s: %s", e, qs.Get("error_description"))
+	}
+	if !ok || time.Now().After(login.expires) {
+		return nil, "", errOIDCState
+	}
+
+	disc, err := p.getDiscovery()
+	if err != nil {
+		return nil, "", err
+	}
+	rawToken, err := p.exchange(disc, qs.Get("code"), login.verifier)
+	if err != nil {
+		return nil, "", err
+	}
+	claims, err := p.verify(disc, rawToken, login.nonce)
+	if err != nil {
+		return nil, "", err
+	}
+	return claims, login.next, nil
+}
+
+// exchange returns the raw ID token given for the authorization code.
+func (p *oidcProvider) exchange(disc oidcDiscovery, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest("POST", disc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token response: %s: %s", resp.Status, token.Error)
+	}
+	if token.IDToken == "" {
+		return "", errOIDCNoIDToken
+	}
+	return token.IDToken, nil
+}
+
+// verify checks the signature and claims of the ID token and returns the
+// claims.
+func (p *oidcProvider) verify(disc oidcDiscovery, rawToken, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errOIDCBadToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported ID token algorithm %q", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errOIDCBadToken
+	}
+	key, err := p.getKey(disc, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig); err != nil {
+		return nil, fmt.Errorf("ID token signature: %v", err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if iss, _ := claims["iss"].(string); iss != disc.Issuer {
+		return nil, fmt.Errorf("ID token from unexpected issuer %q", iss)
+	}
+	if !stringInSlice(p.cfg.ClientID, claimStrings(claims["aud"])) {
+		return nil, errors.New("ID token for another audience")
+	}
+	if exp, ok := claims["exp"].(float64); !ok || time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("ID token expired")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, errors.New("ID token with unexpected nonce")
+	}
+	return claims, nil
+}
+
+func (p *oidcProvider) getDiscovery() (oidcDiscovery, error) {
+	p.mut.Lock()
+	disc := p.discovery
+	p.mut.Unlock()
+	if disc != nil {
+		return *disc, nil
+	}
+
+	disc = new(oidcDiscovery)
+	if err := p.getJSON(strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", disc); err != nil {
+		return oidcDiscovery{}, err
+	}
+	if strings.TrimSuffix(disc.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return oidcDiscovery{}, fmt.Errorf("discovery document for unexpected issuer %q", disc.Issuer)
+	}
+
+	p.mut.Lock()
+	p.discovery = disc
+	p.mut.Unlock()
+	return *disc, nil
+}
+
+// getKey returns the provider's signing key with the given ID, fetching
+// the keys again when it's not known, as they may have been rotated.
+func (p *oidcProvider) getKey(disc oidcDiscovery, kid string) (*rsa.PublicKey, error) {
+	p.mut.Lock()
+	key, ok := p.lookupKey(kid)
+	p.mut.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(disc.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.keys = keys
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown ID token signing key %q", kid)
+}
+
+// lookupKey returns the key with the given ID, or the only key if no ID is
+// given. The mutex must be held.
+func (p *oidcProvider) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *oidcProvider) getJSON(url string, into interface{}) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+// pkceChallenge returns the S256 code challenge for the code verifier, as
+// in RFC 7636.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func decodeJWTSegment(seg string, into interface{}) error {
+	bs, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return errOIDCBadToken
+	}
+	if err := json.Unmarshal(bs, into); err != nil {
+		return errOIDCBadToken
+	}
+	return nil
+}
+
+// oidcIdentity returns the identity of a user logged in with the given
+// claims. On each folder the user has the highest role of the matching
+// role mappings that cover it, where mappings without folders cover all of
+// them. Requests about no folder get the role of those, or without any the
+// lowest role the user has on a folder. The user can't log in when no
+// mapping matches.
+func oidcIdentity(claims map[string]interface{}, cfg config.OIDCConfiguration) (guiIdentity, bool) {
+	username, _ := claims[cfg.UsernameClaim].(string)
+	if username == "" {
+		username, _ = claims["sub"].(string)
+	}
+	id := guiIdentity{User: username}
+
+	values := claimStrings(claims[cfg.RoleClaim])
+	folderRoles := make(map[string]config.GUIRole)
+	matched, allFolders := false, false
+	for _, m := range cfg.RoleMappings {
+		if !stringInSlice(m.Value, values) {
+			continue
+		}
+		matched = true
+		if len(m.Folders) == 0 {
+			if !allFolders || m.Role > id.Role {
+				id.Role = m.Role
+			}
+			allFolders = true
+			continue
+		}
+		for _, folder := range m.Folders {
+			if role, ok := folderRoles[folder]; !ok || m.Role > role {
+				folderRoles[folder] = m.Role
+			}
+		}
+	}
+
+	if !allFolders && len(folderRoles) > 0 {
+		id.Role = config.GUIRoleAdmin
+		for folder, role := range folderRoles {
+			id.Folders = append(id.Folders, folder)
+			if role < id.Role {
+				id.Role = role
+			}
+		}
+		sort.Strings(id.Folders)
+	}
+	for folder, role := range folderRoles {
+		if role > id.Role {
+			if id.FolderRoles == nil {
+				id.FolderRoles = make(map[string]config.GUIRole)
+			}
+			id.FolderRoles[folder] = role
+		}
+	}
+
+	return id, matched && username != ""
+}
+
+// claimStrings returns a claim that is either a string or a list of strings
+// as a list.
+func claimStrings(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case string:
+		return []string{claim}
+	case []interface{}:
+		var strs []string
+		for _, v := range claim {
+			if s, ok := v.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	default:
+		return nil
+	}
+}
+
+func stringInSlice(s string, ss []string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,380 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/rand"
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+// mockOIDCIssuer is an OpenID Connect provider that immediately approves
+// every login, as the user given by claims.
+type mockOIDCIssuer struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+	nonces map[string]string // by code
+	pkce   map[string]string // code challenges by code
+	mut    sync.Mutex
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &mockOIDCIssuer{
+		key:    key,
+		nonces: make(map[string]string),
+		pkce:   make(map[string]string),
+		mut:    sync.NewMutex(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, map[string]string{
+			"issuer":                 iss.URL,
+			"authorization_endpoint": iss.URL + "/authorize",
+			"token_endpoint":         iss.URL + "/token",
+			"jwks_uri":               iss.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		qs := r.URL.Query()
+		code := rand.String(16)
+		iss.mut.Lock()
+		iss.nonces[code] = qs.Get("nonce")
+		if qs.Get("code_challenge_method") == "S256" {
+			iss.pkce[code] = qs.Get("code_challenge")
+		}
+		iss.mut.Unlock()
+		http.Redirect(w, r, qs.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {qs.Get("state")}}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "syncthing" || pass != "secret" {
+			http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		iss.mut.Lock()
+		nonce, ok := iss.nonces[r.FormValue("code")]
+		challenge := iss.pkce[r.FormValue("code")]
+		iss.mut.Unlock()
+		if !ok || challenge == "" || pkceChallenge(r.FormValue("code_verifier")) != challenge {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		sendJSON(w, map[string]string{"id_token": iss.idToken(t, nonce)})
+	})
+	iss.Server = httptest.NewServer(mux)
+	return iss
+}
+
+func (iss *mockOIDCIssuer) idToken(t *testing.T, nonce string) string {
+	claims := map[string]interface{}{
+		"iss":   iss.URL,
+		"aud":   "syncthing",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+	for k, v := range iss.claims {
+		claims[k] = v
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	iss := newMockOIDCIssuer(t)
+	defer iss.Close()
+
+	// The GUI answers with the identity of the logged in user.
+	gui := httptest.NewUnstartedServer(nil)
+	guiCfg := config.GUIConfiguration{AuthMode: config.AuthModeOIDC}
+	oidcCfg := config.OIDCConfiguration{
+		Issuer:        iss.URL,
+		ClientID:      "syncthing",
+		ClientSecret:  "secret",
+		RedirectURL:   "http://" + gui.Listener.Addr().String() + oidcCallbackPath,
+		UsernameClaim: "preferred_username",
+		RoleClaim:     "groups",
+		RoleMappings: []config.OIDCRoleMapping{
+			{Value: "syncthing-users", Role: config.GUIRoleReadOnly, Folders: []string{"default"}},
+			{Value: "syncthing-admins", Role: config.GUIRoleAdmin},
+		},
+	}
+	gui.Config.Handler = basicAuthAndSessionMiddleware("sessionid-test", guiCfg, config.LDAPConfiguration{}, oidcCfg, nil,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, _ := guiIdentityFrom(r)
+			sendJSON(w, id)
+		}))
+	gui.Start()
+	defer gui.Close()
+
+	sub := events.Default.Subscribe(events.LoginAttempt)
+	defer events.Default.Unsubscribe(sub)
+
+	login := func(claims map[string]interface{}) (*http.Response, *http.Client) {
+		t.Helper()
+		iss.claims = claims
+		jar, _ := cookiejar.New(nil)
+		cli := &http.Client{Jar: jar, Timeout: 10 * time.Second}
+		resp, err := cli.Get(gui.URL + "/some/page")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, cli
+	}
+
+	// REST requests without a session aren't redirected.
+
+	resp, err := http.Get(gui.URL + "/rest/system/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Error("Unexpected status for REST request without session:", resp.Status)
+	}
+
+	// A user in both groups gets the highest role and all folders, and
+	// stays logged in.
+
+	resp, cli := login(map[string]interface{}{
+		"preferred_username": "alice",
+		"groups":             []string{"syncthing-users", "syncthing-admins"},
+	})
+	var id guiIdentity
+	err = json.NewDecoder(resp.Body).Decode(&id)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Request.URL.Path != "/some/page" {
+		t.Error("Should be back at the requested page, not", resp.Request.URL)
+	}
+	if id.User != "alice" || id.Role != config.GUIRoleAdmin || id.Folders != nil {
+		t.Errorf("Unexpected identity %+v", id)
+	}
+	ev, err := sub.Poll(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := ev.Data.(map[string]interface{}); data["success"] != true || data["username"] != "alice" {
+		t.Errorf("Unexpected login attempt %v", data)
+	}
+
+	resp, err = cli.Get(gui.URL + "/rest/system/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Error("The session should be valid, not", resp.Status)
+	}
+
+	// A user in no mapped group can't log in.
+
+	resp, _ = login(map[string]interface{}{
+		"preferred_username": "mallory",
+		"groups":             "others",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Error("Unexpected status for user without a role:", resp.Status)
+	}
+	ev, err = sub.Poll(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := ev.Data.(map[string]interface{}); data["success"] != false || data["username"] != "mallory" {
+		t.Errorf("Unexpected login attempt %v", data)
+	}
+
+	// A login started in one browser can't be completed in another, such
+	// as when someone sends theirs to a victim.
+
+	iss.claims = map[string]interface{}{
+		"preferred_username": "mallory",
+		"groups":             "syncthing-admins",
+	}
+	var callbackURL string
+	jar, _ := cookiejar.New(nil)
+	attacker := &http.Client{Jar: jar, Timeout: 10 * time.Second, CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Path == oidcCallbackPath {
+			callbackURL = req.URL.String()
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}}
+	resp, err = attacker.Get(gui.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if callbackURL == "" {
+		t.Fatal("No callback from the provider")
+	}
+	resp, err = http.Get(callbackURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Error("Unexpected status for callback in another browser:", resp.Status)
+	}
+
+	// A callback without a known state is refused.
+
+	resp, err = http.Get(gui.URL + oidcCallbackPath + "?code=abc&state=unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Error("Unexpected status for unknown state:", resp.Status)
+	}
+}
+
+func TestOIDCIdentityFolderRoles(t *testing.T) {
+	cfg := config.OIDCConfiguration{
+		UsernameClaim: "preferred_username",
+		RoleClaim:     "groups",
+		RoleMappings: []config.OIDCRoleMapping{
+			{Value: "staff", Role: config.GUIRoleReadOnly},
+			{Value: "ops-x", Role: config.GUIRoleAdmin, Folders: []string{"x"}},
+			{Value: "ops-y", Role: config.GUIRoleOperator, Folders: []string{"y"}},
+		},
+	}
+	identity := func(groups ...interface{}) guiIdentity {
+		t.Helper()
+		id, ok := oidcIdentity(map[string]interface{}{"preferred_username": "alice", "groups": groups}, cfg)
+		if !ok {
+			t.Fatal("No identity for", groups)
+		}
+		return id
+	}
+
+	// Admin on one folder and read only on all others doesn't make an
+	// admin on all of them.
+
+	id := identity("staff", "ops-x")
+	if id.isScoped() || id.Role != config.GUIRoleReadOnly {
+		t.Errorf("Unexpected identity %+v", id)
+	}
+	for folder, role := range map[string]config.GUIRole{"x": config.GUIRoleAdmin, "y": config.GUIRoleReadOnly, "": config.GUIRoleReadOnly} {
+		if got := id.folderRole(folder); got != role {
+			t.Errorf("Role on %q should be %v, not %v", folder, role, got)
+		}
+	}
+
+	// Only folder mappings restrict to their folders, with the lowest role
+	// for requests about none.
+
+	id = identity("ops-x", "ops-y")
+	if len(id.Folders) != 2 || id.Folders[0] != "x" || id.Folders[1] != "y" || id.Role != config.GUIRoleOperator {
+		t.Errorf("Unexpected identity %+v", id)
+	}
+	if role := id.folderRole("x"); role != config.GUIRoleAdmin {
+		t.Error("Role on x should be admin, not", role)
+	}
+	if role := id.folderRole("y"); role != config.GUIRoleOperator {
+		t.Error("Role on y should be operator, not", role)
+	}
+}
+
+func TestOIDCLoginsCapped(t *testing.T) {
+	oidcLoginsMut.Lock()
+	defer oidcLoginsMut.Unlock()
+	saved := oidcLogins
+	oidcLogins = make(map[string]oidcLogin)
+	defer func() {
+		oidcLogins = saved
+	}()
+
+	// Anyone can start logins, but only the most recent ones are kept.
+
+	now := time.Now()
+	for i := 0; i < oidcMaxLogins+10; i++ {
+		addOIDCLoginLocked(fmt.Sprint(i), oidcLogin{expires: now.Add(oidcLoginTimeout + time.Duration(i))}, now)
+	}
+	if len(oidcLogins) != oidcMaxLogins {
+		t.Errorf("%d pending logins, expected %d", len(oidcLogins), oidcMaxLogins)
+	}
+	if _, ok := oidcLogins["9"]; ok {
+		t.Error("The oldest logins should have been removed")
+	}
+	if _, ok := oidcLogins[fmt.Sprint(oidcMaxLogins+9)]; !ok {
+		t.Error("The newest login should be kept")
+	}
+
+	// Expired logins are removed first.
+
+	addOIDCLoginLocked("late", oidcLogin{expires: now.Add(2 * oidcLoginTimeout)}, now.Add(oidcLoginTimeout+time.Hour))
+	if len(oidcLogins) != 1 {
+		t.Errorf("%d pending logins, expected 1", len(oidcLogins))
+	}
+}
+
+func TestOIDCLoginLockout(t *testing.T) {
+	guiCfg := config.GUIConfiguration{
+		AuthMode:          config.AuthModeOIDC,
+		LoginFailureLimit: 1,
+		LoginLockoutS:     60,
+		LoginLockoutMaxS:  3600,
+	}
+	handler := basicAuthAndSessionMiddleware("sessionid-test", guiCfg, config.LDAPConfiguration{}, config.OIDCConfiguration{}, nil,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer loginLimits.clear("", "")
+
+	callback := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", oidcCallbackPath+"?code=abc&state=unknown", nil))
+		return w.Code
+	}
+
+	// Failed callbacks lock out further ones from the IP address.
+
+	if code := callback(); code != http.StatusUnauthorized {
+		t.Fatal("Unexpected status for unknown state:", code)
+	}
+	if code := callback(); code != http.StatusTooManyRequests {
+		t.Fatal("Should be locked out, not", code)
+	}
+}
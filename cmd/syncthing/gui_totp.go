@@ -0,0 +1,254 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/rand"
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+// TOTP parameters as commonly supported by authenticator apps (RFC 6238
+// defaults). Codes from one step before and after the current one are
+// accepted to allow for clock skew.
+const (
+	totpStep          = 30 * time.Second
+	totpDigits        = 6
+	totpSkewSteps     = 1
+	totpSecretBytes   = 20
+	recoveryCodeCount = 10
+	totpEnrollTimeout = 10 * time.Minute
+)
+
+// The header a second factor can be given in, instead of after the
+// password.
+const secondFactorHeader = "X-Syncthing-OTP"
+
+var (
+	// The last accepted TOTP step per user and the recovery codes used, so
+	// that codes can't be reused. The configuration the latter are removed
+	// from may take a moment to become active.
+	totpLastSteps     = make(map[string]int64)
+	recoveryCodesUsed = make(map[string]bool)
+	secondFactorMut   = sync.NewMutex()
+
+	// The secrets handed out for enrolment per user, until confirmed.
+	pendingTOTPSecrets = make(map[string]pendingSecret)
+
+	totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+func newTOTPSecret() string {
+	bs := make([]byte, totpSecretBytes)
+	if _, err := rand.Reader.Read(bs); err != nil {
+		panic("reading random: " + err.Error())
+	}
+	return totpEncoding.EncodeToString(bs)
+}
+
+type pendingSecret struct {
+	secret  string
+	expires time.Time
+}
+
+// newPendingTOTPSecret returns a new secret for the user to enrol, which
+// replaces any earlier one and is kept until confirmed or it expires.
+func newPendingTOTPSecret(username string, now time.Time) string {
+	secret := newTOTPSecret()
+	secondFactorMut.Lock()
+	defer secondFactorMut.Unlock()
+	for user, p := range pendingTOTPSecrets {
+		if now.After(p.expires) {
+			delete(pendingTOTPSecrets, user)
+		}
+	}
+	pendingTOTPSecrets[username] = pendingSecret{secret, now.Add(totpEnrollTimeout)}
+	return secret
+}
+
+// pendingTOTPSecret returns the secret the user is enrolling, if any.
+func pendingTOTPSecret(username string, now time.Time) (string, bool) {
+	secondFactorMut.Lock()
+	defer secondFactorMut.Unlock()
+	p, ok := pendingTOTPSecrets[username]
+	if !ok || now.After(p.expires) {
+		return "", false
+	}
+	return p.secret, true
+}
+
+func forgetPendingTOTPSecret(username string) {
+	secondFactorMut.Lock()
+	delete(pendingTOTPSecrets, username)
+	secondFactorMut.Unlock()
+}
+
+// totpURI returns the otpauth URI for enrolling the secret in an
+// authenticator app, usually by QR code.
+func totpURI(username, secret string) string {
+	label := url.PathEscape("Syncthing:" + username)
+	params := url.Values{
+		"secret": {secret},
+		"issuer": {"Syncthing"},
+	}
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// totpCode returns the code for the given step, as in RFC 4226.
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// totpStepFor returns the step of the code matching the secret at the given
+// time, if any.
+func totpStepFor(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / int64(totpStep/time.Second)
+	for step := current - totpSkewSteps; step <= current+totpSkewSteps; step++ {
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// useTOTPCode returns true if the code is valid for the secret and no code
+// from the same or a later step has been used by the user before.
+func useTOTPCode(username, secret, code string, now time.Time) bool {
+	step, ok := totpStepFor(secret, code, now)
+	if !ok {
+		return false
+	}
+	secondFactorMut.Lock()
+	defer secondFactorMut.Unlock()
+	if last, ok := totpLastSteps[username]; ok && step <= last {
+		return false
+	}
+	totpLastSteps[username] = step
+	return true
+}
+
+// newRecoveryCodes returns new recovery codes, to be shown to the user
+// once, and the hashes to store in their place.
+func newRecoveryCodes() ([]string, []string) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code := strings.ToLower(rand.String(10))
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitSecondFactor splits a second factor given after the password,
+// separated by a colon, from the password.
+func splitSecondFactor(password string) (string, string) {
+	if i := strings.LastIndex(password, ":"); i >= 0 {
+		return password[:i], password[i+1:]
+	}
+	return password, ""
+}
+
+// checkSecondFactor returns true if the user has no second factor or the
+// given code is a valid TOTP code or unused recovery code for the user.
+// Recovery codes are passed to useRecoveryCode, which must make sure they
+// can't be used again.
+func checkSecondFactor(username, code string, guiCfg config.GUIConfiguration, useRecoveryCode func(username, hash string)) bool {
+	secret, recoveryCodes := guiCfg.SecondFactor(username)
+	if secret == "" {
+		return true
+	}
+	if code == "" {
+		return false
+	}
+	if useTOTPCode(username, secret, code, time.Now()) {
+		return true
+	}
+	hash := hashRecoveryCode(code)
+	for _, h := range recoveryCodes {
+		if !hmac.Equal([]byte(h), []byte(hash)) {
+			continue
+		}
+		secondFactorMut.Lock()
+		used := recoveryCodesUsed[hash]
+		recoveryCodesUsed[hash] = true
+		secondFactorMut.Unlock()
+		if used {
+			return false
+		}
+		useRecoveryCode(username, hash)
+		return true
+	}
+	return false
+}
+
+// maskUsedRecoveryCodes returns from with the recovery codes of to for the
+// users whose only change is that used recovery codes were removed. The
+// running middleware already refuses those, so there's nothing to restart
+// for.
+func maskUsedRecoveryCodes(from, to config.GUIConfiguration) config.GUIConfiguration {
+	usernames := []string{from.User}
+	for _, u := range from.Users {
+		usernames = append(usernames, u.Name)
+	}
+
+	secondFactorMut.Lock()
+	defer secondFactorMut.Unlock()
+	for _, username := range usernames {
+		fromSecret, fromCodes := from.SecondFactor(username)
+		toSecret, toCodes := to.SecondFactor(username)
+		if fromSecret != toSecret || len(toCodes) >= len(fromCodes) {
+			continue
+		}
+		remaining := make(map[string]bool, len(toCodes))
+		for _, h := range toCodes {
+			remaining[h] = true
+		}
+		onlyUsed := true
+		for _, h := range fromCodes {
+			if remaining[h] {
+				delete(remaining, h)
+			} else if !recoveryCodesUsed[h] {
+				onlyUsed = false
+			}
+		}
+		if onlyUsed && len(remaining) == 0 {
+			// The users are shared with other committers.
+			from.Users = append([]config.GUIUser(nil), from.Users...)
+			from.SetSecondFactor(username, fromSecret, toCodes)
+		}
+	}
+	return from
+}
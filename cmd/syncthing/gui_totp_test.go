@@ -0,0 +1,165 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// The SHA-1 test vectors from RFC 6238, truncated to six digits.
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		if _, ok := totpStepFor(secret, tc.code, time.Unix(tc.unix, 0)); !ok {
+			t.Errorf("Code %s not valid at %d", tc.code, tc.unix)
+		}
+	}
+
+	// Codes are accepted one step off, but not more.
+	if _, ok := totpStepFor(secret, "287082", time.Unix(59+30, 0)); !ok {
+		t.Error("Code from the previous step should be valid")
+	}
+	if _, ok := totpStepFor(secret, "287082", time.Unix(59+60, 0)); ok {
+		t.Error("Code from two steps ago should not be valid")
+	}
+}
+
+func TestSecondFactorLogin(t *testing.T) {
+	secret := newTOTPSecret()
+	codes, hashes := newRecoveryCodes()
+	guiCfg := config.GUIConfiguration{
+		Users: []config.GUIUser{{
+			Name:          "totpuser",
+			Password:      string(passwordHashBytes),
+			TOTPSecret:    secret,
+			RecoveryCodes: hashes,
+		}},
+	}
+	var usedRecoveryCode string
+	handler := basicAuthAndSessionMiddleware("sessionid-test", guiCfg, config.LDAPConfiguration{}, config.OIDCConfiguration{},
+		func(username, hash string) { usedRecoveryCode = hash },
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	sub := events.Default.Subscribe(events.LoginAttempt)
+	defer events.Default.Unsubscribe(sub)
+
+	login := func(password, header string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.SetBasicAuth("totpuser", password)
+		if header != "" {
+			r.Header.Set(secondFactorHeader, header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	key, _ := totpEncoding.DecodeString(secret)
+	code := totpCode(key, time.Now().Unix()/30)
+
+	// Without the second factor the login fails, which is reported.
+
+	if status := login("pass", ""); status != http.StatusUnauthorized {
+		t.Error("Login without second factor should fail, not", status)
+	}
+	ev, err := sub.Poll(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := ev.Data.(map[string]interface{}); data["success"] != false || data["reason"] != "invalid second factor" {
+		t.Errorf("Unexpected login attempt %v", data)
+	}
+
+	// A TOTP code works once, after the password or in the header.
+
+	if status := login("pass:"+code, ""); status != http.StatusOK {
+		t.Error("Login with TOTP code should succeed, not", status)
+	}
+	if status := login("pass", code); status != http.StatusUnauthorized {
+		t.Error("Login with reused TOTP code should fail, not", status)
+	}
+	next := totpCode(key, time.Now().Unix()/30+1)
+	if status := login("pass", next); status != http.StatusOK {
+		t.Error("Login with TOTP code in header should succeed, not", status)
+	}
+
+	// A recovery code works once, and the password is still needed.
+
+	if status := login("wrong:"+codes[0], ""); status != http.StatusUnauthorized {
+		t.Error("Login with wrong password should fail, not", status)
+	}
+	if status := login("pass:"+codes[0], ""); status != http.StatusOK {
+		t.Error("Login with recovery code should succeed, not", status)
+	}
+	if usedRecoveryCode != hashes[0] {
+		t.Error("Recovery code should have been used up")
+	}
+	if status := login("pass:"+codes[0], ""); status != http.StatusUnauthorized {
+		t.Error("Login with reused recovery code should fail, not", status)
+	}
+}
+
+func TestPendingTOTPSecret(t *testing.T) {
+	now := time.Now()
+	secret := newPendingTOTPSecret("enroller", now)
+	if got, ok := pendingTOTPSecret("enroller", now.Add(time.Minute)); !ok || got != secret {
+		t.Error("Pending secret not found")
+	}
+	if _, ok := pendingTOTPSecret("other", now); ok {
+		t.Error("Pending secret found for another user")
+	}
+	if _, ok := pendingTOTPSecret("enroller", now.Add(totpEnrollTimeout+time.Second)); ok {
+		t.Error("Pending secret should expire")
+	}
+	forgetPendingTOTPSecret("enroller")
+	if _, ok := pendingTOTPSecret("enroller", now); ok {
+		t.Error("Pending secret should be forgotten")
+	}
+}
+
+func TestMaskUsedRecoveryCodes(t *testing.T) {
+	_, hashes := newRecoveryCodes()
+	from := config.GUIConfiguration{
+		Users: []config.GUIUser{{Name: "user", TOTPSecret: "secret", RecoveryCodes: hashes}},
+	}
+	to := from.Copy()
+	to.Users[0].RecoveryCodes = hashes[1:]
+
+	// Removing an unused code is a change like any other.
+
+	if masked := maskUsedRecoveryCodes(from, to); len(masked.Users[0].RecoveryCodes) != len(hashes) {
+		t.Error("Removal of unused recovery code masked")
+	}
+
+	// Removing a used one isn't, without changing what was given.
+
+	secondFactorMut.Lock()
+	recoveryCodesUsed[hashes[0]] = true
+	secondFactorMut.Unlock()
+	if masked := maskUsedRecoveryCodes(from, to); !reflect.DeepEqual(masked.Users[0].RecoveryCodes, hashes[1:]) {
+		t.Errorf("Removal of used recovery code not masked: %v", masked.Users[0].RecoveryCodes)
+	}
+	if len(from.Users[0].RecoveryCodes) != len(hashes) {
+		t.Error("Original configuration changed")
+	}
+}
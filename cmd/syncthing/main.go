@@ -33,6 +33,7 @@ import (
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/connections"
 	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/db/backend"
 	"github.com/syncthing/syncthing/lib/dialer"
 	"github.com/syncthing/syncthing/lib/discover"
 	"github.com/syncthing/syncthing/lib/events"
@@ -86,6 +87,24 @@ above). The value 0 is used to disable all of the above. The default is to
 show time only (2).
 
 
+Configuration Overlay
+---------------------
+
+XML and JSON files in the config.d directory next to config.xml are merged on
+top of it at startup, in the order of their names. They look like config.xml,
+with only the settings to override, e.g.
+
+   <configuration>
+      <options><maxSendKbps>100</maxSendKbps></options>
+      <folder id="default" rescanIntervalS="60"></folder>
+   </configuration>
+
+Folders and devices that aren't in config.xml are added. The options can also
+be overridden by environment variables named STOPTION_ and the option name in
+upper case, e.g. STOPTION_MAXSENDKBPS=100, with lists separated by commas.
+Settings made this way can't be changed in the GUI and aren't written to
+config.xml.
+
 Development Settings
 --------------------
 
@@ -166,6 +185,10 @@ type RuntimeOptions struct {
 	confDir        string
 	resetDatabase  bool
 	resetDeltaIdxs bool
+	dbBackend      string
+	exportIndex    string
+	importIndex    string
+	indexFolder    string
 	showVersion    bool
 	showPaths      bool
 	showDeviceId   bool
@@ -231,6 +254,10 @@ func parseCommandLineOptions() RuntimeOptions {
 	flag.BoolVar(&options.noRestart, "no-restart", options.noRestart, "Disable monitor process, managed restarts and log file writing")
 	flag.BoolVar(&options.resetDatabase, "reset-database", false, "Reset the database, forcing a full rescan and resync")
 	flag.BoolVar(&options.resetDeltaIdxs, "reset-deltas", false, "Reset delta index IDs, forcing a full index exchange")
+	flag.StringVar(&options.dbBackend, "database-backend", "", "Use the given database backend (\"leveldb\" or \"bolt\"), migrating an existing database if necessary")
+	flag.StringVar(&options.exportIndex, "export-index", "", "Export the index of the folder given by -index-folder to the specified file, then exit")
+	flag.StringVar(&options.importIndex, "import-index", "", "Import the index of the folder given by -index-folder from the specified file, then exit")
+	flag.StringVar(&options.indexFolder, "index-folder", "", "Folder ID for -export-index and -import-index")
 	flag.BoolVar(&options.doUpgrade, "upgrade", false, "Perform upgrade")
 	flag.BoolVar(&options.doUpgradeCheck, "upgrade-check", false, "Check for available upgrade")
 	flag.BoolVar(&options.showVersion, "version", false, "Show version")
@@ -309,6 +336,13 @@ func main() {
 		os.Exit(exitError)
 	}
 
+	if options.dbBackend != "" {
+		if _, err := backend.ParseType(options.dbBackend); err != nil {
+			l.Warnln(err)
+			os.Exit(exitError)
+		}
+	}
+
 	if options.hideConsole {
 		osutil.HideConsole()
 	}
@@ -422,6 +456,22 @@ func main() {
 		return
 	}
 
+	if options.exportIndex != "" {
+		if err := exportIndex(options); err != nil {
+			l.Warnln("Exporting index:", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+
+	if options.importIndex != "" {
+		if err := importIndex(options); err != nil {
+			l.Warnln("Importing index:", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+
 	if innerProcess || options.noRestart {
 		syncthingMain(options)
 	} else {
@@ -531,7 +581,7 @@ func checkUpgrade() upgrade.Release {
 }
 
 func performUpgrade(release upgrade.Release) {
-	// Use leveldb database locks to protect against concurrent upgrades
+	// Use database locks to protect against concurrent upgrades
 	_, err := db.Open(locations.Get(locations.Database))
 	if err == nil {
 		err = upgrade.To(release)
@@ -694,7 +744,7 @@ func syncthingMain(runtimeOptions RuntimeOptions) {
 	l.Infof("Hashing performance is %.02f MB/s", perf)
 
 	dbFile := locations.Get(locations.Database)
-	ldb, err := db.Open(dbFile)
+	ldb, err := openDatabase(dbFile, runtimeOptions.dbBackend)
 	if err != nil {
 		l.Warnln("Error opening database:", err)
 		os.Exit(exitError)
@@ -797,6 +847,12 @@ func syncthingMain(runtimeOptions RuntimeOptions) {
 	connectionsService := connections.NewService(cfg, myID, m, tlsCfg, cachedDiscovery, bepProtocolName, tlsDefaultCommonName)
 	mainService.Add(connectionsService)
 
+	// The address book has the addresses imported from address book files
+	// and learned from our introducers, which we share in turn.
+	addressBook := discover.NewAddressBook(locations.Get(locations.AddressBook), cert, connectionsService)
+	cachedDiscovery.Add(addressBook, 0, 0)
+	m.SetAddressBook(addressBook)
+
 	if cfg.Options().GlobalAnnEnabled {
 		for _, srv := range cfg.GlobalDiscoveryServers() {
 			l.Infoln("Using discovery server", srv)
@@ -830,9 +886,43 @@ func syncthingMain(runtimeOptions RuntimeOptions) {
 		}
 	}
 
+	for _, domain := range cfg.Options().DNSDiscoveryDomains {
+		l.Infoln("Using DNS discovery under", domain)
+
+		// The DNS has its own caching, so we don't keep the results for
+		// long. Devices that aren't found are tried again after a minute.
+		cachedDiscovery.Add(discover.NewDNS(domain), time.Minute, time.Minute)
+	}
+
+	if cfg.Options().MDNSEnabled {
+		for _, addr := range []string{discover.MDNSIPv4Addr, discover.MDNSIPv6Addr} {
+			md, err := discover.NewMDNS(myID, addr, connectionsService)
+			if err != nil {
+				l.Warnln("mDNS discovery:", err)
+				continue
+			}
+			cachedDiscovery.Add(md, 0, 0)
+		}
+	}
+
+	// Webhooks
+
+	webhooks := newWebhookService(cfg, locations.Get(locations.Webhooks))
+	mainService.Add(webhooks)
+
+	// Audit sinks
+
+	auditSinks := newAuditSinkService(cfg, filepath.Dir(locations.Get(locations.ConfigFile)))
+	mainService.Add(auditSinks)
+
+	// Event history
+
+	eventHistory := newEventHistoryService(cfg, locations.Get(locations.EventHistory))
+	mainService.Add(eventHistory)
+
 	// GUI
 
-	setupGUI(mainService, cfg, m, defaultSub, diskSub, cachedDiscovery, connectionsService, errors, systemLog, runtimeOptions)
+	setupGUI(mainService, cfg, m, defaultSub, diskSub, cachedDiscovery, addressBook, connectionsService, webhooks, eventHistory, errors, systemLog, miscDB, runtimeOptions)
 
 	if runtimeOptions.cpuProfile {
 		f, err := os.Create(fmt.Sprintf("cpu-%d.pprof", os.Getpid()))
@@ -980,6 +1070,11 @@ func loadConfigAtStartup() (*config.Wrapper, error) {
 			return nil, errors.Wrap(err, "failed to save default config")
 		}
 		l.Infof("Default config saved. Edit %s to taste (with Syncthing stopped) or use the GUI", cfg.ConfigPath())
+		// Load it again to apply the overlay, if any.
+		cfg, err = config.Load(cfgFile, myID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load config")
+		}
 	} else if err == io.EOF {
 		return nil, errors.New("Failed to load config: unexpected end of file. Truncated or empty configuration?")
 	} else if err != nil {
@@ -1061,7 +1156,7 @@ func startAuditing(mainService *suture.Supervisor, auditFile string) {
 	l.Infoln("Audit log in", auditDest)
 }
 
-func setupGUI(mainService *suture.Supervisor, cfg *config.Wrapper, m *model.Model, defaultSub, diskSub events.BufferedSubscription, discoverer discover.CachingMux, connectionsService *connections.Service, errors, systemLog logger.Recorder, runtimeOptions RuntimeOptions) {
+func setupGUI(mainService *suture.Supervisor, cfg *config.Wrapper, m *model.Model, defaultSub, diskSub events.BufferedSubscription, discoverer discover.CachingMux, addressBook *discover.AddressBook, connectionsService *connections.Service, webhooks *webhookService, eventHistory *eventHistoryService, errors, systemLog logger.Recorder, miscDB *db.NamespacedKV, runtimeOptions RuntimeOptions) {
 	guiCfg := cfg.GUI()
 
 	if !guiCfg.Enabled {
@@ -1075,7 +1170,7 @@ func setupGUI(mainService *suture.Supervisor, cfg *config.Wrapper, m *model.Mode
 	cpu := newCPUService()
 	mainService.Add(cpu)
 
-	api := newAPIService(myID, cfg, locations.Get(locations.HTTPSCertFile), locations.Get(locations.HTTPSKeyFile), runtimeOptions.assetDir, m, defaultSub, diskSub, discoverer, connectionsService, errors, systemLog, cpu)
+	api := newAPIService(myID, cfg, locations.Get(locations.HTTPSCertFile), locations.Get(locations.HTTPSKeyFile), runtimeOptions.assetDir, m, defaultSub, diskSub, discoverer, addressBook, connectionsService, webhooks, eventHistory, errors, systemLog, cpu, miscDB)
 	cfg.Subscribe(api)
 	mainService.Add(api)
 
@@ -1102,13 +1197,31 @@ func defaultConfig(cfgFile string) (*config.Wrapper, error) {
 		return config.Wrap(cfgFile, newCfg), nil
 	}
 
-	newCfg.Folders = append(newCfg.Folders, config.NewFolderConfiguration(myID, "default", "Default Folder", fs.FilesystemTypeBasic, locations.Get(locations.DefFolder)))
+	newCfg.Folders = append(newCfg.Folders, newCfg.Defaults.NewFolder(myID, "default", "Default Folder", fs.FilesystemTypeBasic, locations.Get(locations.DefFolder)))
 	l.Infoln("Default folder created and/or linked to new config")
 	return config.Wrap(cfgFile, newCfg), nil
 }
 
+// openDatabase opens the database using the given backend, or the backend of
+// the existing database if none is given.
+func openDatabase(location, backendName string) (*db.Lowlevel, error) {
+	if backendName == "" {
+		return db.Open(location)
+	}
+	typ, err := backend.ParseType(backendName)
+	if err != nil {
+		return nil, err
+	}
+	return db.OpenType(typ, location)
+}
+
 func resetDB() error {
-	return os.RemoveAll(locations.Get(locations.Database))
+	for _, typ := range backend.Types {
+		if err := backend.Remove(typ, locations.Get(locations.Database)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func ensureDir(dir string, mode fs.FileMode) error {
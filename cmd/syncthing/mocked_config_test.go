@@ -28,8 +28,12 @@ func (c *mockedConfig) LDAP() config.LDAPConfiguration {
 	return config.LDAPConfiguration{}
 }
 
+func (c *mockedConfig) OIDC() config.OIDCConfiguration {
+	return config.OIDCConfiguration{}
+}
+
 func (c *mockedConfig) RawCopy() config.Configuration {
-	cfg := config.Configuration{}
+	cfg := config.Configuration{GUI: c.gui.Copy()}
 	util.SetDefaults(&cfg.Options)
 	return cfg
 }
@@ -60,10 +64,58 @@ func (c *mockedConfig) SetDevices([]config.DeviceConfiguration) (config.Waiter,
 	return noopWaiter{}, nil
 }
 
+func (c *mockedConfig) RemoveDevice(protocol.DeviceID) (config.Waiter, error) {
+	return noopWaiter{}, nil
+}
+
+func (c *mockedConfig) SetFolder(config.FolderConfiguration) (config.Waiter, error) {
+	return noopWaiter{}, nil
+}
+
+func (c *mockedConfig) RemoveFolder(id string) (config.Waiter, error) {
+	return noopWaiter{}, nil
+}
+
+func (c *mockedConfig) SetOptions(config.OptionsConfiguration) (config.Waiter, error) {
+	return noopWaiter{}, nil
+}
+
+func (c *mockedConfig) SetGUI(config.GUIConfiguration) (config.Waiter, error) {
+	return noopWaiter{}, nil
+}
+
+func (c *mockedConfig) Defaults() config.DefaultsConfiguration {
+	return config.DefaultsConfiguration{}
+}
+
+func (c *mockedConfig) SetDefaults(config.DefaultsConfiguration) (config.Waiter, error) {
+	return noopWaiter{}, nil
+}
+
+func (c *mockedConfig) Locked() []string {
+	return nil
+}
+
 func (c *mockedConfig) Save() error {
 	return nil
 }
 
+func (c *mockedConfig) SaveBy(author string) error {
+	return nil
+}
+
+func (c *mockedConfig) History() ([]config.Revision, error) {
+	return nil, nil
+}
+
+func (c *mockedConfig) RevisionDiff(from, to int) (string, error) {
+	return "", config.ErrNoSuchRevision
+}
+
+func (c *mockedConfig) ReadRevision(rev int) (config.Configuration, error) {
+	return config.Configuration{}, config.ErrNoSuchRevision
+}
+
 func (c *mockedConfig) RequiresRestart() bool {
 	return false
 }
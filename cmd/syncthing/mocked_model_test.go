@@ -11,6 +11,7 @@ import (
 
 	"github.com/syncthing/syncthing/lib/connections"
 	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/fs"
 	"github.com/syncthing/syncthing/lib/model"
 	"github.com/syncthing/syncthing/lib/protocol"
 	"github.com/syncthing/syncthing/lib/stats"
@@ -44,7 +45,7 @@ func (m *mockedModel) NeedSize(folder string) db.Counts {
 }
 
 func (m *mockedModel) ConnectionStats() map[string]interface{} {
-	return nil
+	return map[string]interface{}{}
 }
 
 func (m *mockedModel) DeviceStatistics() map[string]stats.DeviceStatistics {
@@ -147,6 +148,14 @@ func (m *mockedModel) WatchError(folder string) error {
 	return nil
 }
 
+func (m *mockedModel) WatchState(folder string) (fs.WatchState, error) {
+	return fs.WatchState{}, nil
+}
+
+func (m *mockedModel) CheckDatabase(folder string) error {
+	return nil
+}
+
 func (m *mockedModel) LocalChangedFiles(folder string, page, perpage int) []db.FileInfoTruncated {
 	return nil
 }
@@ -0,0 +1,503 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/dialer"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/sync"
+	"github.com/thejerf/suture"
+)
+
+var (
+	webhookMinRetry = 5 * time.Second
+	webhookMaxRetry = 10 * time.Minute
+)
+
+const webhookTimeout = 30 * time.Second
+
+type webhookStatus struct {
+	ID                  string    `json:"id"`
+	URL                 string    `json:"url"`
+	Enabled             bool      `json:"enabled"`
+	QueuedBatches       int       `json:"queuedBatches"`
+	DeliveredBatches    int       `json:"deliveredBatches"`
+	DroppedBatches      int       `json:"droppedBatches"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastAttempt         time.Time `json:"lastAttempt"`
+	LastDelivery        time.Time `json:"lastDelivery"`
+	LastError           string    `json:"lastError,omitempty"`
+	NextAttempt         time.Time `json:"nextAttempt"`
+}
+
+// The webhookService runs a webhook for each enabled webhook configuration,
+// and restarts them as the configuration changes. Each webhook has a queue
+// directory below dir, so that undelivered events survive restarts.
+type webhookService struct {
+	*suture.Supervisor
+	dir    string
+	mut    sync.Mutex
+	cfgs   []config.WebhookConfiguration
+	hooks  map[string]*webhook
+	tokens map[string]suture.ServiceToken
+}
+
+func newWebhookService(cfg *config.Wrapper, dir string) *webhookService {
+	s := &webhookService{
+		Supervisor: suture.New("webhookService", suture.Spec{
+			Log: func(line string) {
+				l.Infoln(line)
+			},
+			PassThroughPanics: true,
+		}),
+		dir:    dir,
+		mut:    sync.NewMutex(),
+		hooks:  make(map[string]*webhook),
+		tokens: make(map[string]suture.ServiceToken),
+	}
+	s.CommitConfiguration(config.Configuration{}, cfg.RawCopy())
+	cfg.Subscribe(s)
+	return s
+}
+
+func (s *webhookService) VerifyConfiguration(from, to config.Configuration) error {
+	return nil
+}
+
+func (s *webhookService) CommitConfiguration(from, to config.Configuration) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	wanted := make(map[string]config.WebhookConfiguration, len(to.Webhooks))
+	for _, hookCfg := range to.Webhooks {
+		wanted[hookCfg.ID] = hookCfg
+	}
+
+	for id, hook := range s.hooks {
+		if hookCfg, ok := wanted[id]; ok && hookCfg.Enabled && reflect.DeepEqual(hookCfg, hook.cfg) {
+			continue
+		}
+		s.RemoveAndWait(s.tokens[id], 10*time.Second)
+		delete(s.hooks, id)
+		delete(s.tokens, id)
+		if _, ok := wanted[id]; !ok {
+			// The queue of a removed webhook will never be delivered.
+			os.RemoveAll(s.queueDir(id))
+		}
+	}
+
+	for id, hookCfg := range wanted {
+		if _, ok := s.hooks[id]; ok || !hookCfg.Enabled {
+			continue
+		}
+		hook := newWebhook(hookCfg, s.queueDir(id))
+		s.hooks[id] = hook
+		s.tokens[id] = s.Add(hook)
+	}
+
+	s.cfgs = to.Webhooks
+	return true
+}
+
+// Status returns the delivery status of each configured webhook.
+func (s *webhookService) Status() []webhookStatus {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	res := make([]webhookStatus, 0, len(s.cfgs))
+	for _, hookCfg := range s.cfgs {
+		if hook, ok := s.hooks[hookCfg.ID]; ok {
+			res = append(res, hook.Status())
+			continue
+		}
+		names, _ := queuedBatches(s.queueDir(hookCfg.ID))
+		res = append(res, webhookStatus{
+			ID:            hookCfg.ID,
+			URL:           hookCfg.URL,
+			QueuedBatches: len(names),
+		})
+	}
+	return res
+}
+
+func (s *webhookService) queueDir(id string) string {
+	// The ID is user provided and may not be a valid or safe file name.
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(id)))
+}
+
+func (s *webhookService) String() string {
+	return fmt.Sprintf("webhookService@%p", s)
+}
+
+// A webhook collects the events matching its configuration into batches,
+// writes them to the queue directory and posts them to the configured URL,
+// oldest first. Failed deliveries are retried with an exponential backoff.
+type webhook struct {
+	cfg       config.WebhookConfiguration
+	dir       string
+	client    *http.Client
+	queued    chan struct{}
+	stop      chan struct{}
+	nextBatch int64
+
+	mut    sync.Mutex
+	status webhookStatus
+}
+
+func newWebhook(cfg config.WebhookConfiguration, dir string) *webhook {
+	return &webhook{
+		cfg: cfg,
+		dir: dir,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			Transport: &http.Transport{
+				Dial:  dialer.Dial,
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: cfg.InsecureSkipVerify,
+				},
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return errors.New("stopped after 10 redirects")
+				}
+				// A redirect must not lead to a target we wouldn't post to
+				// directly.
+				if !cfg.AllowLocalTarget {
+					return checkWebhookTarget(req.URL.String())
+				}
+				return nil
+			},
+		},
+		queued: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		mut:    sync.NewMutex(),
+		status: webhookStatus{
+			ID:      cfg.ID,
+			URL:     cfg.URL,
+			Enabled: true,
+		},
+	}
+}
+
+// webhookIdentity is who the events are redacted for.
+var webhookIdentity = guiIdentity{Role: config.GUIRoleReadOnly}
+
+func (h *webhook) Serve() {
+	sub := events.Default.Subscribe(h.cfg.EventMask())
+	defer events.Default.Unsubscribe(sub)
+
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		l.Warnf("Webhook %s: %v", h.cfg.ID, err)
+	}
+	names, _ := queuedBatches(h.dir)
+	if len(names) > 0 {
+		last, _ := strconv.ParseInt(strings.TrimSuffix(names[len(names)-1], ".json"), 10, 64)
+		h.nextBatch = last + 1
+	}
+	h.mut.Lock()
+	h.status.QueuedBatches = len(names)
+	h.mut.Unlock()
+
+	stopDelivery := make(chan struct{})
+	deliveryDone := make(chan struct{})
+	go func() {
+		h.deliverQueued(stopDelivery)
+		close(deliveryDone)
+	}()
+	defer func() {
+		close(stopDelivery)
+		<-deliveryDone
+	}()
+
+	batchDelay := time.Duration(h.cfg.BatchDelayS) * time.Second
+	batchTimer := time.NewTimer(batchDelay)
+	batchTimer.Stop()
+	var pending []events.Event
+	for {
+		select {
+		case ev := <-sub.C():
+			// The events are queued and sent without the secrets of the
+			// configuration, the same as to read only GUI users.
+			pending = append(pending, redactedEvents([]events.Event{ev}, webhookIdentity)...)
+			if len(pending) >= h.cfg.MaxBatchEvents {
+				if !batchTimer.Stop() {
+					// Drain an expiry we didn't get to, so that it doesn't
+					// cut the next batch short after the reset.
+					select {
+					case <-batchTimer.C:
+					default:
+					}
+				}
+				h.enqueue(pending)
+				pending = nil
+			} else if len(pending) == 1 {
+				batchTimer.Reset(batchDelay)
+			}
+
+		case <-batchTimer.C:
+			h.enqueue(pending)
+			pending = nil
+
+		case <-h.stop:
+			h.enqueue(pending)
+			return
+		}
+	}
+}
+
+func (h *webhook) Stop() {
+	close(h.stop)
+}
+
+func (h *webhook) Status() webhookStatus {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	return h.status
+}
+
+// enqueue writes the events as a batch to the queue directory, dropping the
+// oldest batches if there are too many.
+func (h *webhook) enqueue(evs []events.Event) {
+	if len(evs) == 0 {
+		return
+	}
+
+	bs, err := json.Marshal(evs)
+	if err != nil {
+		l.Warnf("Webhook %s: %v", h.cfg.ID, err)
+		return
+	}
+	name := fmt.Sprintf("%016d.json", h.nextBatch)
+	h.nextBatch++
+	if err := writeBatch(filepath.Join(h.dir, name), bs); err != nil {
+		l.Warnf("Webhook %s: queueing events: %v", h.cfg.ID, err)
+		return
+	}
+
+	names, _ := queuedBatches(h.dir)
+	dropped := 0
+	for len(names) > h.cfg.MaxQueuedBatches {
+		os.Remove(filepath.Join(h.dir, names[0]))
+		names = names[1:]
+		dropped++
+	}
+	if dropped > 0 {
+		l.Warnf("Webhook %s: dropped %d undelivered batches of events", h.cfg.ID, dropped)
+	}
+
+	h.mut.Lock()
+	h.status.QueuedBatches = len(names)
+	h.status.DroppedBatches += dropped
+	h.mut.Unlock()
+
+	select {
+	case h.queued <- struct{}{}:
+	default:
+	}
+}
+
+// deliverQueued posts the queued batches in order until stopped, backing
+// off on failure.
+func (h *webhook) deliverQueued(stop chan struct{}) {
+	retryTimer := time.NewTimer(0) // deliver anything left from last time
+	defer retryTimer.Stop()
+	var backoff time.Duration
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-h.queued:
+			if backoff > 0 {
+				// Waiting for the retry timer
+				continue
+			}
+		case <-retryTimer.C:
+		}
+
+		for {
+			names, _ := queuedBatches(h.dir)
+			if len(names) == 0 {
+				backoff = 0
+				break
+			}
+			if err := h.deliver(names[0]); err != nil {
+				backoff *= 2
+				if backoff < webhookMinRetry {
+					backoff = webhookMinRetry
+				} else if backoff > webhookMaxRetry {
+					backoff = webhookMaxRetry
+				}
+				l.Debugf("Webhook %s: delivery failed, retrying in %v: %v", h.cfg.ID, backoff, err)
+
+				h.mut.Lock()
+				h.status.ConsecutiveFailures++
+				h.status.LastError = err.Error()
+				h.status.NextAttempt = time.Now().Add(backoff)
+				h.mut.Unlock()
+
+				retryTimer.Reset(backoff)
+				break
+			}
+			backoff = 0
+
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// deliver posts the batch and removes it from the queue once accepted.
+func (h *webhook) deliver(name string) error {
+	path := filepath.Join(h.dir, name)
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	h.mut.Lock()
+	h.status.LastAttempt = time.Now()
+	h.mut.Unlock()
+
+	if !h.cfg.AllowLocalTarget {
+		if err := checkWebhookTarget(h.cfg.URL); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest("POST", h.cfg.URL, bytes.NewReader(bs))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Syncthing-Webhook", h.cfg.ID)
+	// Identifies the batch across retries, so that the receiver can drop
+	// duplicates.
+	req.Header.Set("X-Syncthing-Delivery", strings.TrimSuffix(name, ".json"))
+	if h.cfg.Secret != "" {
+		req.Header.Set("X-Syncthing-Signature", "sha256="+webhookSignature(h.cfg.Secret, bs))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	names, _ := queuedBatches(h.dir)
+	h.mut.Lock()
+	h.status.QueuedBatches = len(names)
+	h.status.DeliveredBatches++
+	h.status.ConsecutiveFailures = 0
+	h.status.LastDelivery = time.Now()
+	h.status.LastError = ""
+	h.status.NextAttempt = time.Time{}
+	h.mut.Unlock()
+	return nil
+}
+
+func (h *webhook) String() string {
+	return fmt.Sprintf("webhook@%p(%s)", h, h.cfg.ID)
+}
+
+// checkWebhookTarget returns an error if the host of the URL is, or
+// resolves to, a loopback or link-local address. Those would let the
+// webhook reach services only meant for this host or its link, such as
+// cloud metadata endpoints.
+func checkWebhookTarget(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	ips := []net.IP{net.ParseIP(u.Hostname())}
+	if ips[0] == nil {
+		if ips, err = net.LookupIP(u.Hostname()); err != nil {
+			return err
+		}
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			return fmt.Errorf("refusing local target %s", ip)
+		}
+	}
+	return nil
+}
+
+// webhookSignature returns the hex encoded HMAC-SHA256 of the body.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// queuedBatches returns the names of the batches in the queue directory,
+// oldest first.
+func queuedBatches(dir string) ([]string, error) {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	all, err := fd.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	names := all[:0]
+	for _, name := range all {
+		if strings.HasSuffix(name, ".json") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func writeBatch(path string, bs []byte) error {
+	fd, err := osutil.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(bs); err != nil {
+		return err
+	}
+	return fd.Close()
+}
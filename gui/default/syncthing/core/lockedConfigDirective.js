@@ -0,0 +1,35 @@
+angular.module('syncthing.core')
+    .directive('ngModel', function ($translate) {
+        // Disables the inputs for settings made by the configuration
+        // overlay, which can't be changed in the GUI.
+        var sections = {
+            tmpOptions: 'options',
+            tmpGUI: 'gui',
+            currentFolder: 'folders',
+            currentDevice: 'devices'
+        };
+        return {
+            restrict: 'A',
+            link: function (scope, element, attrs) {
+                var m = attrs.ngModel.match(/^(\w+)\.(\w+)/);
+                if (!m || !sections[m[1]] || !scope.isLockedConfig) {
+                    return;
+                }
+                // Fields edited as strings are named like _listenAddressesStr.
+                var field = m[2].replace(/^_/, '').replace(/Str$/, '');
+                var disabled = false;
+                scope.$watch(function () {
+                    return scope.isLockedConfig(sections[m[1]], scope.$eval(m[1]), field);
+                }, function (locked) {
+                    if (locked) {
+                        element.attr('disabled', 'disabled');
+                        element.attr('title', $translate.instant('Set by the configuration overlay'));
+                    } else if (disabled) {
+                        element.removeAttr('disabled');
+                        element.removeAttr('title');
+                    }
+                    disabled = locked;
+                });
+            }
+        };
+    });
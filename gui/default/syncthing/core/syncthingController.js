@@ -23,6 +23,7 @@ angular.module('syncthing.core')
         $scope.completion = {};
         $scope.config = {};
         $scope.configInSync = true;
+        $scope.lockedConfig = {};
         $scope.connections = {};
         $scope.errors = [];
         $scope.model = {};
@@ -395,6 +396,7 @@ angular.module('syncthing.core')
                 && guiCfg.address.substr(0, 6) !== "[::1]:"
                 && (!guiCfg.user || !guiCfg.password)
                 && guiCfg.authMode !== 'ldap'
+                && guiCfg.authMode !== 'oidc'
                 && !guiCfg.insecureAdminAccess;
 
             if (!hasConfig) {
@@ -558,6 +560,13 @@ angular.module('syncthing.core')
             $http.get(urlbase + '/system/config/insync').success(function (data) {
                 $scope.configInSync = data.configInSync;
             }).error($scope.emitHTTPError);
+
+            $http.get(urlbase + '/config/locked').success(function (data) {
+                $scope.lockedConfig = {};
+                data.forEach(function (path) {
+                    $scope.lockedConfig[path] = true;
+                });
+            }).error($scope.emitHTTPError);
         }
 
         function refreshNeed(folder) {
@@ -1171,6 +1180,18 @@ angular.module('syncthing.core')
             }
         }
 
+        $scope.isLockedConfig = function (section, obj, field) {
+            // Fields are locked one by one, while folders and devices that
+            // only exist in the overlay are locked as a whole.
+            var prefix = section;
+            if (section === 'folders') {
+                prefix += '.' + (obj && obj.id);
+            } else if (section === 'devices') {
+                prefix += '.' + (obj && obj.deviceID);
+            }
+            return !!($scope.lockedConfig[prefix] || $scope.lockedConfig[prefix + '.' + field]);
+        };
+
         $scope.editSettings = function () {
             // Make a working copy
             $scope.tmpOptions = angular.copy($scope.config.options);
@@ -1404,16 +1425,19 @@ angular.module('syncthing.core')
                     }
                 })
                 .then(function () {
-                    $scope.currentDevice = {
+                    var defaults = $scope.config.defaults ? angular.copy($scope.config.defaults.device) : {};
+                    $scope.currentDevice = angular.extend({
+                        addresses: ['dynamic'],
+                        compression: 'metadata',
+                        introducer: false
+                    }, defaults, {
                         name: name,
                         deviceID: deviceID,
-                        _addressesStr: 'dynamic',
-                        compression: 'metadata',
-                        introducer: false,
                         selectedFolders: {},
                         pendingFolders: [],
                         ignoredFolders: []
-                    };
+                    });
+                    $scope.currentDevice._addressesStr = $scope.currentDevice.addresses.join(', ');
                     $scope.editingExisting = false;
                     $scope.deviceEditor.$setPristine();
                     $('#editDevice').modal();
@@ -1649,6 +1673,60 @@ angular.module('syncthing.core')
             });
         };
 
+        // setFileVersioningFields sets the fields the folder editor uses for
+        // the versioning settings from the folder's versioning.
+        function setFileVersioningFields(folderCfg) {
+            if (folderCfg.versioning && folderCfg.versioning.type === "trashcan") {
+                folderCfg.trashcanFileVersioning = true;
+                folderCfg.fileVersioningSelector = "trashcan";
+                folderCfg.trashcanClean = +folderCfg.versioning.params.cleanoutDays;
+            } else if (folderCfg.versioning && folderCfg.versioning.type === "simple") {
+                folderCfg.simpleFileVersioning = true;
+                folderCfg.fileVersioningSelector = "simple";
+                folderCfg.simpleKeep = +folderCfg.versioning.params.keep;
+            } else if (folderCfg.versioning && folderCfg.versioning.type === "staggered") {
+                folderCfg.staggeredFileVersioning = true;
+                folderCfg.fileVersioningSelector = "staggered";
+                folderCfg.staggeredMaxAge = Math.floor(+folderCfg.versioning.params.maxAge / 86400);
+                folderCfg.staggeredCleanInterval = +folderCfg.versioning.params.cleanInterval;
+                folderCfg.staggeredVersionsPath = folderCfg.versioning.params.versionsPath;
+            } else if (folderCfg.versioning && folderCfg.versioning.type === "external") {
+                folderCfg.externalFileVersioning = true;
+                folderCfg.fileVersioningSelector = "external";
+                folderCfg.externalCommand = folderCfg.versioning.params.command;
+            } else {
+                folderCfg.fileVersioningSelector = "none";
+            }
+            folderCfg.trashcanClean = folderCfg.trashcanClean || 0; // weeds out nulls and undefineds
+            folderCfg.simpleKeep = folderCfg.simpleKeep || 5;
+            folderCfg.staggeredCleanInterval = folderCfg.staggeredCleanInterval || 3600;
+            folderCfg.staggeredVersionsPath = folderCfg.staggeredVersionsPath || "";
+
+            // staggeredMaxAge can validly be zero, which we should not replace
+            // with the default value of 365. So only set the default if it's
+            // actually undefined.
+            if (typeof folderCfg.staggeredMaxAge === 'undefined') {
+                folderCfg.staggeredMaxAge = 365;
+            }
+            folderCfg.externalCommand = folderCfg.externalCommand || "";
+        }
+
+        // newFolder returns a folder for the folder editor, based on the
+        // configured default folder.
+        function newFolder() {
+            var folderCfg = angular.copy($scope.folderDefaults);
+            if (!$scope.config.defaults) {
+                return folderCfg;
+            }
+            angular.extend(folderCfg, angular.copy($scope.config.defaults.folder));
+            folderCfg.selectedDevices = {};
+            (folderCfg.devices || []).forEach(function (n) {
+                folderCfg.selectedDevices[n.deviceID] = true;
+            });
+            setFileVersioningFields(folderCfg);
+            return folderCfg;
+        }
+
         $scope.editFolder = function (folderCfg) {
             $scope.editingExisting = true;
             $scope.currentFolder = angular.copy(folderCfg);
@@ -1659,39 +1737,7 @@ angular.module('syncthing.core')
             $scope.currentFolder.devices.forEach(function (n) {
                 $scope.currentFolder.selectedDevices[n.deviceID] = true;
             });
-            if ($scope.currentFolder.versioning && $scope.currentFolder.versioning.type === "trashcan") {
-                $scope.currentFolder.trashcanFileVersioning = true;
-                $scope.currentFolder.fileVersioningSelector = "trashcan";
-                $scope.currentFolder.trashcanClean = +$scope.currentFolder.versioning.params.cleanoutDays;
-            } else if ($scope.currentFolder.versioning && $scope.currentFolder.versioning.type === "simple") {
-                $scope.currentFolder.simpleFileVersioning = true;
-                $scope.currentFolder.fileVersioningSelector = "simple";
-                $scope.currentFolder.simpleKeep = +$scope.currentFolder.versioning.params.keep;
-            } else if ($scope.currentFolder.versioning && $scope.currentFolder.versioning.type === "staggered") {
-                $scope.currentFolder.staggeredFileVersioning = true;
-                $scope.currentFolder.fileVersioningSelector = "staggered";
-                $scope.currentFolder.staggeredMaxAge = Math.floor(+$scope.currentFolder.versioning.params.maxAge / 86400);
-                $scope.currentFolder.staggeredCleanInterval = +$scope.currentFolder.versioning.params.cleanInterval;
-                $scope.currentFolder.staggeredVersionsPath = $scope.currentFolder.versioning.params.versionsPath;
-            } else if ($scope.currentFolder.versioning && $scope.currentFolder.versioning.type === "external") {
-                $scope.currentFolder.externalFileVersioning = true;
-                $scope.currentFolder.fileVersioningSelector = "external";
-                $scope.currentFolder.externalCommand = $scope.currentFolder.versioning.params.command;
-            } else {
-                $scope.currentFolder.fileVersioningSelector = "none";
-            }
-            $scope.currentFolder.trashcanClean = $scope.currentFolder.trashcanClean || 0; // weeds out nulls and undefineds
-            $scope.currentFolder.simpleKeep = $scope.currentFolder.simpleKeep || 5;
-            $scope.currentFolder.staggeredCleanInterval = $scope.currentFolder.staggeredCleanInterval || 3600;
-            $scope.currentFolder.staggeredVersionsPath = $scope.currentFolder.staggeredVersionsPath || "";
-
-            // staggeredMaxAge can validly be zero, which we should not replace
-            // with the default value of 365. So only set the default if it's
-            // actually undefined.
-            if (typeof $scope.currentFolder.staggeredMaxAge === 'undefined') {
-                $scope.currentFolder.staggeredMaxAge = 365;
-            }
-            $scope.currentFolder.externalCommand = $scope.currentFolder.externalCommand || "";
+            setFileVersioningFields($scope.currentFolder);
 
             $('#folder-ignores textarea').val($translate.instant("Loading..."));
             $('#folder-ignores textarea').attr('disabled', 'disabled');
@@ -1726,7 +1772,7 @@ angular.module('syncthing.core')
         $scope.addFolder = function () {
             $http.get(urlbase + '/svc/random/string?length=10').success(function (data) {
                 $scope.editingExisting = false;
-                $scope.currentFolder = angular.copy($scope.folderDefaults);
+                $scope.currentFolder = newFolder();
                 $scope.currentFolder.id = (data.random.substr(0, 5) + '-' + data.random.substr(5, 5)).toLowerCase();
                 $('#folder-ignores textarea').val("");
                 $('#folder-ignores textarea').removeAttr('disabled');
@@ -1736,7 +1782,7 @@ angular.module('syncthing.core')
 
         $scope.addFolderAndShare = function (folder, folderLabel, device) {
             $scope.editingExisting = false;
-            $scope.currentFolder = angular.copy($scope.folderDefaults);
+            $scope.currentFolder = newFolder();
             $scope.currentFolder.id = folder;
             $scope.currentFolder.label = folderLabel;
             $scope.currentFolder.viewFlags = {
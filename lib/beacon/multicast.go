@@ -10,9 +10,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	stdsync "sync"
 	"time"
 
 	"github.com/thejerf/suture"
+	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
 )
 
@@ -24,6 +26,8 @@ type Multicast struct {
 	mw     *multicastWriter
 }
 
+// NewMulticast returns a beacon sending to and receiving from the multicast
+// group at addr, which may be an IPv4 or IPv6 group.
 func NewMulticast(addr string) *Multicast {
 	m := &Multicast{
 		Supervisor: suture.New("multicastBeacon", suture.Spec{
@@ -59,6 +63,15 @@ func NewMulticast(addr string) *Multicast {
 	return m
 }
 
+// NewMulticastFromGroupPort returns a beacon like NewMulticast, except that
+// it sends on the socket it receives on, which is bound to the port of the
+// group. That's what mDNS requires of responses.
+func NewMulticastFromGroupPort(addr string) *Multicast {
+	m := NewMulticast(addr)
+	m.mw.conn = m.mr.currentConn
+	return m
+}
+
 func (m *Multicast) Send(data []byte) {
 	m.inbox <- data
 }
@@ -78,6 +91,7 @@ func (m *Multicast) Error() error {
 type multicastWriter struct {
 	addr  string
 	inbox <-chan []byte
+	conn  func() net.PacketConn // to send on, if not on a socket of our own
 	errorHolder
 	stop chan struct{}
 }
@@ -86,27 +100,40 @@ func (w *multicastWriter) Serve() {
 	l.Debugln(w, "starting")
 	defer l.Debugln(w, "stopping")
 
-	gaddr, err := net.ResolveUDPAddr("udp6", w.addr)
+	gaddr, err := net.ResolveUDPAddr("udp", w.addr)
 	if err != nil {
 		l.Debugln(err)
 		w.setError(err)
 		return
 	}
 
-	conn, err := net.ListenPacket("udp6", ":0")
-	if err != nil {
-		l.Debugln(err)
-		w.setError(err)
-		return
-	}
-
-	pconn := ipv6.NewPacketConn(conn)
-
-	wcm := &ipv6.ControlMessage{
-		HopLimit: 1,
+	var conn net.PacketConn
+	if w.conn == nil {
+		conn, err = net.ListenPacket(multicastNetwork(gaddr), ":0")
+		if err != nil {
+			l.Debugln(err)
+			w.setError(err)
+			return
+		}
 	}
 
+	var writeTo func(bs []byte, intf net.Interface) (int, error)
+	var writeToConn net.PacketConn
 	for bs := range w.inbox {
+		if w.conn != nil {
+			// The receiving socket may have been opened again since the
+			// last time.
+			if conn = w.conn(); conn == nil {
+				l.Debugln(errNotListening)
+				w.setError(errNotListening)
+				continue
+			}
+		}
+		if conn != writeToConn {
+			writeTo = multicastWriteTo(conn, gaddr)
+			writeToConn = conn
+		}
+
 		intfs, err := net.Interfaces()
 		if err != nil {
 			l.Debugln(err)
@@ -116,10 +143,9 @@ func (w *multicastWriter) Serve() {
 
 		success := 0
 		for _, intf := range intfs {
-			wcm.IfIndex = intf.Index
-			pconn.SetWriteDeadline(time.Now().Add(time.Second))
-			_, err = pconn.WriteTo(bs, wcm, gaddr)
-			pconn.SetWriteDeadline(time.Time{})
+			conn.SetWriteDeadline(time.Now().Add(time.Second))
+			_, err = writeTo(bs, intf)
+			conn.SetWriteDeadline(time.Time{})
 
 			if err != nil {
 				l.Debugln(err, "on write to", gaddr, intf.Name)
@@ -141,6 +167,29 @@ func (w *multicastWriter) Serve() {
 	}
 }
 
+// multicastWriteTo returns a function writing to the group on the given
+// interface, using conn.
+func multicastWriteTo(conn net.PacketConn, gaddr *net.UDPAddr) func(bs []byte, intf net.Interface) (int, error) {
+	if gaddr.IP.To4() != nil {
+		pconn := ipv4.NewPacketConn(conn)
+		pconn.SetMulticastTTL(1)
+		return func(bs []byte, intf net.Interface) (int, error) {
+			if err := pconn.SetMulticastInterface(&intf); err != nil {
+				return 0, err
+			}
+			return pconn.WriteTo(bs, nil, gaddr)
+		}
+	}
+	pconn := ipv6.NewPacketConn(conn)
+	wcm := &ipv6.ControlMessage{
+		HopLimit: 1,
+	}
+	return func(bs []byte, intf net.Interface) (int, error) {
+		wcm.IfIndex = intf.Index
+		return pconn.WriteTo(bs, wcm, gaddr)
+	}
+}
+
 func (w *multicastWriter) Stop() {
 	close(w.stop)
 }
@@ -154,25 +203,46 @@ type multicastReader struct {
 	outbox chan<- recv
 	errorHolder
 	stop chan struct{}
+
+	connMut stdsync.Mutex
+	conn    net.PacketConn
+}
+
+var errNotListening = errors.New("not listening on the multicast group")
+
+// currentConn returns the socket listening on the group, or nil.
+func (r *multicastReader) currentConn() net.PacketConn {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+	return r.conn
 }
 
 func (r *multicastReader) Serve() {
 	l.Debugln(r, "starting")
 	defer l.Debugln(r, "stopping")
 
-	gaddr, err := net.ResolveUDPAddr("udp6", r.addr)
+	gaddr, err := net.ResolveUDPAddr("udp", r.addr)
 	if err != nil {
 		l.Debugln(err)
 		r.setError(err)
 		return
 	}
 
-	conn, err := net.ListenPacket("udp6", r.addr)
+	conn, err := net.ListenPacket(multicastNetwork(gaddr), r.addr)
 	if err != nil {
 		l.Debugln(err)
 		r.setError(err)
 		return
 	}
+	r.connMut.Lock()
+	r.conn = conn
+	r.connMut.Unlock()
+	defer func() {
+		r.connMut.Lock()
+		r.conn = nil
+		r.connMut.Unlock()
+		conn.Close()
+	}()
 
 	intfs, err := net.Interfaces()
 	if err != nil {
@@ -181,14 +251,19 @@ func (r *multicastReader) Serve() {
 		return
 	}
 
-	pconn := ipv6.NewPacketConn(conn)
+	var joinGroup func(intf *net.Interface, group net.Addr) error
+	if gaddr.IP.To4() != nil {
+		joinGroup = ipv4.NewPacketConn(conn).JoinGroup
+	} else {
+		joinGroup = ipv6.NewPacketConn(conn).JoinGroup
+	}
 	joined := 0
 	for _, intf := range intfs {
-		err := pconn.JoinGroup(&intf, &net.UDPAddr{IP: gaddr.IP})
+		err := joinGroup(&intf, &net.UDPAddr{IP: gaddr.IP})
 		if err != nil {
-			l.Debugln("IPv6 join", intf.Name, "failed:", err)
+			l.Debugln("Multicast join", intf.Name, "failed:", err)
 		} else {
-			l.Debugln("IPv6 join", intf.Name, "success")
+			l.Debugln("Multicast join", intf.Name, "success")
 		}
 		joined++
 	}
@@ -201,7 +276,7 @@ func (r *multicastReader) Serve() {
 
 	bs := make([]byte, 65536)
 	for {
-		n, _, addr, err := pconn.ReadFrom(bs)
+		n, addr, err := conn.ReadFrom(bs)
 		if err != nil {
 			l.Debugln(err)
 			r.setError(err)
@@ -219,6 +294,13 @@ func (r *multicastReader) Serve() {
 	}
 }
 
+func multicastNetwork(gaddr *net.UDPAddr) string {
+	if gaddr.IP.To4() != nil {
+		return "udp4"
+	}
+	return "udp6"
+}
+
 func (r *multicastReader) Stop() {
 	close(r.stop)
 }
@@ -0,0 +1,111 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+const (
+	AuditSinkFile   = "file"
+	AuditSinkSyslog = "syslog"
+
+	AuditFormatJSON = "json" // versioned audit records
+	AuditFormatRaw  = "raw"  // events as returned by the REST API
+)
+
+type AuditSinkConfiguration struct {
+	ID      string   `xml:"id,attr" json:"id"`
+	Enabled bool     `xml:"enabled,attr" json:"enabled"`
+	Type    string   `xml:"type,attr" json:"type"` // AuditSinkFile or AuditSinkSyslog
+	Format  string   `xml:"format" json:"format"`  // AuditFormatJSON or AuditFormatRaw; syslog messages are always AuditFormatJSON.
+	Events  []string `xml:"event" json:"events"`   // The event types to write, or all if empty.
+
+	// File sinks
+	Path            string `xml:"path,omitempty" json:"path"`
+	MaxSizeMiB      int    `xml:"maxSizeMiB" json:"maxSizeMiB"`           // Rotate when the file would grow beyond this size; 0 for off.
+	RotateIntervalH int    `xml:"rotateIntervalH" json:"rotateIntervalH"` // Rotate when the file has been written to for this long; 0 for off.
+	MaxFiles        int    `xml:"maxFiles" json:"maxFiles"`               // The number of rotated files to keep.
+	Compress        bool   `xml:"compress" json:"compress"`               // Compress rotated files with gzip.
+
+	// Syslog sinks
+	Network  string `xml:"network,omitempty" json:"network"` // "unixgram", "unix" or "udp"; a local socket is found if empty.
+	Address  string `xml:"address,omitempty" json:"address"`
+	Facility string `xml:"facility,omitempty" json:"facility"`
+	AppName  string `xml:"appName,omitempty" json:"appName"`
+}
+
+func (c AuditSinkConfiguration) Copy() AuditSinkConfiguration {
+	c.Events = append([]string(nil), c.Events...)
+	return c
+}
+
+// EventMask returns the mask of the configured event types. Unknown event
+// types are ignored.
+func (c AuditSinkConfiguration) EventMask() events.EventType {
+	return eventMask(c.Events)
+}
+
+// FacilityCode returns the syslog facility number for the configured
+// facility name.
+func (c AuditSinkConfiguration) FacilityCode() int {
+	return syslogFacilities[c.Facility]
+}
+
+func (c *AuditSinkConfiguration) prepare() error {
+	switch c.Type {
+	case AuditSinkFile:
+		if c.Path == "" {
+			return fmt.Errorf("audit sink %q: missing path", c.ID)
+		}
+	case AuditSinkSyslog:
+		if c.Address == "" && c.Network != "" {
+			return fmt.Errorf("audit sink %q: missing address", c.ID)
+		}
+	default:
+		return fmt.Errorf("audit sink %q: unknown type %q", c.ID, c.Type)
+	}
+
+	if c.Format != AuditFormatRaw {
+		c.Format = AuditFormatJSON
+	}
+	if c.MaxSizeMiB < 0 {
+		c.MaxSizeMiB = 0
+	}
+	if c.RotateIntervalH < 0 {
+		c.RotateIntervalH = 0
+	}
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = 10
+	}
+	if _, ok := syslogFacilities[c.Facility]; !ok {
+		c.Facility = "authpriv"
+	}
+	if c.AppName == "" {
+		c.AppName = "syncthing"
+	}
+	return nil
+}
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"daemon":   3,
+	"auth":     4,
+	"authpriv": 10,
+	"audit":    13,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
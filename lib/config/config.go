@@ -75,6 +75,8 @@ func New(myID protocol.DeviceID) Configuration {
 	util.SetDefaults(&cfg)
 	util.SetDefaults(&cfg.Options)
 	util.SetDefaults(&cfg.GUI)
+	util.SetDefaults(&cfg.OIDC)
+	util.SetDefaults(&cfg.Defaults.Folder)
 
 	// Can't happen.
 	if err := cfg.prepare(myID); err != nil {
@@ -115,6 +117,8 @@ func ReadXML(r io.Reader, myID protocol.DeviceID) (Configuration, error) {
 	util.SetDefaults(&cfg)
 	util.SetDefaults(&cfg.Options)
 	util.SetDefaults(&cfg.GUI)
+	util.SetDefaults(&cfg.OIDC)
+	util.SetDefaults(&cfg.Defaults.Folder)
 
 	if err := xml.NewDecoder(r).Decode(&cfg); err != nil {
 		return Configuration{}, err
@@ -133,6 +137,8 @@ func ReadJSON(r io.Reader, myID protocol.DeviceID) (Configuration, error) {
 	util.SetDefaults(&cfg)
 	util.SetDefaults(&cfg.Options)
 	util.SetDefaults(&cfg.GUI)
+	util.SetDefaults(&cfg.OIDC)
+	util.SetDefaults(&cfg.Defaults.Folder)
 
 	bs, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -151,15 +157,19 @@ func ReadJSON(r io.Reader, myID protocol.DeviceID) (Configuration, error) {
 }
 
 type Configuration struct {
-	Version        int                   `xml:"version,attr" json:"version"`
-	Folders        []FolderConfiguration `xml:"folder" json:"folders"`
-	Devices        []DeviceConfiguration `xml:"device" json:"devices"`
-	GUI            GUIConfiguration      `xml:"gui" json:"gui"`
-	LDAP           LDAPConfiguration     `xml:"ldap" json:"ldap"`
-	Options        OptionsConfiguration  `xml:"options" json:"options"`
-	IgnoredDevices []ObservedDevice      `xml:"remoteIgnoredDevice" json:"remoteIgnoredDevices"`
-	PendingDevices []ObservedDevice      `xml:"pendingDevice" json:"pendingDevices"`
-	XMLName        xml.Name              `xml:"configuration" json:"-"`
+	Version        int                      `xml:"version,attr" json:"version"`
+	Folders        []FolderConfiguration    `xml:"folder" json:"folders"`
+	Devices        []DeviceConfiguration    `xml:"device" json:"devices"`
+	GUI            GUIConfiguration         `xml:"gui" json:"gui"`
+	LDAP           LDAPConfiguration        `xml:"ldap" json:"ldap"`
+	OIDC           OIDCConfiguration        `xml:"oidc" json:"oidc"`
+	Options        OptionsConfiguration     `xml:"options" json:"options"`
+	Defaults       DefaultsConfiguration    `xml:"defaults" json:"defaults"`
+	IgnoredDevices []ObservedDevice         `xml:"remoteIgnoredDevice" json:"remoteIgnoredDevices"`
+	PendingDevices []ObservedDevice         `xml:"pendingDevice" json:"pendingDevices"`
+	Webhooks       []WebhookConfiguration   `xml:"webhook" json:"webhooks"`
+	AuditSinks     []AuditSinkConfiguration `xml:"auditSink" json:"auditSinks"`
+	XMLName        xml.Name                 `xml:"configuration" json:"-"`
 
 	MyID            protocol.DeviceID `xml:"-" json:"-"` // Provided by the instantiator.
 	OriginalVersion int               `xml:"-" json:"-"` // The version we read from disk, before any conversion
@@ -182,6 +192,8 @@ func (cfg Configuration) Copy() Configuration {
 
 	newCfg.Options = cfg.Options.Copy()
 	newCfg.GUI = cfg.GUI.Copy()
+	newCfg.OIDC = cfg.OIDC.Copy()
+	newCfg.Defaults = cfg.Defaults.Copy()
 
 	// DeviceIDs are values
 	newCfg.IgnoredDevices = make([]ObservedDevice, len(cfg.IgnoredDevices))
@@ -190,6 +202,16 @@ func (cfg Configuration) Copy() Configuration {
 	newCfg.PendingDevices = make([]ObservedDevice, len(cfg.PendingDevices))
 	copy(newCfg.PendingDevices, cfg.PendingDevices)
 
+	newCfg.Webhooks = make([]WebhookConfiguration, len(cfg.Webhooks))
+	for i := range newCfg.Webhooks {
+		newCfg.Webhooks[i] = cfg.Webhooks[i].Copy()
+	}
+
+	newCfg.AuditSinks = make([]AuditSinkConfiguration, len(cfg.AuditSinks))
+	for i := range newCfg.AuditSinks {
+		newCfg.AuditSinks[i] = cfg.AuditSinks[i].Copy()
+	}
+
 	return newCfg
 }
 
@@ -258,6 +280,69 @@ func (cfg *Configuration) clean() error {
 		existingFolders[folder.ID] = folder
 	}
 
+	existingWebhooks := make(map[string]struct{})
+	for i := range cfg.Webhooks {
+		hook := &cfg.Webhooks[i]
+
+		if hook.ID == "" {
+			return fmt.Errorf("webhook with empty ID in configuration")
+		}
+
+		if _, ok := existingWebhooks[hook.ID]; ok {
+			return fmt.Errorf("duplicate webhook ID %q in configuration", hook.ID)
+		}
+		existingWebhooks[hook.ID] = struct{}{}
+
+		if err := hook.prepare(); err != nil {
+			return err
+		}
+	}
+
+	existingAuditSinks := make(map[string]struct{})
+	for i := range cfg.AuditSinks {
+		sink := &cfg.AuditSinks[i]
+
+		if sink.ID == "" {
+			return fmt.Errorf("audit sink with empty ID in configuration")
+		}
+
+		if _, ok := existingAuditSinks[sink.ID]; ok {
+			return fmt.Errorf("duplicate audit sink ID %q in configuration", sink.ID)
+		}
+		existingAuditSinks[sink.ID] = struct{}{}
+
+		if err := sink.prepare(); err != nil {
+			return err
+		}
+	}
+
+	existingUsers := make(map[string]struct{})
+	for _, user := range cfg.GUI.Users {
+		if user.Name == "" {
+			return fmt.Errorf("GUI user with empty name in configuration")
+		}
+		if _, ok := existingUsers[user.Name]; ok || user.Name == cfg.GUI.User {
+			return fmt.Errorf("duplicate GUI user %q in configuration", user.Name)
+		}
+		existingUsers[user.Name] = struct{}{}
+	}
+
+	existingAPIKeys := make(map[string]struct{})
+	for _, key := range cfg.GUI.APIKeys {
+		if key.Name == "" || key.Key == "" {
+			return fmt.Errorf("API key with empty name or key in configuration")
+		}
+		if _, ok := existingAPIKeys[key.Name]; ok {
+			return fmt.Errorf("duplicate API key name %q in configuration", key.Name)
+		}
+		for _, endpoint := range key.Endpoints {
+			if _, _, ok := splitEndpoint(endpoint); !ok {
+				return fmt.Errorf("invalid endpoint %q for API key %q in configuration", endpoint, key.Name)
+			}
+		}
+		existingAPIKeys[key.Name] = struct{}{}
+	}
+
 	cfg.Options.ListenAddresses = util.UniqueStrings(cfg.Options.ListenAddresses)
 	cfg.Options.GlobalAnnServers = util.UniqueStrings(cfg.Options.GlobalAnnServers)
 
@@ -363,6 +448,14 @@ func (cfg *Configuration) clean() error {
 		cfg.Devices[i].prepare(sharedFolders[cfg.Devices[i].DeviceID])
 	}
 
+	// The templates are cleaned up the same way, as far as that applies
+	cfg.Defaults.Folder.Devices = ensureExistingDevices(cfg.Defaults.Folder.Devices, existingDevices)
+	cfg.Defaults.Folder.Devices = ensureNoDuplicateFolderDevices(cfg.Defaults.Folder.Devices)
+	if cfg.Defaults.Folder.Versioning.Params == nil {
+		cfg.Defaults.Folder.Versioning.Params = map[string]string{}
+	}
+	cfg.Defaults.Device.prepare(nil)
+
 	// Very short reconnection intervals are annoying
 	if cfg.Options.ReconnectIntervalS < 5 {
 		cfg.Options.ReconnectIntervalS = 5
@@ -430,6 +523,9 @@ nextPendingDevice:
 	if cfg.Options.AlwaysLocalNets == nil {
 		cfg.Options.AlwaysLocalNets = []string{}
 	}
+	if cfg.Options.DNSDiscoveryDomains == nil {
+		cfg.Options.DNSDiscoveryDomains = []string{}
+	}
 	if cfg.Options.UnackedNotificationIDs == nil {
 		cfg.Options.UnackedNotificationIDs = []string{}
 	}
@@ -0,0 +1,75 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// DefaultsConfiguration holds the templates that new folders and devices
+// are created from, whether added by the user, auto accepted or
+// introduced. Their IDs, names and paths are not used.
+type DefaultsConfiguration struct {
+	Folder FolderConfiguration `xml:"folder" json:"folder"`
+	Device DeviceConfiguration `xml:"device" json:"device"`
+}
+
+func (c DefaultsConfiguration) Copy() DefaultsConfiguration {
+	c.Folder = c.Folder.Copy()
+	c.Device = c.Device.Copy()
+	return c
+}
+
+// MarshalJSON marshals the templates from an addressable copy, for the
+// device ID of the device template to be marshalled as text.
+func (c DefaultsConfiguration) MarshalJSON() ([]byte, error) {
+	type plain DefaultsConfiguration
+	return json.Marshal((*plain)(&c))
+}
+
+// NewFolder returns a new folder based on the default folder. It's shared
+// with the devices of the default folder and with myID.
+func (c DefaultsConfiguration) NewFolder(myID protocol.DeviceID, id, label string, fsType fs.FilesystemType, path string) FolderConfiguration {
+	f := c.Folder.Copy()
+	f.ID = id
+	f.Label = label
+	f.FilesystemType = fsType
+	f.Path = path
+	f.Devices = ensureDevicePresent(f.Devices, myID)
+	if f.Versioning.Params == nil {
+		f.Versioning.Params = map[string]string{}
+	}
+
+	f.prepare()
+	return f
+}
+
+// NewDevice returns a new device based on the default device.
+func (c DefaultsConfiguration) NewDevice(id protocol.DeviceID, name string) DeviceConfiguration {
+	d := c.Device.Copy()
+	d.DeviceID = id
+	d.Name = name
+
+	d.prepare(nil)
+	return d
+}
+
+// HasDevice returns true if the default device has been configured, i.e.
+// it differs from a device without any settings.
+func (c DefaultsConfiguration) HasDevice() bool {
+	blank := DeviceConfiguration{}.Copy()
+	blank.prepare(nil)
+	d := c.Device.Copy()
+	d.DeviceID = protocol.EmptyDeviceID
+	d.Name = ""
+	d.prepare(nil)
+	return !reflect.DeepEqual(d, blank)
+}
@@ -30,6 +30,10 @@ type DeviceConfiguration struct {
 	IgnoredFolders           []ObservedFolder     `xml:"ignoredFolder" json:"ignoredFolders"`
 	PendingFolders           []ObservedFolder     `xml:"pendingFolder" json:"pendingFolders"`
 	MaxRequestKiB            int                  `xml:"maxRequestKiB" json:"maxRequestKiB"`
+	HideObservedAddresses    bool                 `xml:"hideObservedAddresses" json:"hideObservedAddresses"`
+	IgnoreGossipedAddresses  bool                 `xml:"ignoreGossipedAddresses" json:"ignoreGossipedAddresses"`
+	ProxyURL                 string               `xml:"proxyURL" json:"proxyURL"`
+	NumConnections           int                  `xml:"numConnections" json:"numConnections"`
 }
 
 func NewDeviceConfiguration(id protocol.DeviceID, name string) DeviceConfiguration {
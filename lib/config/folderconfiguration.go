@@ -54,6 +54,11 @@ type FolderConfiguration struct {
 	MarkerName              string                      `xml:"markerName" json:"markerName"`
 	UseLargeBlocks          bool                        `xml:"useLargeBlocks" json:"useLargeBlocks"`
 	CopyOwnershipFromParent bool                        `xml:"copyOwnershipFromParent" json:"copyOwnershipFromParent"`
+	FSWatcherMaxDirs        int                         `xml:"fsWatcherMaxDirs" json:"fsWatcherMaxDirs"`            // The maximum number of directories to watch, the rest is polled. Zero means no limit.
+	FSWatcherPollS          int                         `xml:"fsWatcherPollS" json:"fsWatcherPollS" default:"3600"` // Directories that aren't watched are scanned once within this interval.
+	ScrubIntervalH          int                         `xml:"scrubIntervalH" json:"scrubIntervalH"`                // All file contents are rehashed once within this interval to detect corruption. Zero disables scrubbing.
+	ScrubRateKiBps          int                         `xml:"scrubRateKiBps" json:"scrubRateKiBps" default:"4096"` // The maximum rate at which data is read while scrubbing. Zero means no limit.
+	ScrubRefetch            bool                        `xml:"scrubRefetch" json:"scrubRefetch"`                    // Corrupted files are fetched again from other devices.
 
 	cachedFilesystem fs.Filesystem
 
@@ -225,6 +230,22 @@ func (f *FolderConfiguration) prepare() {
 		f.FSWatcherDelayS = 10
 	}
 
+	if f.FSWatcherMaxDirs < 0 {
+		f.FSWatcherMaxDirs = 0
+	}
+
+	if f.FSWatcherPollS <= 0 {
+		f.FSWatcherPollS = 3600
+	}
+
+	if f.ScrubIntervalH < 0 {
+		f.ScrubIntervalH = 0
+	}
+
+	if f.ScrubRateKiBps < 0 {
+		f.ScrubRateKiBps = 0
+	}
+
 	if f.Versioning.Params == nil {
 		f.Versioning.Params = make(map[string]string)
 	}
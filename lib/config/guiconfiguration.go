@@ -10,25 +10,33 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 )
 
 type GUIConfiguration struct {
-	Enabled                   bool     `xml:"enabled,attr" json:"enabled" default:"true"`
-	RawAddress                string   `xml:"address" json:"address" default:"127.0.0.1:8384"`
-	User                      string   `xml:"user,omitempty" json:"user"`
-	Password                  string   `xml:"password,omitempty" json:"password"`
-	AuthMode                  AuthMode `xml:"authMode,omitempty" json:"authMode"`
-	RawUseTLS                 bool     `xml:"tls,attr" json:"useTLS"`
-	APIKey                    string   `xml:"apikey,omitempty" json:"apiKey"`
-	InsecureAdminAccess       bool     `xml:"insecureAdminAccess,omitempty" json:"insecureAdminAccess"`
-	Theme                     string   `xml:"theme" json:"theme" default:"default"`
-	Debugging                 bool     `xml:"debugging,attr" json:"debugging"`
-	InsecureSkipHostCheck     bool     `xml:"insecureSkipHostcheck,omitempty" json:"insecureSkipHostcheck"`
-	InsecureAllowFrameLoading bool     `xml:"insecureAllowFrameLoading,omitempty" json:"insecureAllowFrameLoading"`
+	Enabled                   bool                  `xml:"enabled,attr" json:"enabled" default:"true"`
+	RawAddress                string                `xml:"address" json:"address" default:"127.0.0.1:8384"`
+	User                      string                `xml:"user,omitempty" json:"user"`
+	Password                  string                `xml:"password,omitempty" json:"password"`
+	AuthMode                  AuthMode              `xml:"authMode,omitempty" json:"authMode"`
+	RawUseTLS                 bool                  `xml:"tls,attr" json:"useTLS"`
+	APIKey                    string                `xml:"apikey,omitempty" json:"apiKey"`
+	InsecureAdminAccess       bool                  `xml:"insecureAdminAccess,omitempty" json:"insecureAdminAccess"`
+	Theme                     string                `xml:"theme" json:"theme" default:"default"`
+	Debugging                 bool                  `xml:"debugging,attr" json:"debugging"`
+	InsecureSkipHostCheck     bool                  `xml:"insecureSkipHostcheck,omitempty" json:"insecureSkipHostcheck"`
+	InsecureAllowFrameLoading bool                  `xml:"insecureAllowFrameLoading,omitempty" json:"insecureAllowFrameLoading"`
+	LoginFailureLimit         int                   `xml:"loginFailureLimit" json:"loginFailureLimit" default:"5"` // per IP and per user before lockout, zero to disable
+	LoginLockoutS             int                   `xml:"loginLockoutS" json:"loginLockoutS" default:"60"`        // doubled for each further failure
+	LoginLockoutMaxS          int                   `xml:"loginLockoutMaxS" json:"loginLockoutMaxS" default:"3600"`
+	TOTPSecret                string                `xml:"totpSecret,omitempty" json:"totpSecret"` // for User, see GUIUser
+	RecoveryCodes             []string              `xml:"recoveryCode" json:"recoveryCodes"`
+	Users                     []GUIUser             `xml:"guiUser" json:"users"`
+	APIKeys                   []APIKeyConfiguration `xml:"namedApiKey" json:"apiKeys"`
 }
 
 func (c GUIConfiguration) IsAuthEnabled() bool {
-	return c.AuthMode == AuthModeLDAP || (len(c.User) > 0 && len(c.Password) > 0)
+	return c.AuthMode == AuthModeLDAP || c.AuthMode == AuthModeOIDC || (len(c.User) > 0 && len(c.Password) > 0) || len(c.Users) > 0
 }
 
 func (c GUIConfiguration) IsOverridden() bool {
@@ -127,10 +135,77 @@ func (c GUIConfiguration) IsValidAPIKey(apiKey string) bool {
 		return true
 
 	default:
-		return false
+		_, ok := c.NamedAPIKey(apiKey)
+		return ok
+	}
+}
+
+// NamedAPIKey returns the named API key configuration with the given key,
+// if any and it hasn't expired.
+func (c GUIConfiguration) NamedAPIKey(apiKey string) (APIKeyConfiguration, bool) {
+	if apiKey == "" {
+		return APIKeyConfiguration{}, false
+	}
+	now := time.Now()
+	for _, k := range c.APIKeys {
+		if k.Key == apiKey && !k.Expired(now) {
+			return k, true
+		}
+	}
+	return APIKeyConfiguration{}, false
+}
+
+// GUIUser returns the configuration of the named user in Users, if any.
+func (c GUIConfiguration) GUIUser(name string) (GUIUser, bool) {
+	for _, u := range c.Users {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return GUIUser{}, false
+}
+
+// SecondFactor returns the TOTP secret and recovery code hashes of the
+// named user, which are empty when the user has no second factor.
+func (c GUIConfiguration) SecondFactor(username string) (string, []string) {
+	if u, ok := c.GUIUser(username); ok {
+		return u.TOTPSecret, u.RecoveryCodes
+	}
+	if username != "" && username == c.User {
+		return c.TOTPSecret, c.RecoveryCodes
 	}
+	return "", nil
+}
+
+// SetSecondFactor sets the TOTP secret and recovery code hashes of the
+// named user, returning false if there is no such user.
+func (c *GUIConfiguration) SetSecondFactor(username, secret string, recoveryCodes []string) bool {
+	for i := range c.Users {
+		if c.Users[i].Name == username {
+			c.Users[i].TOTPSecret = secret
+			c.Users[i].RecoveryCodes = recoveryCodes
+			return true
+		}
+	}
+	if username != "" && username == c.User {
+		c.TOTPSecret = secret
+		c.RecoveryCodes = recoveryCodes
+		return true
+	}
+	return false
 }
 
 func (c GUIConfiguration) Copy() GUIConfiguration {
+	c.RecoveryCodes = append([]string(nil), c.RecoveryCodes...)
+	users := make([]GUIUser, len(c.Users))
+	for i, u := range c.Users {
+		users[i] = u.Copy()
+	}
+	c.Users = users
+	apiKeys := make([]APIKeyConfiguration, len(c.APIKeys))
+	for i, k := range c.APIKeys {
+		apiKeys[i] = k.Copy()
+	}
+	c.APIKeys = apiKeys
 	return c
 }
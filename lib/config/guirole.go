@@ -0,0 +1,125 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// A GUIRole is the level of access a GUI user or API key has. Each role
+// includes the access of the ones before it.
+type GUIRole int
+
+const (
+	GUIRoleReadOnly GUIRole = iota // default is read-only
+	GUIRoleOperator
+	GUIRoleAdmin
+)
+
+func (r GUIRole) String() string {
+	switch r {
+	case GUIRoleReadOnly:
+		return "read-only"
+	case GUIRoleOperator:
+		return "operator"
+	case GUIRoleAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+func (r GUIRole) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *GUIRole) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "admin":
+		*r = GUIRoleAdmin
+	case "operator":
+		*r = GUIRoleOperator
+	default:
+		*r = GUIRoleReadOnly
+	}
+	return nil
+}
+
+// Allows returns true if the role includes the access of the other role.
+func (r GUIRole) Allows(other GUIRole) bool {
+	return r >= other
+}
+
+// A GUIUser is a user that can log in to the GUI, in addition to the
+// administrator given by GUIConfiguration.User.
+type GUIUser struct {
+	Name          string   `xml:"name,attr" json:"name"`
+	Password      string   `xml:"password" json:"password"` // bcrypt hash
+	Role          GUIRole  `xml:"role" json:"role"`
+	Folders       []string `xml:"folder" json:"folders"`                  // The folders the user can access, or all if empty.
+	TOTPSecret    string   `xml:"totpSecret,omitempty" json:"totpSecret"` // base32, enables the second factor when set
+	RecoveryCodes []string `xml:"recoveryCode" json:"recoveryCodes"`      // SHA-256 hashes of the unused recovery codes
+}
+
+func (u GUIUser) Copy() GUIUser {
+	u.Folders = append([]string(nil), u.Folders...)
+	u.RecoveryCodes = append([]string(nil), u.RecoveryCodes...)
+	return u
+}
+
+// An APIKeyConfiguration is an API key with a role, in addition to the
+// administrator key given by GUIConfiguration.APIKey.
+type APIKeyConfiguration struct {
+	Name      string    `xml:"name,attr" json:"name"`
+	Key       string    `xml:"key" json:"key"`
+	Role      GUIRole   `xml:"role" json:"role"`
+	Folders   []string  `xml:"folder" json:"folders"`     // The folders the key can access, or all if empty.
+	Endpoints []string  `xml:"endpoint" json:"endpoints"` // The endpoints the key can use, as "METHOD /path", or all if empty.
+	Expires   time.Time `xml:"expires" json:"expires"`    // When the key stops being valid, or never if zero.
+}
+
+func (k APIKeyConfiguration) Copy() APIKeyConfiguration {
+	k.Folders = append([]string(nil), k.Folders...)
+	k.Endpoints = append([]string(nil), k.Endpoints...)
+	return k
+}
+
+// Expired returns true if the key has an expiry that is not after now.
+func (k APIKeyConfiguration) Expired(now time.Time) bool {
+	return !k.Expires.IsZero() && !now.Before(k.Expires)
+}
+
+// AllowsEndpoint returns true if the key may be used for the given method
+// and path. An endpoint is given as "METHOD /path", where the method may be
+// "*" for all methods and a path ending in "*" matches all paths with that
+// prefix.
+func (k APIKeyConfiguration) AllowsEndpoint(method, path string) bool {
+	if len(k.Endpoints) == 0 {
+		return true
+	}
+	for _, endpoint := range k.Endpoints {
+		m, p, ok := splitEndpoint(endpoint)
+		if !ok || (m != "*" && m != method) {
+			continue
+		}
+		if p == path || (strings.HasSuffix(p, "*") && strings.HasPrefix(path, p[:len(p)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitEndpoint splits an endpoint into the method and path, returning
+// false if it's not of the form "METHOD /path".
+func splitEndpoint(endpoint string) (string, string, bool) {
+	fields := strings.Fields(endpoint)
+	if len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+		return "", "", false
+	}
+	return strings.ToUpper(fields[0]), fields[1], true
+}
@@ -0,0 +1,334 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/osutil"
+)
+
+// HistoryDir is the directory next to the config file where the last
+// saved configurations are kept, as set by the configHistoryRevisions
+// option.
+const HistoryDir = "config.history"
+
+const historyIndexFile = "index.json"
+
+// ErrNoSuchRevision is returned for revisions that aren't in the history,
+// or no longer.
+var ErrNoSuchRevision = errors.New("no such revision")
+
+// A Revision is a configuration as it was saved at some point.
+type Revision struct {
+	Revision int       `json:"revision"`
+	Time     time.Time `json:"time"`
+	Author   string    `json:"author"` // who made the change, empty for Syncthing itself or edits of the config file
+}
+
+// The configHistory keeps the configurations in files named by their
+// revision, with an index listing the revisions in order.
+type configHistory struct {
+	dir  string
+	keep int
+}
+
+func (h configHistory) revisions() ([]Revision, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(h.dir, historyIndexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var revs []Revision
+	if err := json.Unmarshal(bs, &revs); err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+func (h configHistory) read(rev int) ([]byte, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(h.dir, strconv.Itoa(rev)+".xml"))
+	if os.IsNotExist(err) {
+		return nil, ErrNoSuchRevision
+	}
+	return bs, err
+}
+
+// record adds the configuration as a new revision, unless it's the same
+// as the last one, and forgets the revisions beyond the ones to keep.
+func (h configHistory) record(bs []byte, author string, t time.Time) error {
+	revs, err := h.revisions()
+	if err != nil {
+		return err
+	}
+
+	next := 1
+	if len(revs) > 0 {
+		last := revs[len(revs)-1].Revision
+		if prev, err := h.read(last); err == nil && bytes.Equal(prev, bs) {
+			return nil
+		}
+		next = last + 1
+	}
+
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(h.dir, strconv.Itoa(next)+".xml"), bs); err != nil {
+		return err
+	}
+	revs = append(revs, Revision{Revision: next, Time: t, Author: author})
+	for len(revs) > h.keep {
+		os.Remove(filepath.Join(h.dir, strconv.Itoa(revs[0].Revision)+".xml"))
+		revs = revs[1:]
+	}
+
+	index, err := json.MarshalIndent(revs, "", "    ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(h.dir, historyIndexFile), index)
+}
+
+// keepCredentials sets the GUI credentials of cfg, passwords, second
+// factors and API keys, to those of current.
+func keepCredentials(cfg *Configuration, current Configuration) {
+	gui := current.GUI.Copy()
+	cfg.GUI.User = gui.User
+	cfg.GUI.Password = gui.Password
+	cfg.GUI.APIKey = gui.APIKey
+	cfg.GUI.TOTPSecret = gui.TOTPSecret
+	cfg.GUI.RecoveryCodes = gui.RecoveryCodes
+	cfg.GUI.Users = gui.Users
+	cfg.GUI.APIKeys = gui.APIKeys
+	cfg.OIDC.ClientSecret = current.OIDC.ClientSecret
+}
+
+func writeFileAtomic(path string, bs []byte) error {
+	fd, err := osutil.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(bs); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// diffLines returns a unified diff from the lines of a to those of b, with
+// three lines of context around the changes, or an empty string if they
+// are the same.
+func diffLines(aName, bName string, a, b []string) string {
+	const context = 3
+
+	// The edit script, with the line numbers in a and b before each line.
+	var script []lineEdit
+	var changes []int
+	i, j := 0, 0
+	for _, op := range diffOps(a, b) {
+		e := lineEdit{op: op, ai: i, bi: j}
+		switch op {
+		case ' ':
+			e.text = a[i]
+			i++
+			j++
+		case '-':
+			e.text = a[i]
+			i++
+		case '+':
+			e.text = b[j]
+			j++
+		}
+		if op != ' ' {
+			changes = append(changes, len(script))
+		}
+		script = append(script, e)
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+	for c := 0; c < len(changes); {
+		// A hunk goes on for as long as the changes are close enough for
+		// their context to overlap.
+		first, last := changes[c], changes[c]
+		for c++; c < len(changes) && changes[c]-last <= 2*context; c++ {
+			last = changes[c]
+		}
+		start, end := first-context, last+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(script) {
+			end = len(script)
+		}
+
+		var aLen, bLen int
+		for _, e := range script[start:end] {
+			if e.op != '+' {
+				aLen++
+			}
+			if e.op != '-' {
+				bLen++
+			}
+		}
+		aStart, bStart := script[start].ai+1, script[start].bi+1
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, e := range script[start:end] {
+			buf.WriteByte(e.op)
+			buf.WriteString(e.text)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
+type lineEdit struct {
+	op     byte
+	text   string
+	ai, bi int
+}
+
+// maxDiffEdits bounds the work done by diffOps, which is quadratic in the
+// number of changes.
+const maxDiffEdits = 1000
+
+// diffOps returns the shortest edit script from a to b, as found by Myers'
+// algorithm: ' ' for a line kept, '-' for a line of a removed and '+' for a
+// line of b added. Removals come before the additions replacing them. When
+// there are more than maxDiffEdits changes, everything between the common
+// prefix and suffix is replaced as a whole instead.
+func diffOps(a, b []string) []byte {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	ops := make([]byte, 0, prefix+len(a)+len(b)+suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, ' ')
+	}
+	ops = append(ops, myersOps(a, b)...)
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, ' ')
+	}
+	return ops
+}
+
+func myersOps(a, b []string) []byte {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxDiffEdits {
+		limit = maxDiffEdits
+	}
+
+	// v[offset+k] is the furthest x reached on diagonal k = x-y. The
+	// trace keeps the diagonals -d..d of v as they were before each step
+	// d, for walking back along the path found.
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down, adding from b
+			} else {
+				x = v[offset+k-1] + 1 // right, removing from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		ops := make([]byte, 0, n+m)
+		for i := 0; i < n; i++ {
+			ops = append(ops, '-')
+		}
+		for i := 0; i < m; i++ {
+			ops = append(ops, '+')
+		}
+		return ops
+	}
+
+	// Walk back from the end, collecting the operations in reverse.
+	var ops []byte
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := func(k int) int { return trace[d][d+k] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev(k-1) < prev(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, ' ')
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, '+')
+		} else {
+			ops = append(ops, '-')
+		}
+		x, y = prevX, prevY
+	}
+	for ; x > 0; x-- {
+		ops = append(ops, ' ')
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func splitLines(bs []byte) []string {
+	s := strings.TrimSuffix(string(bs), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
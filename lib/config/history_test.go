@@ -0,0 +1,205 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.xml")
+	cfg := New(device1)
+	cfg.Options.ConfigHistoryRevisions = 3
+	w := Wrap(path, cfg)
+	if err := w.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	setRate := func(rate int, author string) {
+		t.Helper()
+		cfg := w.RawCopy()
+		cfg.Options.MaxSendKbps = rate
+		if _, err := w.Replace(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.SaveBy(author); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setRate(100, "alice")
+	setRate(100, "alice") // no change, no revision
+
+	// Editing the file by hand makes a revision of its own when the
+	// configuration is saved the next time.
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs = []byte(strings.Replace(string(bs), "<startBrowser>true</startBrowser>", "<startBrowser>false</startBrowser>", 1))
+	if err := ioutil.WriteFile(path, bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	setRate(200, "bob")
+
+	// Only the last three are kept.
+	revs, err := w.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var authors []string
+	for _, rev := range revs {
+		authors = append(authors, rev.Author)
+	}
+	if len(revs) != 3 || revs[0].Revision != 2 || strings.Join(authors, ",") != "alice,,bob" {
+		t.Fatalf("Unexpected history %+v", revs)
+	}
+	if _, err := w.ReadRevision(1); err != ErrNoSuchRevision {
+		t.Error("Expected the first revision to be forgotten, not", err)
+	}
+
+	// The running configuration didn't have the edit, so it's undone
+	// again by the next save.
+	diff, err := w.RevisionDiff(0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "--- revision 3\n") || !strings.Contains(diff, "-        <maxSendKbps>100</maxSendKbps>\n+        <maxSendKbps>200</maxSendKbps>\n") || !strings.Contains(diff, "-        <startBrowser>false</startBrowser>\n+        <startBrowser>true</startBrowser>\n") {
+		t.Errorf("Unexpected diff from the previous revision:\n%s", diff)
+	}
+	if diff, err := w.RevisionDiff(2, 3); err != nil || !strings.Contains(diff, "-        <startBrowser>true</startBrowser>\n+        <startBrowser>false</startBrowser>\n") {
+		t.Errorf("Unexpected diff between revisions (%v):\n%s", err, diff)
+	}
+
+	old, err := w.ReadRevision(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Options.MaxSendKbps != 100 || !old.Options.StartBrowser {
+		t.Errorf("Unexpected options in old revision %+v", old.Options)
+	}
+
+	// Old revisions come with the current credentials, and are converted
+	// from the version they were saved with.
+	cfg = w.RawCopy()
+	cfg.GUI.User, cfg.GUI.Password, cfg.GUI.APIKey, cfg.GUI.TOTPSecret = "admin", "hash", "key", "secret"
+	cfg.GUI.Users = []GUIUser{{Name: "bob", Password: "bobhash", Role: GUIRoleReadOnly}}
+	if _, err := w.Replace(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SaveBy("alice"); err != nil {
+		t.Fatal(err)
+	}
+	revPath := filepath.Join(dir, HistoryDir, "3.xml")
+	if bs, err = ioutil.ReadFile(revPath); err != nil {
+		t.Fatal(err)
+	}
+	bs = []byte(strings.Replace(string(bs), `version="`+strconv.Itoa(CurrentVersion)+`"`, `version="27"`, 1))
+	if err := ioutil.WriteFile(revPath, bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	old, err = w.ReadRevision(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Version != CurrentVersion {
+		t.Errorf("Old revision has version %d, expected %d", old.Version, CurrentVersion)
+	}
+	if old.GUI.User != "admin" || old.GUI.Password != "hash" || old.GUI.APIKey != "key" || old.GUI.TOTPSecret != "secret" || len(old.GUI.Users) != 1 || old.GUI.Users[0].Password != "bobhash" {
+		t.Errorf("Old revision doesn't have the current credentials: %+v", old.GUI)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := strings.Split("1 2 3 4 5 6 7 8 9 10 11 12 13 14 15", " ")
+	b := strings.Split("1 2 three 4 5 6 7 8 9 10 11 12 13 14 15 16", " ")
+	expected := `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`
+	if diff := diffLines("a", "b", a, b); diff != expected {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+	if diff := diffLines("a", "b", a, a); diff != "" {
+		t.Errorf("Unexpected diff of the same lines:\n%s", diff)
+	}
+	if diff := diffLines("a", "b", nil, []string{"x"}); diff != "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n" {
+		t.Errorf("Unexpected diff from nothing:\n%s", diff)
+	}
+
+	// Large inputs with few changes are cheap, and the edit script turns
+	// the one into the other whether or not there are too many changes to
+	// look for the shortest script.
+	var long, other []string
+	for i := 0; i < 20000; i++ {
+		long = append(long, strconv.Itoa(i))
+		if i%1000 == 500 {
+			other = append(other, "changed")
+		} else {
+			other = append(other, strconv.Itoa(i))
+		}
+	}
+	reversed := make([]string, len(long))
+	for i := range long {
+		reversed[i] = long[len(long)-1-i]
+	}
+	for _, tc := range []struct {
+		b       []string
+		changes int
+	}{
+		{other, 40},
+		{reversed, 2 * len(long)}, // replaced as a whole
+	} {
+		ops := diffOps(long, tc.b)
+		var gotA, gotB []string
+		i, j, changes := 0, 0, 0
+		for _, op := range ops {
+			switch op {
+			case ' ':
+				gotA, gotB = append(gotA, long[i]), append(gotB, tc.b[j])
+				i++
+				j++
+			case '-':
+				gotA = append(gotA, long[i])
+				i++
+				changes++
+			case '+':
+				gotB = append(gotB, tc.b[j])
+				j++
+				changes++
+			}
+		}
+		if strings.Join(gotA, " ") != strings.Join(long, " ") || strings.Join(gotB, " ") != strings.Join(tc.b, " ") {
+			t.Error("Edit script doesn't turn the one into the other")
+		}
+		if changes != tc.changes {
+			t.Errorf("Edit script has %d changes, expected %d", changes, tc.changes)
+		}
+	}
+}
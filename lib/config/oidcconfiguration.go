@@ -0,0 +1,41 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+// OIDCConfiguration is the OpenID Connect provider used to log in to the
+// GUI when the authentication mode is "oidc".
+type OIDCConfiguration struct {
+	Issuer        string            `xml:"issuer,omitempty" json:"issuer"`
+	ClientID      string            `xml:"clientID,omitempty" json:"clientID"`
+	ClientSecret  string            `xml:"clientSecret,omitempty" json:"clientSecret"`
+	RedirectURL   string            `xml:"redirectURL,omitempty" json:"redirectURL"` // The GUI URL with "/oidc/callback" by default
+	Scopes        []string          `xml:"scope" json:"scopes"`                      // In addition to "openid"
+	UsernameClaim string            `xml:"usernameClaim,omitempty" json:"usernameClaim" default:"preferred_username"`
+	RoleClaim     string            `xml:"roleClaim,omitempty" json:"roleClaim" default:"groups"`
+	RoleMappings  []OIDCRoleMapping `xml:"roleMapping" json:"roleMappings"`
+}
+
+// An OIDCRoleMapping gives users with the value in their role claim a role
+// on all folders or, optionally, only on some. Users matching several
+// mappings get the highest role of those covering each folder. Users
+// matching none can't log in.
+type OIDCRoleMapping struct {
+	Value   string   `xml:"value,attr" json:"value"`
+	Role    GUIRole  `xml:"role" json:"role"`
+	Folders []string `xml:"folder" json:"folders"` // The folders the users can access, or all if empty.
+}
+
+func (c OIDCConfiguration) Copy() OIDCConfiguration {
+	c.Scopes = append([]string(nil), c.Scopes...)
+	mappings := make([]OIDCRoleMapping, len(c.RoleMappings))
+	for i, m := range c.RoleMappings {
+		m.Folders = append([]string(nil), m.Folders...)
+		mappings[i] = m
+	}
+	c.RoleMappings = mappings
+	return c
+}
@@ -19,11 +19,15 @@ type OptionsConfiguration struct {
 	LocalAnnEnabled         bool     `xml:"localAnnounceEnabled" json:"localAnnounceEnabled" default:"true" restart:"true"`
 	LocalAnnPort            int      `xml:"localAnnouncePort" json:"localAnnouncePort" default:"21027" restart:"true"`
 	LocalAnnMCAddr          string   `xml:"localAnnounceMCAddr" json:"localAnnounceMCAddr" default:"[ff12::8384]:21027" restart:"true"`
+	MDNSEnabled             bool     `xml:"mdnsEnabled" json:"mdnsEnabled" default:"false" restart:"true"`
+	DNSDiscoveryDomains     []string `xml:"dnsDiscoveryDomain" json:"dnsDiscoveryDomains" restart:"true"`
+	AddressGossipEnabled    bool     `xml:"addressGossipEnabled" json:"addressGossipEnabled" default:"true"`
 	MaxSendKbps             int      `xml:"maxSendKbps" json:"maxSendKbps"`
 	MaxRecvKbps             int      `xml:"maxRecvKbps" json:"maxRecvKbps"`
 	ReconnectIntervalS      int      `xml:"reconnectionIntervalS" json:"reconnectionIntervalS" default:"60"`
 	RelaysEnabled           bool     `xml:"relaysEnabled" json:"relaysEnabled" default:"true"`
 	RelayReconnectIntervalM int      `xml:"relayReconnectIntervalM" json:"relayReconnectIntervalM" default:"10"`
+	ProxyURL                string   `xml:"proxyURL" json:"proxyURL"`
 	StartBrowser            bool     `xml:"startBrowser" json:"startBrowser" default:"true"`
 	NATEnabled              bool     `xml:"natEnabled" json:"natEnabled" default:"true"`
 	NATLeaseM               int      `xml:"natLeaseMinutes" json:"natLeaseMinutes" default:"60"`
@@ -52,6 +56,10 @@ type OptionsConfiguration struct {
 	DefaultFolderPath       string   `xml:"defaultFolderPath" json:"defaultFolderPath" default:"~"`
 	SetLowPriority          bool     `xml:"setLowPriority" json:"setLowPriority" default:"true"`
 	MaxConcurrentScans      int      `xml:"maxConcurrentScans" json:"maxConcurrentScans"`
+	DatabaseCheckIntervalH  int      `xml:"databaseCheckIntervalH" json:"databaseCheckIntervalH"` // 0 for off
+	EventHistoryDays        int      `xml:"eventHistoryDays" json:"eventHistoryDays"`             // 0 for off
+	EventHistoryTypes       []string `xml:"eventHistoryType" json:"eventHistoryTypes" default:"LocalChangeDetected, RemoteChangeDetected, ItemFinished, DeviceConnected, DeviceDisconnected, FolderPaused, FolderResumed, FileCorrupted"`
+	ConfigHistoryRevisions  int      `xml:"configHistoryRevisions" json:"configHistoryRevisions" default:"20"` // 0 for off
 
 	DeprecatedUPnPEnabled        bool     `xml:"upnpEnabled,omitempty" json:"-"`
 	DeprecatedUPnPLeaseM         int      `xml:"upnpLeaseMinutes,omitempty" json:"-"`
@@ -69,8 +77,12 @@ func (orig OptionsConfiguration) Copy() OptionsConfiguration {
 	copy(c.GlobalAnnServers, orig.GlobalAnnServers)
 	c.AlwaysLocalNets = make([]string, len(orig.AlwaysLocalNets))
 	copy(c.AlwaysLocalNets, orig.AlwaysLocalNets)
+	c.DNSDiscoveryDomains = make([]string, len(orig.DNSDiscoveryDomains))
+	copy(c.DNSDiscoveryDomains, orig.DNSDiscoveryDomains)
 	c.UnackedNotificationIDs = make([]string, len(orig.UnackedNotificationIDs))
 	copy(c.UnackedNotificationIDs, orig.UnackedNotificationIDs)
+	c.EventHistoryTypes = make([]string, len(orig.EventHistoryTypes))
+	copy(c.EventHistoryTypes, orig.EventHistoryTypes)
 	return c
 }
 
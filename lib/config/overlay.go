@@ -0,0 +1,573 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	// OverlayDir is the directory next to the config file holding XML and
+	// JSON fragments that are merged on top of it, in the order of their
+	// names.
+	OverlayDir = "config.d"
+
+	// OptionEnvPrefix is the prefix of environment variables overriding
+	// an option, followed by its XML name in upper case.
+	OptionEnvPrefix = "STOPTION_"
+)
+
+// The overlay holds the parts of the configuration that are managed outside
+// of the config file. They are applied on top of every new configuration,
+// so they can't be changed while running, and they are not saved.
+type overlay struct {
+	sections []*overlaySection
+}
+
+// An overlaySection holds the overlaid fields of one of the options, GUI,
+// LDAP or OIDC configurations, or of one folder or device.
+type overlaySection struct {
+	name   string // as in the JSON configuration, e.g. "options" or "folders"
+	id     string // the folder or device ID
+	fields map[string]reflect.Value
+
+	applied  bool
+	added    bool          // the folder or device only exists in the overlay
+	original reflect.Value // as it was before the overlay was first applied
+}
+
+var overlaySectionTypes = map[string]reflect.Type{
+	"options": reflect.TypeOf(OptionsConfiguration{}),
+	"gui":     reflect.TypeOf(GUIConfiguration{}),
+	"ldap":    reflect.TypeOf(LDAPConfiguration{}),
+	"oidc":    reflect.TypeOf(OIDCConfiguration{}),
+	"folders": reflect.TypeOf(FolderConfiguration{}),
+	"devices": reflect.TypeOf(DeviceConfiguration{}),
+}
+
+// loadOverlay reads the fragments in dir, which need not exist, and the
+// option overrides in environ.
+func loadOverlay(dir string, environ []string) (*overlay, error) {
+	o := &overlay{}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, info.Name())
+		var add func([]byte) error
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".xml":
+			add = o.addXML
+		case ".json":
+			add = o.addJSON
+		default:
+			continue
+		}
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(bs); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	if err := o.addEnv(environ); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *overlay) section(name, id string) *overlaySection {
+	for _, s := range o.sections {
+		if s.name == name && s.id == id {
+			return s
+		}
+	}
+	s := &overlaySection{
+		name:   name,
+		id:     id,
+		fields: make(map[string]reflect.Value),
+	}
+	o.sections = append(o.sections, s)
+	return s
+}
+
+// set takes the fields with the given names in encoding from src.
+func (s *overlaySection) set(src reflect.Value, encoding string, names []string) error {
+	for _, name := range names {
+		field, ok := overlayField(src.Type(), encoding, name)
+		if !ok {
+			return fmt.Errorf("unknown field %q in %s", name, s.name)
+		}
+		if field.Name == "ID" || field.Name == "DeviceID" {
+			// Identifies the folder or device rather than changing it.
+			continue
+		}
+		s.fields[field.Name] = src.FieldByIndex(field.Index)
+	}
+	return nil
+}
+
+func (o *overlay) addXML(bs []byte) error {
+	var frag Configuration
+	if err := xml.Unmarshal(bs, &frag); err != nil {
+		return err
+	}
+	elements, err := overlayXMLElements(bs)
+	if err != nil {
+		return err
+	}
+
+	var folders, devices int
+	for _, el := range elements {
+		var s *overlaySection
+		var src reflect.Value
+		switch el.name {
+		case "options":
+			s, src = o.section("options", ""), reflect.ValueOf(frag.Options)
+		case "gui":
+			s, src = o.section("gui", ""), reflect.ValueOf(frag.GUI)
+		case "ldap":
+			s, src = o.section("ldap", ""), reflect.ValueOf(frag.LDAP)
+		case "oidc":
+			s, src = o.section("oidc", ""), reflect.ValueOf(frag.OIDC)
+		case "folder":
+			folder := frag.Folders[folders]
+			folders++
+			if folder.ID == "" {
+				return fmt.Errorf("folder without ID")
+			}
+			s, src = o.section("folders", folder.ID), reflect.ValueOf(folder)
+		case "device":
+			device := frag.Devices[devices]
+			devices++
+			if device.DeviceID == protocol.EmptyDeviceID {
+				return fmt.Errorf("device without ID")
+			}
+			s, src = o.section("devices", device.DeviceID.String()), reflect.ValueOf(device)
+		default:
+			return fmt.Errorf("unsupported element %q", el.name)
+		}
+		if err := s.set(src, "xml", el.fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type overlayXMLElement struct {
+	name   string
+	fields []string // the names of the attributes and child elements
+}
+
+// overlayXMLElements returns the elements below the root of the document.
+func overlayXMLElements(bs []byte) ([]overlayXMLElement, error) {
+	dec := xml.NewDecoder(bytes.NewReader(bs))
+	var elements []overlayXMLElement
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return elements, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 2:
+				el := overlayXMLElement{name: tok.Name.Local}
+				for _, attr := range tok.Attr {
+					el.fields = append(el.fields, attr.Name.Local)
+				}
+				elements = append(elements, el)
+			case 3:
+				el := &elements[len(elements)-1]
+				el.fields = append(el.fields, tok.Name.Local)
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+func (o *overlay) addJSON(bs []byte) error {
+	var frag Configuration
+	if err := json.Unmarshal(bs, &frag); err != nil {
+		return err
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &sections); err != nil {
+		return err
+	}
+
+	for name, raw := range sections {
+		var src reflect.Value
+		switch name {
+		case "version":
+			continue
+		case "options":
+			src = reflect.ValueOf(frag.Options)
+		case "gui":
+			src = reflect.ValueOf(frag.GUI)
+		case "ldap":
+			src = reflect.ValueOf(frag.LDAP)
+		case "oidc":
+			src = reflect.ValueOf(frag.OIDC)
+		case "folders", "devices":
+			var entries []map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &entries); err != nil {
+				return err
+			}
+			for i, entry := range entries {
+				var s *overlaySection
+				if name == "folders" {
+					if frag.Folders[i].ID == "" {
+						return fmt.Errorf("folder without ID")
+					}
+					s, src = o.section(name, frag.Folders[i].ID), reflect.ValueOf(frag.Folders[i])
+				} else {
+					if frag.Devices[i].DeviceID == protocol.EmptyDeviceID {
+						return fmt.Errorf("device without ID")
+					}
+					s, src = o.section(name, frag.Devices[i].DeviceID.String()), reflect.ValueOf(frag.Devices[i])
+				}
+				if err := s.set(src, "json", jsonKeys(entry)); err != nil {
+					return err
+				}
+			}
+			continue
+		default:
+			return fmt.Errorf("unsupported section %q", name)
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return err
+		}
+		if err := o.section(name, "").set(src, "json", jsonKeys(fields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func jsonKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// addEnv takes the options from environment variables like
+// STOPTION_MAXSENDKBPS=100. Lists are separated by commas.
+func (o *overlay) addEnv(environ []string) error {
+	optionsType := reflect.TypeOf(OptionsConfiguration{})
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, OptionEnvPrefix) {
+			continue
+		}
+		kv = strings.TrimPrefix(kv, OptionEnvPrefix)
+		name, value := kv, ""
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			name, value = kv[:i], kv[i+1:]
+		}
+
+		field, ok := overlayField(optionsType, "xml", name)
+		if !ok {
+			return fmt.Errorf("%s%s: unknown option", OptionEnvPrefix, name)
+		}
+		v := reflect.New(field.Type).Elem()
+		if err := parseOverlayValue(v, value); err != nil {
+			return fmt.Errorf("%s%s: %v", OptionEnvPrefix, name, err)
+		}
+		o.section("options", "").fields[field.Name] = v
+	}
+	return nil
+}
+
+func parseOverlayValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(Size{}) {
+		size, err := ParseSize(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(size))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", v.Type())
+		}
+		items := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = reflect.Append(items, reflect.ValueOf(item).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// overlayField returns the field of t called name in encoding, with XML
+// names matched case insensitively.
+func overlayField(t reflect.Type, encoding, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get(encoding), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if tag == name || encoding == "xml" && strings.EqualFold(tag, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// apply sets the overlaid fields in cfg, adding folders and devices that
+// only exist in the overlay. Folders and devices of the config file that
+// have been removed since stay removed.
+func (o *overlay) apply(cfg *Configuration) {
+	for _, s := range o.sections {
+		target, ok := s.target(cfg)
+		if !ok {
+			if s.applied && !s.added {
+				continue
+			}
+			target = s.add(cfg)
+		}
+		if !s.applied {
+			s.applied = true
+			s.added = !ok
+			s.original = reflect.New(target.Type()).Elem()
+			s.original.Set(target)
+		}
+		for name, value := range s.fields {
+			target.FieldByName(name).Set(copyOverlayValue(value))
+		}
+		if folder, ok := target.Addr().Interface().(*FolderConfiguration); ok && cfg.MyID != protocol.EmptyDeviceID {
+			folder.Devices = ensureDevicePresent(folder.Devices, cfg.MyID)
+		}
+	}
+}
+
+// restore undoes the overlay in cfg, as far as possible, for saving it.
+func (o *overlay) restore(cfg *Configuration) {
+	for _, s := range o.sections {
+		if !s.applied {
+			continue
+		}
+		if s.added {
+			s.remove(cfg)
+			continue
+		}
+		target, ok := s.target(cfg)
+		if !ok {
+			continue
+		}
+		for name := range s.fields {
+			target.FieldByName(name).Set(s.original.FieldByName(name))
+		}
+	}
+}
+
+// check returns an error if going from one configuration to the other
+// changes overlaid fields, or removes a folder or device only existing in
+// the overlay.
+func (o *overlay) check(from, to *Configuration) error {
+	var paths []string
+	for _, s := range o.sections {
+		fromTarget, ok := s.target(from)
+		if !ok {
+			continue
+		}
+		toTarget, ok := s.target(to)
+		if !ok {
+			if s.added {
+				paths = append(paths, s.path())
+			}
+			continue
+		}
+		for name := range s.fields {
+			fromBs, _ := json.Marshal(fromTarget.FieldByName(name).Interface())
+			toBs, _ := json.Marshal(toTarget.FieldByName(name).Interface())
+			if !bytes.Equal(fromBs, toBs) {
+				paths = append(paths, s.fieldPath(name))
+			}
+		}
+	}
+	if len(paths) > 0 {
+		sort.Strings(paths)
+		return fmt.Errorf("set by the configuration overlay: %s", strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// locked returns the overlaid fields by their path in the JSON
+// configuration, e.g. "options.maxSendKbps" or "folders.default.path". A
+// folder or device only existing in the overlay is locked as a whole.
+func (o *overlay) locked() []string {
+	var paths []string
+	for _, s := range o.sections {
+		if s.added {
+			paths = append(paths, s.path())
+			continue
+		}
+		for name := range s.fields {
+			paths = append(paths, s.fieldPath(name))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// path returns the path of the section in the JSON configuration.
+func (s *overlaySection) path() string {
+	if s.id != "" {
+		return s.name + "." + s.id
+	}
+	return s.name
+}
+
+// fieldPath returns the path of the named field in the JSON configuration.
+func (s *overlaySection) fieldPath(name string) string {
+	field, _ := overlaySectionTypes[s.name].FieldByName(name)
+	return s.path() + "." + strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+func (s *overlaySection) target(cfg *Configuration) (reflect.Value, bool) {
+	switch s.name {
+	case "options":
+		return reflect.ValueOf(&cfg.Options).Elem(), true
+	case "gui":
+		return reflect.ValueOf(&cfg.GUI).Elem(), true
+	case "ldap":
+		return reflect.ValueOf(&cfg.LDAP).Elem(), true
+	case "oidc":
+		return reflect.ValueOf(&cfg.OIDC).Elem(), true
+	case "folders":
+		for i := range cfg.Folders {
+			if cfg.Folders[i].ID == s.id {
+				return reflect.ValueOf(&cfg.Folders[i]).Elem(), true
+			}
+		}
+	case "devices":
+		for i := range cfg.Devices {
+			if cfg.Devices[i].DeviceID.String() == s.id {
+				return reflect.ValueOf(&cfg.Devices[i]).Elem(), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (s *overlaySection) add(cfg *Configuration) reflect.Value {
+	if s.name == "folders" {
+		cfg.Folders = append(cfg.Folders, cfg.Defaults.NewFolder(cfg.MyID, s.id, "", fs.FilesystemTypeBasic, ""))
+		return reflect.ValueOf(&cfg.Folders[len(cfg.Folders)-1]).Elem()
+	}
+	id, _ := protocol.DeviceIDFromString(s.id)
+	cfg.Devices = append(cfg.Devices, cfg.Defaults.NewDevice(id, ""))
+	return reflect.ValueOf(&cfg.Devices[len(cfg.Devices)-1]).Elem()
+}
+
+func (s *overlaySection) remove(cfg *Configuration) {
+	switch s.name {
+	case "folders":
+		for i := range cfg.Folders {
+			if cfg.Folders[i].ID == s.id {
+				cfg.Folders = append(cfg.Folders[:i], cfg.Folders[i+1:]...)
+				return
+			}
+		}
+	case "devices":
+		for i := range cfg.Devices {
+			if cfg.Devices[i].DeviceID.String() == s.id {
+				cfg.Devices = append(cfg.Devices[:i], cfg.Devices[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// copyOverlayValue returns a copy of v that doesn't share slices or maps
+// with it, so that changes to the configuration don't affect the overlay.
+func copyOverlayValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMap(v.Type())
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(key, v.MapIndex(key))
+		}
+		return c
+	}
+	return v
+}
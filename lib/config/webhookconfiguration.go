@@ -0,0 +1,73 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+type WebhookConfiguration struct {
+	ID                 string   `xml:"id,attr" json:"id"`
+	Enabled            bool     `xml:"enabled,attr" json:"enabled"`
+	URL                string   `xml:"url" json:"url"`
+	Events             []string `xml:"event" json:"events"`                      // The event types to send, or all but ConfigSaved if empty.
+	Secret             string   `xml:"secret,omitempty" json:"secret"`           // If set, the request body is signed with HMAC-SHA256 using this key.
+	MaxBatchEvents     int      `xml:"maxBatchEvents" json:"maxBatchEvents"`     // The maximum number of events per request.
+	BatchDelayS        int      `xml:"batchDelayS" json:"batchDelayS"`           // Events are collected for this long before being queued for sending.
+	MaxQueuedBatches   int      `xml:"maxQueuedBatches" json:"maxQueuedBatches"` // The oldest batches are dropped when more than this many await delivery.
+	InsecureSkipVerify bool     `xml:"insecureSkipVerify" json:"insecureSkipVerify"`
+	AllowLocalTarget   bool     `xml:"allowLocalTarget" json:"allowLocalTarget"` // Loopback and link-local targets are refused unless set.
+}
+
+func (c WebhookConfiguration) Copy() WebhookConfiguration {
+	c.Events = append([]string(nil), c.Events...)
+	return c
+}
+
+// EventMask returns the mask of the configured event types. Unknown event
+// types are ignored. Without any, it's all events but ConfigSaved, as the
+// configuration is only sent elsewhere when asked for.
+func (c WebhookConfiguration) EventMask() events.EventType {
+	if len(c.Events) == 0 {
+		return events.AllEvents &^ events.ConfigSaved
+	}
+	return eventMask(c.Events)
+}
+
+// eventMask returns the mask for the named event types, or all events if
+// there are none.
+func eventMask(names []string) events.EventType {
+	if len(names) == 0 {
+		return events.AllEvents
+	}
+	var mask events.EventType
+	for _, name := range names {
+		mask |= events.UnmarshalEventType(name)
+	}
+	return mask
+}
+
+func (c *WebhookConfiguration) prepare() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %q: URL must be an http or https URL", c.ID)
+	}
+
+	if c.MaxBatchEvents <= 0 {
+		c.MaxBatchEvents = 100
+	}
+	if c.BatchDelayS <= 0 {
+		c.BatchDelayS = 1
+	}
+	if c.MaxQueuedBatches <= 0 {
+		c.MaxQueuedBatches = 1000
+	}
+	return nil
+}
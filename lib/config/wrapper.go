@@ -7,7 +7,11 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 
@@ -62,6 +66,7 @@ type Wrapper struct {
 	deviceMap map[protocol.DeviceID]DeviceConfiguration
 	folderMap map[string]FolderConfiguration
 	subs      []Committer
+	overlay   *overlay
 	mut       sync.Mutex
 
 	requiresRestart uint32 // an atomic bool
@@ -79,7 +84,8 @@ func Wrap(path string, cfg Configuration) *Wrapper {
 }
 
 // Load loads an existing file on disk and returns a new configuration
-// wrapper.
+// wrapper. The fragments in the OverlayDir next to it and the option
+// overrides from the environment are applied on top, but not saved.
 func Load(path string, myID protocol.DeviceID) (*Wrapper, error) {
 	fd, err := os.Open(path)
 	if err != nil {
@@ -92,7 +98,31 @@ func Load(path string, myID protocol.DeviceID) (*Wrapper, error) {
 		return nil, err
 	}
 
-	return Wrap(path, cfg), nil
+	overlay, err := loadOverlay(filepath.Join(filepath.Dir(path), OverlayDir), os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	overlay.apply(&cfg)
+	if err := cfg.prepare(myID); err != nil {
+		return nil, err
+	}
+
+	w := Wrap(path, cfg)
+	w.overlay = overlay
+	return w, nil
+}
+
+// Locked returns the fields set by the overlay, which can't be changed, by
+// their path in the JSON configuration, e.g. "options.maxSendKbps". Folders
+// and devices only existing in the overlay are locked as a whole, e.g.
+// "folders.default".
+func (w *Wrapper) Locked() []string {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.overlay == nil {
+		return nil
+	}
+	return w.overlay.locked()
 }
 
 func (w *Wrapper) ConfigPath() string {
@@ -139,6 +169,12 @@ func (w *Wrapper) Replace(cfg Configuration) (Waiter, error) {
 func (w *Wrapper) replaceLocked(to Configuration) (Waiter, error) {
 	from := w.cfg
 
+	if w.overlay != nil {
+		if err := w.overlay.check(&from, &to); err != nil {
+			return noopWaiter{}, err
+		}
+		w.overlay.apply(&to)
+	}
 	if err := to.clean(); err != nil {
 		return noopWaiter{}, err
 	}
@@ -279,6 +315,22 @@ func (w *Wrapper) SetFolder(fld FolderConfiguration) (Waiter, error) {
 	return w.replaceLocked(newCfg)
 }
 
+// RemoveFolder removes the folder from the configuration
+func (w *Wrapper) RemoveFolder(id string) (Waiter, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	newCfg := w.cfg.Copy()
+	for i := range newCfg.Folders {
+		if newCfg.Folders[i].ID == id {
+			newCfg.Folders = append(newCfg.Folders[:i], newCfg.Folders[i+1:]...)
+			return w.replaceLocked(newCfg)
+		}
+	}
+
+	return noopWaiter{}, nil
+}
+
 // Options returns the current options configuration object.
 func (w *Wrapper) Options() OptionsConfiguration {
 	w.mut.Lock()
@@ -295,12 +347,56 @@ func (w *Wrapper) SetOptions(opts OptionsConfiguration) (Waiter, error) {
 	return w.replaceLocked(newCfg)
 }
 
+// Defaults returns the current templates for new folders and devices.
+func (w *Wrapper) Defaults() DefaultsConfiguration {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.cfg.Defaults.Copy()
+}
+
+// SetDefaults replaces the current templates for new folders and devices.
+func (w *Wrapper) SetDefaults(defaults DefaultsConfiguration) (Waiter, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	newCfg := w.cfg.Copy()
+	newCfg.Defaults = defaults.Copy()
+	return w.replaceLocked(newCfg)
+}
+
 func (w *Wrapper) LDAP() LDAPConfiguration {
 	w.mut.Lock()
 	defer w.mut.Unlock()
 	return w.cfg.LDAP.Copy()
 }
 
+func (w *Wrapper) OIDC() OIDCConfiguration {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.cfg.OIDC.Copy()
+}
+
+// Webhooks returns the current webhook configurations.
+func (w *Wrapper) Webhooks() []WebhookConfiguration {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	hooks := make([]WebhookConfiguration, len(w.cfg.Webhooks))
+	for i := range w.cfg.Webhooks {
+		hooks[i] = w.cfg.Webhooks[i].Copy()
+	}
+	return hooks
+}
+
+// AuditSinks returns the current audit sink configurations.
+func (w *Wrapper) AuditSinks() []AuditSinkConfiguration {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	sinks := make([]AuditSinkConfiguration, len(w.cfg.AuditSinks))
+	for i := range w.cfg.AuditSinks {
+		sinks[i] = w.cfg.AuditSinks[i].Copy()
+	}
+	return sinks
+}
+
 // GUI returns the current GUI configuration object.
 func (w *Wrapper) GUI() GUIConfiguration {
 	w.mut.Lock()
@@ -365,31 +461,137 @@ func (w *Wrapper) Folder(id string) (FolderConfiguration, bool) {
 }
 
 // Save writes the configuration to disk, and generates a ConfigSaved event.
+// Changes are recorded in the history as made by Syncthing itself.
 func (w *Wrapper) Save() error {
+	return w.SaveBy("")
+}
+
+// SaveBy saves the configuration like Save, recording changes in the
+// history as made by author, e.g. the GUI user.
+func (w *Wrapper) SaveBy(author string) error {
 	w.mut.Lock()
 	defer w.mut.Unlock()
 
+	cfg := w.cfg
+	if w.overlay != nil {
+		cfg = cfg.Copy()
+		w.overlay.restore(&cfg)
+	}
+	buf := new(bytes.Buffer)
+	if err := cfg.WriteXML(buf); err != nil {
+		l.Debugln("WriteXML:", err)
+		return err
+	}
+
+	// Edits of the config file since it was last saved become a revision
+	// of their own, so that the history has what is being replaced.
+	history := w.history()
+	if history.keep > 0 {
+		if info, err := os.Stat(w.path); err == nil {
+			if bs, err := ioutil.ReadFile(w.path); err == nil && len(bs) > 0 {
+				if err := history.record(bs, "", info.ModTime()); err != nil {
+					l.Warnln("Recording config history:", err)
+				}
+			}
+		}
+	}
+
 	fd, err := osutil.CreateAtomic(w.path)
 	if err != nil {
 		l.Debugln("CreateAtomic:", err)
 		return err
 	}
-
-	if err := w.cfg.WriteXML(fd); err != nil {
-		l.Debugln("WriteXML:", err)
+	if _, err := fd.Write(buf.Bytes()); err != nil {
+		l.Debugln("Write:", err)
 		fd.Close()
 		return err
 	}
-
 	if err := fd.Close(); err != nil {
 		l.Debugln("Close:", err)
 		return err
 	}
 
+	if history.keep > 0 {
+		if err := history.record(buf.Bytes(), author, time.Now()); err != nil {
+			l.Warnln("Recording config history:", err)
+		}
+	}
+
 	events.Default.Log(events.ConfigSaved, w.cfg)
 	return nil
 }
 
+func (w *Wrapper) history() configHistory {
+	return configHistory{
+		dir:  filepath.Join(filepath.Dir(w.path), HistoryDir),
+		keep: w.cfg.Options.ConfigHistoryRevisions,
+	}
+}
+
+// History returns the revisions of the configuration that are kept, oldest
+// first.
+func (w *Wrapper) History() ([]Revision, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.history().revisions()
+}
+
+// RevisionDiff returns a unified diff of the config file from one revision
+// to another. A from revision of zero means the one before to, if any.
+func (w *Wrapper) RevisionDiff(from, to int) (string, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	history := w.history()
+	if from == 0 {
+		revs, err := history.revisions()
+		if err != nil {
+			return "", err
+		}
+		for _, rev := range revs {
+			if rev.Revision < to {
+				from = rev.Revision
+			}
+		}
+	}
+
+	toBs, err := history.read(to)
+	if err != nil {
+		return "", err
+	}
+	var fromBs []byte
+	if from != 0 {
+		if fromBs, err = history.read(from); err != nil {
+			return "", err
+		}
+	}
+	return diffLines(fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to), splitLines(fromBs), splitLines(toBs)), nil
+}
+
+// ReadRevision returns the configuration as it was at the given revision,
+// e.g. to Replace the current one with it. It's converted to the current
+// configuration version, the same as when loading the config file. The
+// credentials for the GUI and the fields set by the overlay are as they are
+// now, as rolling back mustn't bring back a password or key that has since
+// been changed, and the overlay can't be changed.
+func (w *Wrapper) ReadRevision(rev int) (Configuration, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	bs, err := w.history().read(rev)
+	if err != nil {
+		return Configuration{}, err
+	}
+	cfg, err := ReadXML(bytes.NewReader(bs), w.cfg.MyID)
+	if err != nil {
+		return Configuration{}, err
+	}
+	keepCredentials(&cfg, w.cfg)
+	if w.overlay != nil {
+		w.overlay.apply(&cfg)
+	}
+	return cfg, nil
+}
+
 func (w *Wrapper) GlobalDiscoveryServers() []string {
 	var servers []string
 	for _, srv := range w.Options().GlobalAnnServers {
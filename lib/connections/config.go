@@ -9,4 +9,9 @@ package connections
 const (
 	tcpPriority   = 10
 	relayPriority = 200
+
+	// Added to the priority of the addresses gossiped by other devices, so
+	// that we try the addresses we know about ourselves first, but still
+	// prefer a direct connection over a relayed one.
+	gossipedPriorityPenalty = 5
 )
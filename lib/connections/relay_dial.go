@@ -27,12 +27,13 @@ type relayDialer struct {
 }
 
 func (d *relayDialer) Dial(id protocol.DeviceID, uri *url.URL) (internalConn, error) {
-	inv, err := client.GetInvitationFromRelay(uri, id, d.tlsCfg.Certificates, 10*time.Second)
+	proxy := deviceProxy(d.cfg, id)
+	inv, err := client.GetInvitationFromRelayProxy(proxy, uri, id, d.tlsCfg.Certificates, 10*time.Second)
 	if err != nil {
 		return internalConn{}, err
 	}
 
-	conn, err := client.JoinSession(inv)
+	conn, err := client.JoinSessionProxy(proxy, inv)
 	if err != nil {
 		return internalConn{}, err
 	}
@@ -61,7 +62,7 @@ func (d *relayDialer) Dial(id protocol.DeviceID, uri *url.URL) (internalConn, er
 		return internalConn{}, err
 	}
 
-	return internalConn{tc, connTypeRelayClient, relayPriority}, nil
+	return internalConn{tc, connTypeRelayClient, relayPriority, dialer.ProxyOf(conn), false}, nil
 }
 
 func (d *relayDialer) RedialFrequency() time.Duration {
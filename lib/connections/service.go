@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/dialer"
 	"github.com/syncthing/syncthing/lib/discover"
 	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/nat"
@@ -27,6 +28,7 @@ import (
 	"github.com/syncthing/syncthing/lib/util"
 
 	// Registers NAT service providers
+	_ "github.com/syncthing/syncthing/lib/pcp"
 	_ "github.com/syncthing/syncthing/lib/pmp"
 	_ "github.com/syncthing/syncthing/lib/upnp"
 
@@ -190,8 +192,15 @@ next:
 			continue
 		}
 
+		// Both sides go by the word of the one that dialed the connection
+		// on whether it's an additional one.
+		ourHello := s.model.GetHello(remoteID)
+		if h, ok := ourHello.(*protocol.Hello); ok && c.additional {
+			h.Additional = true
+		}
+
 		c.SetDeadline(time.Now().Add(20 * time.Second))
-		hello, err := protocol.ExchangeHello(c, s.model.GetHello(remoteID))
+		hello, err := protocol.ExchangeHello(c, ourHello)
 		if err != nil {
 			if protocol.IsVersionMismatch(err) {
 				// The error will be a relatively user friendly description
@@ -224,12 +233,30 @@ next:
 			continue
 		}
 
+		deviceCfg, ok := s.cfg.Device(remoteID)
+		if !ok {
+			l.Infof("Device %s removed from config during connection attempt at %s", remoteID, c)
+			c.Close()
+			continue
+		}
+
 		// If we have a relay connection, and the new incoming connection is
 		// not a relay connection, we should drop that, and prefer this one.
 		ct, connected := s.model.Connection(remoteID)
 
+		// An additional connection is added next to the existing one, if it's
+		// as good and the device is to have more than one.
+		stream := c.additional || hello.Additional
+
 		// Lower priority is better, just like nice etc.
-		if connected && ct.Priority() > c.priority {
+		if stream {
+			if !connected || ct.Priority() != c.priority || s.model.NumConnections(remoteID) >= numConnections(deviceCfg) {
+				l.Infof("Not adding connection to %s (existing: %v new: %s)", remoteID, ct, c)
+				c.Close()
+				continue
+			}
+			l.Debugf("Adding connection to %s (existing: %s new: %s)", remoteID, ct, c)
+		} else if connected && ct.Priority() > c.priority {
 			l.Debugf("Switching connections %s (existing: %s new: %s)", remoteID, ct, c)
 		} else if connected {
 			// We should not already be connected to the other party. TODO: This
@@ -243,13 +270,6 @@ next:
 			continue
 		}
 
-		deviceCfg, ok := s.cfg.Device(remoteID)
-		if !ok {
-			l.Infof("Device %s removed from config during connection attempt at %s", remoteID, c)
-			c.Close()
-			continue
-		}
-
 		// Verify the name on the certificate. By default we set it to
 		// "syncthing" when generating, but the user may have replaced
 		// the certificate and used another name.
@@ -272,6 +292,17 @@ next:
 		isLAN := s.isLAN(c.RemoteAddr())
 		rd, wr := s.limiter.getLimiters(remoteID, c, isLAN)
 
+		if stream {
+			sm := &streamModel{Model: s.model}
+			protoConn := protocol.NewConnection(remoteID, rd, wr, sm, c.String(), deviceCfg.Compression)
+			sm.conn = completeConn{c, protoConn}
+
+			l.Infof("Established additional secure connection to %s at %s (%s)", remoteID, c, tlsCipherSuiteNames[c.ConnectionState().CipherSuite])
+
+			s.model.AddStream(sm.conn)
+			continue next
+		}
+
 		protoConn := protocol.NewConnection(remoteID, rd, wr, s.model, c.String(), deviceCfg.Compression)
 		modelConn := completeConn{c, protoConn}
 
@@ -322,7 +353,19 @@ func (s *Service) connect() {
 
 			ct, connected := s.model.Connection(deviceID)
 
-			if connected && ct.Priority() == bestDialerPrio {
+			// Devices that are to have more than one connection get more of
+			// the same kind as the one they have, once they've said they
+			// want them too.
+			toDial := 1
+			if connected {
+				toDial = numConnections(deviceCfg)
+				if remote := s.model.RemoteNumConnections(deviceID); remote < toDial {
+					toDial = remote
+				}
+				toDial -= s.model.NumConnections(deviceID)
+			}
+
+			if connected && ct.Priority() == bestDialerPrio && toDial <= 0 {
 				// Things are already as good as they can get.
 				continue
 			}
@@ -342,11 +385,26 @@ func (s *Service) connect() {
 
 			addrs = util.UniqueStrings(addrs)
 
-			l.Debugln("Reconnect loop for", deviceID, addrs)
+			// The addresses our other devices have seen the device at come
+			// after the ones we know of ourselves.
+			var gossiped []string
+			if cfg.Options.AddressGossipEnabled {
+				known := make(map[string]bool, len(addrs))
+				for _, addr := range addrs {
+					known[addr] = true
+				}
+				for _, addr := range s.model.GossipedAddresses(deviceID) {
+					if !known[addr] {
+						gossiped = append(gossiped, addr)
+					}
+				}
+			}
+
+			l.Debugln("Reconnect loop for", deviceID, addrs, gossiped)
 
 			dialTargets := make([]dialTarget, 0)
 
-			for _, addr := range addrs {
+			for i, addr := range append(addrs, gossiped...) {
 				// Use a special key that is more than just the address, as you might have two devices connected to the same relay
 				nextDialKey := deviceID.String() + "/" + addr
 				seen = append(seen, nextDialKey)
@@ -389,7 +447,7 @@ func (s *Service) connect() {
 
 				priority := dialerFactory.Priority()
 
-				if connected && priority >= ct.Priority() {
+				if connected && (priority > ct.Priority() || priority == ct.Priority() && toDial <= 0) {
 					l.Debugf("Not dialing using %s as priority is less than current connection (%d >= %d)", dialerFactory, dialerFactory.Priority(), ct.Priority())
 					continue
 				}
@@ -405,6 +463,9 @@ func (s *Service) connect() {
 				case s.isLANHost(uri.Host):
 					priority -= 1
 				}
+				if i >= len(addrs) {
+					priority += gossipedPriorityPenalty
+				}
 
 				dialTargets = append(dialTargets, dialTarget{
 					dialer:   dialer,
@@ -414,8 +475,22 @@ func (s *Service) connect() {
 				})
 			}
 
-			conn, ok := dialParallel(deviceCfg.DeviceID, dialTargets)
-			if ok {
+			// Being connected with nothing missing, we're after a better
+			// connection. It replaces the existing ones, and whatever else
+			// it needs is dialed in the next rounds.
+			if toDial < 1 {
+				toDial = 1
+			}
+			for i := 0; i < toDial; i++ {
+				conn, ok := dialParallel(deviceCfg.DeviceID, dialTargets)
+				if !ok {
+					break
+				}
+				if !connected || conn.priority != ct.Priority() {
+					s.conns <- conn
+					break
+				}
+				conn.additional = true
 				s.conns <- conn
 			}
 		}
@@ -433,6 +508,15 @@ func (s *Service) connect() {
 	}
 }
 
+// numConnections returns the number of connections we want to have to the
+// device, which is one unless configured otherwise.
+func numConnections(deviceCfg config.DeviceConfiguration) int {
+	if deviceCfg.NumConnections < 1 {
+		return 1
+	}
+	return deviceCfg.NumConnections
+}
+
 func (s *Service) isLANHost(host string) bool {
 	// Probably we are called with an ip:port combo which we can resolve as
 	// a TCP address.
@@ -509,10 +593,25 @@ func (s *Service) logListenAddressesChangedEvent(l genericListener) {
 }
 
 func (s *Service) VerifyConfiguration(from, to config.Configuration) error {
+	if to.Options.ProxyURL == dialer.NoProxy {
+		return errors.New("proxy: direct is only for devices")
+	}
+	if err := dialer.ValidateProxy(to.Options.ProxyURL); err != nil {
+		return err
+	}
+	for _, dev := range to.Devices {
+		if err := dialer.ValidateProxy(dev.ProxyURL); err != nil {
+			return fmt.Errorf("device %s: %v", dev.DeviceID, err)
+		}
+	}
 	return nil
 }
 
 func (s *Service) CommitConfiguration(from, to config.Configuration) bool {
+	if err := dialer.SetProxy(to.Options.ProxyURL); err != nil {
+		l.Warnln("Setting proxy:", err)
+	}
+
 	newDevices := make(map[protocol.DeviceID]bool, len(to.Devices))
 	for _, dev := range to.Devices {
 		newDevices[dev.DeviceID] = true
@@ -716,6 +815,15 @@ func warningFor(dev protocol.DeviceID, msg string) {
 	}
 }
 
+// deviceProxy returns the proxy to dial the device through, or the empty
+// string for the global one.
+func deviceProxy(cfg *config.Wrapper, id protocol.DeviceID) string {
+	if dev, ok := cfg.Device(id); ok {
+		return dev.ProxyURL
+	}
+	return ""
+}
+
 func tlsTimedHandshake(tc *tls.Conn) error {
 	tc.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
 	defer tc.SetDeadline(time.Time{})
@@ -8,9 +8,11 @@ package connections
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/syncthing/syncthing/lib/config"
@@ -26,9 +28,30 @@ type Connection interface {
 	Transport() string
 	RemoteAddr() net.Addr
 	Priority() int
+	Proxy() string
 	String() string
 }
 
+// ObservedAddress returns the address the remote device could be reached
+// at by others, as far as we can tell from the connection. That's where we
+// connected to for outgoing TCP connections. For incoming ones it's the
+// address they came from, with the default port as the one they came from
+// won't take connections. Relayed connections tell us nothing.
+func ObservedAddress(c Connection) (string, bool) {
+	addr, ok := c.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return "", false
+	}
+	switch c.Type() {
+	case connTypeTCPClient.String():
+		return "tcp://" + addr.String(), true
+	case connTypeTCPServer.String():
+		return "tcp://" + net.JoinHostPort(addr.IP.String(), strconv.Itoa(config.DefaultTCPPort)), true
+	default:
+		return "", false
+	}
+}
+
 // completeConn is the aggregation of an internalConn and the
 // protocol.Connection running on top of it. It implements the Connection
 // interface.
@@ -48,6 +71,11 @@ type internalConn struct {
 	*tls.Conn
 	connType connType
 	priority int
+	proxy    string // the proxy we dialed through, if any
+
+	// We dialed it as an additional connection to a device we're already
+	// connected to.
+	additional bool
 }
 
 type connType int
@@ -101,6 +129,10 @@ func (c internalConn) Priority() int {
 	return c.priority
 }
 
+func (c internalConn) Proxy() string {
+	return c.proxy
+}
+
 func (c internalConn) Transport() string {
 	transport := c.connType.Transport()
 	host, _, err := net.SplitHostPort(c.LocalAddr().String())
@@ -162,9 +194,39 @@ type genericListener interface {
 type Model interface {
 	protocol.Model
 	AddConnection(conn Connection, hello protocol.HelloResult)
+	AddStream(conn Connection)
+	StreamClosed(conn Connection, err error)
 	Connection(remoteID protocol.DeviceID) (Connection, bool)
+	NumConnections(remoteID protocol.DeviceID) int
+	RemoteNumConnections(remoteID protocol.DeviceID) int
 	OnHello(protocol.DeviceID, net.Addr, protocol.HelloResult) error
 	GetHello(protocol.DeviceID) protocol.HelloIntf
+	GossipedAddresses(protocol.DeviceID) []string
+}
+
+// streamModel is the model for the additional connections to a device. They
+// carry requests and the responses to them, while the cluster config and
+// indexes go over the main connection. The cluster config they start with
+// is ignored, and indexes close them. They get closed on their own.
+type streamModel struct {
+	Model
+	conn Connection
+}
+
+var errIndexOnStream = errors.New("protocol error: index on additional connection")
+
+func (m *streamModel) ClusterConfig(protocol.DeviceID, protocol.ClusterConfig) {}
+
+func (m *streamModel) Index(protocol.DeviceID, string, []protocol.FileInfo) {
+	go m.conn.Close(errIndexOnStream)
+}
+
+func (m *streamModel) IndexUpdate(protocol.DeviceID, string, []protocol.FileInfo) {
+	go m.conn.Close(errIndexOnStream)
+}
+
+func (m *streamModel) Closed(_ protocol.Connection, err error) {
+	m.Model.StreamClosed(m.conn, err)
 }
 
 // serviceFunc wraps a function to create a suture.Service without stop
@@ -31,7 +31,7 @@ type tcpDialer struct {
 func (d *tcpDialer) Dial(id protocol.DeviceID, uri *url.URL) (internalConn, error) {
 	uri = fixupPort(uri, config.DefaultTCPPort)
 
-	conn, err := dialer.DialTimeout(uri.Scheme, uri.Host, 10*time.Second)
+	conn, err := dialer.DialTimeoutProxy(deviceProxy(d.cfg, id), uri.Scheme, uri.Host, 10*time.Second)
 	if err != nil {
 		return internalConn{}, err
 	}
@@ -53,7 +53,7 @@ func (d *tcpDialer) Dial(id protocol.DeviceID, uri *url.URL) (internalConn, erro
 		return internalConn{}, err
 	}
 
-	return internalConn{tc, connTypeTCPClient, tcpPriority}, nil
+	return internalConn{tc, connTypeTCPClient, tcpPriority, dialer.ProxyOf(conn), false}, nil
 }
 
 func (d *tcpDialer) RedialFrequency() time.Duration {
@@ -133,7 +133,7 @@ func (t *tcpListener) Serve() {
 			continue
 		}
 
-		t.conns <- internalConn{tc, connTypeTCPServer, tcpPriority}
+		t.conns <- internalConn{tc, connTypeTCPServer, tcpPriority, "", false}
 	}
 }
 
@@ -151,14 +151,22 @@ func (t *tcpListener) WANAddresses() []*url.URL {
 	if t.mapping != nil {
 		addrs := t.mapping.ExternalAddresses()
 		for _, addr := range addrs {
+			// Pinholes for IPv6 are no use to an IPv4 only listener, and
+			// the other way around.
+			isIPv4 := addr.IP == nil || addr.IP.To4() != nil
+			if t.uri.Scheme == "tcp4" && !isIPv4 || t.uri.Scheme == "tcp6" && isIPv4 {
+				continue
+			}
+
 			uri := *t.uri
 			// Does net.JoinHostPort internally
 			uri.Host = addr.String()
 			uris = append(uris, &uri)
 
-			// For every address with a specified IP, add one without an IP,
-			// just in case the specified IP is still internal (router behind DMZ).
-			if len(addr.IP) != 0 && !addr.IP.IsUnspecified() {
+			// For every IPv4 address with a specified IP, add one without an
+			// IP, just in case the specified IP is still internal (router
+			// behind DMZ). IPv6 addresses aren't translated.
+			if isIPv4 && len(addr.IP) != 0 && !addr.IP.IsUnspecified() {
 				uri = *t.uri
 				addr.IP = nil
 				uri.Host = addr.String()
@@ -0,0 +1,238 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package backend implements the key-value stores that the database is
+// built upon.
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Type is the name of a backend implementation.
+type Type string
+
+const (
+	TypeLevelDB Type = "leveldb"
+	TypeBolt    Type = "bolt"
+)
+
+// DefaultType is the backend used for new databases.
+const DefaultType = TypeLevelDB
+
+// Types lists all available backend implementations.
+var Types = []Type{TypeLevelDB, TypeBolt}
+
+var ErrNotFound = errors.New("key not found")
+
+// IsNotFound returns true if the error is a "key not found" error.
+func IsNotFound(err error) bool {
+	return err == ErrNotFound
+}
+
+// The Reader interface contains the read operations available on the
+// backend itself and on snapshots.
+type Reader interface {
+	// Get returns the value for the given key, or ErrNotFound.
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	// NewPrefixIterator returns an iterator over all keys with the given
+	// prefix. A nil prefix iterates over all keys.
+	NewPrefixIterator(prefix []byte) Iterator
+	// NewRangeIterator returns an iterator over the keys from first
+	// (inclusive) to last (exclusive).
+	NewRangeIterator(first, last []byte) Iterator
+}
+
+// The Writer interface contains the write operations available on the
+// backend itself.
+type Writer interface {
+	Put(key, val []byte) error
+	Delete(key []byte) error
+}
+
+// A Snapshot is a consistent read only view of the database. It must be
+// released when done. With the bolt backend a snapshot is only consistent
+// until the next write, which it then sees.
+type Snapshot interface {
+	Reader
+	Release()
+}
+
+// A Batch collects writes which are then applied together by Write.
+type Batch interface {
+	Put(key, val []byte)
+	Delete(key []byte)
+	// Size returns the approximate amount of data in the batch, in bytes.
+	Size() int
+	Reset()
+	Write() error
+}
+
+// An Iterator iterates over key-value pairs in key order. Key and Value
+// are only valid until the next call to Next. An iterator must be
+// released when done.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// The Backend interface is the lowest level of the database: an ordered
+// key-value store.
+type Backend interface {
+	Reader
+	Writer
+	NewSnapshot() (Snapshot, error)
+	NewBatch() Batch
+	Close() error
+}
+
+// ParseType returns the backend type with the given name.
+func ParseType(name string) (Type, error) {
+	for _, typ := range Types {
+		if string(typ) == name {
+			return typ, nil
+		}
+	}
+	return "", fmt.Errorf("unknown database backend %q", name)
+}
+
+// Location returns the path of the database of the given type, based on
+// the database location of the default backend.
+func Location(typ Type, location string) string {
+	switch typ {
+	case TypeBolt:
+		return location + ".bolt"
+	default:
+		return location
+	}
+}
+
+// Detect returns the type of the existing database based at location, if
+// there is one. If there are several, the default type wins.
+func Detect(location string) (Type, bool) {
+	for _, typ := range Types {
+		if _, err := os.Stat(Location(typ, location)); err == nil {
+			return typ, true
+		}
+	}
+	return "", false
+}
+
+// Open opens the database of the given type based at location, creating it
+// if it doesn't exist.
+func Open(typ Type, location string) (Backend, error) {
+	switch typ {
+	case TypeLevelDB:
+		return OpenLevelDB(Location(typ, location))
+	case TypeBolt:
+		return OpenBolt(Location(typ, location))
+	default:
+		return nil, fmt.Errorf("unknown database backend %q", typ)
+	}
+}
+
+// OpenRO opens the existing database of the given type based at location,
+// read only.
+func OpenRO(typ Type, location string) (Backend, error) {
+	switch typ {
+	case TypeLevelDB:
+		return OpenLevelDBRO(Location(typ, location))
+	case TypeBolt:
+		return OpenBoltRO(Location(typ, location))
+	default:
+		return nil, fmt.Errorf("unknown database backend %q", typ)
+	}
+}
+
+// OpenMemory returns a new, empty database of the given type that does not
+// persist beyond its lifetime.
+func OpenMemory(typ Type) (Backend, error) {
+	switch typ {
+	case TypeLevelDB:
+		return OpenLevelDBMemory(), nil
+	case TypeBolt:
+		return OpenBoltTemporary()
+	default:
+		return nil, fmt.Errorf("unknown database backend %q", typ)
+	}
+}
+
+// Remove removes the database of the given type based at location.
+func Remove(typ Type, location string) error {
+	return os.RemoveAll(Location(typ, location))
+}
+
+// Migrate copies the database based at location from one backend type to
+// another and removes the source database when done. The destination is
+// written under a temporary name first, such that an interrupted migration
+// leaves the source intact.
+func Migrate(from, to Type, location string) error {
+	if from == to {
+		return nil
+	}
+
+	src, err := Open(from, location)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpLocation := location + ".migrating"
+	tmpPath := Location(to, tmpLocation)
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return err
+	}
+	dst, err := Open(to, tmpLocation)
+	if err != nil {
+		return err
+	}
+
+	if err := Copy(dst, src); err != nil {
+		dst.Close()
+		os.RemoveAll(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.RemoveAll(tmpPath)
+		return err
+	}
+	src.Close()
+
+	if err := os.Rename(tmpPath, Location(to, location)); err != nil {
+		return err
+	}
+	return Remove(from, location)
+}
+
+// copyBatchSize is the amount of data copied at once by Copy.
+const copyBatchSize = 4 << 20
+
+// Copy writes all key-value pairs in src to dst.
+func Copy(dst, src Backend) error {
+	it := src.NewPrefixIterator(nil)
+	defer it.Release()
+
+	batch := dst.NewBatch()
+	for it.Next() {
+		batch.Put(it.Key(), it.Value())
+		if batch.Size() > copyBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
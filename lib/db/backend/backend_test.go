@@ -0,0 +1,218 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package backend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func openTestBackends(t *testing.T) map[Type]Backend {
+	t.Helper()
+	bs := make(map[Type]Backend)
+	for _, typ := range Types {
+		b, err := OpenMemory(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs[typ] = b
+	}
+	return bs
+}
+
+func iterKeys(it Iterator) []string {
+	defer it.Release()
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key())+"="+string(it.Value()))
+	}
+	return keys
+}
+
+func TestSnapshotIsolation(t *testing.T) {
+	for typ, b := range openTestBackends(t) {
+		t.Run(string(typ), func(t *testing.T) {
+			defer b.Close()
+			if typ == TypeBolt {
+				t.Skip("bolt snapshots see later writes")
+			}
+
+			b.Put([]byte("a1"), []byte("1"))
+			b.Put([]byte("a2"), []byte("2"))
+			b.Put([]byte("b1"), []byte("3"))
+
+			snap, err := b.NewSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer snap.Release()
+
+			batch := b.NewBatch()
+			batch.Put([]byte("a0"), []byte("new"))
+			batch.Put([]byte("a1"), []byte("changed"))
+			batch.Delete([]byte("a2"))
+			if err := batch.Write(); err != nil {
+				t.Fatal(err)
+			}
+
+			if val, err := snap.Get([]byte("a1")); err != nil || string(val) != "1" {
+				t.Errorf("snapshot Get a1 = %q, %v", val, err)
+			}
+			if _, err := snap.Get([]byte("a0")); !IsNotFound(err) {
+				t.Errorf("snapshot Get a0 = %v, expected not found", err)
+			}
+			if ok, _ := snap.Has([]byte("a2")); !ok {
+				t.Error("snapshot lost a2")
+			}
+
+			if keys := fmt.Sprint(iterKeys(snap.NewPrefixIterator([]byte("a")))); keys != "[a1=1 a2=2]" {
+				t.Error("snapshot iteration returned", keys)
+			}
+			if keys := fmt.Sprint(iterKeys(b.NewPrefixIterator([]byte("a")))); keys != "[a0=new a1=changed]" {
+				t.Error("iteration returned", keys)
+			}
+			if keys := fmt.Sprint(iterKeys(snap.NewRangeIterator([]byte("a2"), []byte("b2")))); keys != "[a2=2 b1=3]" {
+				t.Error("snapshot range iteration returned", keys)
+			}
+		})
+	}
+}
+
+func TestWriteWhileIterating(t *testing.T) {
+	for typ, b := range openTestBackends(t) {
+		t.Run(string(typ), func(t *testing.T) {
+			defer b.Close()
+
+			const n = 1000
+			batch := b.NewBatch()
+			for i := 0; i < n; i++ {
+				batch.Put([]byte(fmt.Sprintf("k%05d", i)), []byte("v"))
+			}
+			if err := batch.Write(); err != nil {
+				t.Fatal(err)
+			}
+
+			snap, err := b.NewSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer snap.Release()
+
+			// Delete everything while iterating the snapshot.
+			it := snap.NewPrefixIterator(nil)
+			defer it.Release()
+			seen := 0
+			batch.Reset()
+			for it.Next() {
+				batch.Delete(it.Key())
+				seen++
+				if seen%100 == 0 {
+					if err := batch.Write(); err != nil {
+						t.Fatal(err)
+					}
+					batch.Reset()
+				}
+			}
+			if err := b.Delete([]byte("k00000")); err != nil {
+				t.Fatal(err)
+			}
+			if seen != n {
+				t.Errorf("Iterated over %d keys, expected %d", seen, n)
+			}
+			if keys := iterKeys(b.NewPrefixIterator(nil)); len(keys) != 0 {
+				t.Errorf("%d keys left after deleting all", len(keys))
+			}
+		})
+	}
+}
+
+func TestGrowWhileIterating(t *testing.T) {
+	for typ, b := range openTestBackends(t) {
+		t.Run(string(typ), func(t *testing.T) {
+			defer b.Close()
+
+			for _, key := range []string{"k1", "k2", "k3"} {
+				if err := b.Put([]byte(key), []byte("v")); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The database grows to many times its initial mapping while
+			// the same goroutine has an iterator open.
+			done := make(chan error, 1)
+			go func() {
+				it := b.NewPrefixIterator([]byte("k"))
+				defer it.Release()
+				if !it.Next() {
+					done <- fmt.Errorf("no keys")
+					return
+				}
+
+				val := make([]byte, 64<<10)
+				batch := b.NewBatch()
+				for i := 0; i < 256; i++ {
+					key := []byte(fmt.Sprintf("big%05d", i))
+					if i%2 == 0 {
+						if err := b.Put(key, val); err != nil {
+							done <- err
+							return
+						}
+						continue
+					}
+					batch.Reset()
+					batch.Put(key, val)
+					if err := batch.Write(); err != nil {
+						done <- err
+						return
+					}
+				}
+
+				seen := 1
+				for it.Next() {
+					seen++
+				}
+				if seen != 3 {
+					done <- fmt.Errorf("iterated over %d keys, expected 3", seen)
+					return
+				}
+				done <- it.Error()
+			}()
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(time.Minute):
+				t.Fatal("Writing while iterating didn't complete")
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := OpenLevelDBMemory()
+	defer src.Close()
+	dst, err := OpenBoltTemporary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	for i := 0; i < 1000; i++ {
+		src.Put([]byte(fmt.Sprintf("k%04d", i)), []byte(fmt.Sprint(i)))
+	}
+	if err := Copy(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	srcKeys := fmt.Sprint(iterKeys(src.NewPrefixIterator(nil)))
+	dstKeys := fmt.Sprint(iterKeys(dst.NewPrefixIterator(nil)))
+	if srcKeys != dstKeys {
+		t.Error("Copy does not match the source")
+	}
+}
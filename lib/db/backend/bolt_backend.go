@@ -0,0 +1,481 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package backend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltFileMode    = 0600
+	boltLockTimeout = 5 * time.Second
+)
+
+// All keys live in a single bucket, such that the key space is ordered the
+// same way as in the other backends.
+var boltBucket = []byte("syncthing")
+
+// boltBackend implements Backend on top of a bolt file.
+//
+// Snapshots, and the iterators on the backend itself, are bolt read
+// transactions. A write transaction that has to grow the memory mapping of
+// the file waits for all read transactions to finish, while the database
+// layer keeps snapshots and iterators open as it writes, from the same
+// goroutine. Writes therefore hold writeMut exclusively and roll back the
+// read transactions of all open snapshots first. A snapshot begins a new
+// read transaction when next used, under writeMut held shared, and so sees
+// the writes made in between.
+type boltBackend struct {
+	bdb      *bolt.DB
+	remove   string // file to remove on close, for temporary databases
+	writeMut sync.RWMutex
+	snapMut  sync.Mutex
+	snaps    map[*boltSnapshot]struct{}
+}
+
+func newBoltBackend(bdb *bolt.DB) *boltBackend {
+	return &boltBackend{
+		bdb:   bdb,
+		snaps: make(map[*boltSnapshot]struct{}),
+	}
+}
+
+// OpenBolt opens or creates the bolt database at the given location.
+func OpenBolt(location string) (Backend, error) {
+	bdb, err := bolt.Open(location, boltFileMode, &bolt.Options{
+		Timeout:        boltLockTimeout,
+		NoFreelistSync: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = bdb.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	return newBoltBackend(bdb), nil
+}
+
+// OpenBoltRO opens the existing bolt database at the given location, read
+// only.
+func OpenBoltRO(location string) (Backend, error) {
+	bdb, err := bolt.Open(location, boltFileMode, &bolt.Options{
+		Timeout:  boltLockTimeout,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newBoltBackend(bdb), nil
+}
+
+// OpenBoltTemporary returns a new bolt database in a temporary file, which
+// is removed when the database is closed.
+func OpenBoltTemporary() (Backend, error) {
+	fd, err := ioutil.TempFile("", "syncthing-bolt-")
+	if err != nil {
+		return nil, err
+	}
+	location := fd.Name()
+	fd.Close()
+	os.Remove(location)
+
+	b, err := OpenBolt(location)
+	if err != nil {
+		return nil, err
+	}
+	b.(*boltBackend).remove = location
+	return b, nil
+}
+
+func (b *boltBackend) Get(key []byte) ([]byte, error) {
+	var val []byte
+	found := false
+	err := b.bdb.View(func(tx *bolt.Tx) error {
+		val, found = boltGet(tx.Bucket(boltBucket), key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return val, nil
+}
+
+func (b *boltBackend) Has(key []byte) (bool, error) {
+	found := false
+	err := b.bdb.View(func(tx *bolt.Tx) error {
+		_, found = boltGet(tx.Bucket(boltBucket), key)
+		return nil
+	})
+	return found, err
+}
+
+// Iterators on the backend itself are iterators on a snapshot of their
+// own.
+
+func (b *boltBackend) NewPrefixIterator(prefix []byte) Iterator {
+	s, err := b.newSnapshot()
+	if err != nil {
+		return &boltIterator{err: err}
+	}
+	it := s.NewPrefixIterator(prefix).(*boltIterator)
+	it.ownsSnap = true
+	return it
+}
+
+func (b *boltBackend) NewRangeIterator(first, last []byte) Iterator {
+	s, err := b.newSnapshot()
+	if err != nil {
+		return &boltIterator{err: err}
+	}
+	it := s.NewRangeIterator(first, last).(*boltIterator)
+	it.ownsSnap = true
+	return it
+}
+
+func (b *boltBackend) Put(key, val []byte) error {
+	return b.update(func(bkt *bolt.Bucket) error {
+		return bkt.Put(key, val)
+	})
+}
+
+func (b *boltBackend) Delete(key []byte) error {
+	return b.update(func(bkt *bolt.Bucket) error {
+		return bkt.Delete(key)
+	})
+}
+
+// update runs fn in a write transaction, after rolling back the read
+// transactions of all snapshots such that growing the file doesn't wait for
+// them.
+func (b *boltBackend) update(fn func(bkt *bolt.Bucket) error) error {
+	b.writeMut.Lock()
+	defer b.writeMut.Unlock()
+
+	b.snapMut.Lock()
+	for s := range b.snaps {
+		s.rollback()
+	}
+	b.snapMut.Unlock()
+
+	return b.bdb.Update(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket(boltBucket))
+	})
+}
+
+func (b *boltBackend) NewSnapshot() (Snapshot, error) {
+	return b.newSnapshot()
+}
+
+func (b *boltBackend) newSnapshot() (*boltSnapshot, error) {
+	b.writeMut.RLock()
+	defer b.writeMut.RUnlock()
+
+	s := &boltSnapshot{b: b}
+	if err := s.begin(); err != nil {
+		return nil, err
+	}
+	b.snapMut.Lock()
+	b.snaps[s] = struct{}{}
+	b.snapMut.Unlock()
+	return s, nil
+}
+
+func (b *boltBackend) NewBatch() Batch {
+	return &boltBatch{b: b}
+}
+
+func (b *boltBackend) Close() error {
+	err := b.bdb.Close()
+	if b.remove != "" {
+		os.Remove(b.remove)
+	}
+	return err
+}
+
+// boltGet returns a copy of the value for the given key, as the value
+// returned by bolt is only valid during the transaction and must not be
+// modified.
+func boltGet(bkt *bolt.Bucket, key []byte) ([]byte, bool) {
+	if bkt == nil {
+		return nil, false
+	}
+	v := bkt.Get(key)
+	if v == nil {
+		return nil, false
+	}
+	return append([]byte{}, v...), true
+}
+
+// A boltSnapshot is a read transaction, which is rolled back by writes and
+// begun again when next used. Read transactions can't be used concurrently,
+// hence the lock.
+type boltSnapshot struct {
+	b        *boltBackend
+	mut      sync.Mutex
+	tx       *bolt.Tx
+	bkt      *bolt.Bucket
+	gen      int // counts the read transactions begun
+	released bool
+}
+
+// begin begins a read transaction unless there is one already. It must be
+// called with writeMut held shared and mut held.
+func (s *boltSnapshot) begin() error {
+	if s.tx != nil || s.released {
+		return nil
+	}
+	tx, err := s.b.bdb.Begin(false)
+	if err != nil {
+		return err
+	}
+	s.tx, s.bkt = tx, tx.Bucket(boltBucket)
+	s.gen++
+	return nil
+}
+
+func (s *boltSnapshot) rollback() {
+	s.mut.Lock()
+	if s.tx != nil {
+		s.tx.Rollback()
+		s.tx, s.bkt = nil, nil
+	}
+	s.mut.Unlock()
+}
+
+func (s *boltSnapshot) get(key []byte) ([]byte, bool, error) {
+	s.b.writeMut.RLock()
+	defer s.b.writeMut.RUnlock()
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if err := s.begin(); err != nil {
+		return nil, false, err
+	}
+	val, found := boltGet(s.bkt, key)
+	return val, found, nil
+}
+
+func (s *boltSnapshot) Get(key []byte) ([]byte, error) {
+	val, found, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return val, nil
+}
+
+func (s *boltSnapshot) Has(key []byte) (bool, error) {
+	_, found, err := s.get(key)
+	return found, err
+}
+
+func (s *boltSnapshot) NewPrefixIterator(prefix []byte) Iterator {
+	return &boltIterator{
+		snap:   s,
+		prefix: prefix,
+		first:  prefix,
+	}
+}
+
+func (s *boltSnapshot) NewRangeIterator(first, last []byte) Iterator {
+	return &boltIterator{
+		snap:  s,
+		first: first,
+		last:  last,
+	}
+}
+
+func (s *boltSnapshot) Release() {
+	s.rollback()
+	s.mut.Lock()
+	s.released = true
+	s.mut.Unlock()
+
+	s.b.snapMut.Lock()
+	delete(s.b.snaps, s)
+	s.b.snapMut.Unlock()
+}
+
+type boltBatchOp struct {
+	key    []byte
+	val    []byte
+	delete bool
+}
+
+type boltBatch struct {
+	b    *boltBackend
+	ops  []boltBatchOp
+	size int
+}
+
+func (b *boltBatch) Put(key, val []byte) {
+	b.ops = append(b.ops, boltBatchOp{
+		key: append([]byte{}, key...),
+		val: append([]byte{}, val...),
+	})
+	b.size += len(key) + len(val)
+}
+
+func (b *boltBatch) Delete(key []byte) {
+	b.ops = append(b.ops, boltBatchOp{
+		key:    append([]byte{}, key...),
+		delete: true,
+	})
+	b.size += len(key)
+}
+
+func (b *boltBatch) Size() int {
+	return b.size
+}
+
+func (b *boltBatch) Reset() {
+	b.ops = b.ops[:0]
+	b.size = 0
+}
+
+// Write applies all operations in the batch in a single transaction.
+func (b *boltBatch) Write() error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	return b.b.update(func(bkt *bolt.Bucket) error {
+		for _, op := range b.ops {
+			var err error
+			if op.delete {
+				err = bkt.Delete(op.key)
+			} else {
+				err = bkt.Put(op.key, op.val)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type boltIterator struct {
+	snap     *boltSnapshot
+	ownsSnap bool   // release the snapshot with the iterator
+	prefix   []byte // only keys with this prefix, if set
+	first    []byte // where to start, if set
+	last     []byte // only keys before this one, if set
+
+	cursor *bolt.Cursor
+	gen    int // the read transaction of the cursor
+	done   bool
+	key    []byte
+	val    []byte
+	err    error
+}
+
+func (it *boltIterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	it.snap.b.writeMut.RLock()
+	defer it.snap.b.writeMut.RUnlock()
+	it.snap.mut.Lock()
+	defer it.snap.mut.Unlock()
+
+	if err := it.snap.begin(); err != nil {
+		it.err = err
+		return false
+	}
+	if it.snap.bkt == nil {
+		// Released, or an empty read only database.
+		it.done = true
+		return false
+	}
+
+	var k, v []byte
+	switch {
+	case it.cursor != nil && it.gen == it.snap.gen:
+		k, v = it.cursor.Next()
+	case it.key != nil:
+		// The read transaction was rolled back by a write, continue after
+		// the current key in the new one.
+		it.cursor, it.gen = it.snap.bkt.Cursor(), it.snap.gen
+		k, v = it.cursor.Seek(it.key)
+		if bytes.Equal(k, it.key) {
+			k, v = it.cursor.Next()
+		}
+	default:
+		it.cursor, it.gen = it.snap.bkt.Cursor(), it.snap.gen
+		if len(it.first) == 0 {
+			k, v = it.cursor.First()
+		} else {
+			k, v = it.cursor.Seek(it.first)
+		}
+	}
+	if k == nil || !it.inRange(k) {
+		it.done = true
+		it.key, it.val = nil, nil
+		return false
+	}
+
+	// The memory returned by bolt is read only and goes away with the
+	// transaction.
+	it.key = append([]byte{}, k...)
+	it.val = append([]byte{}, v...)
+	return true
+}
+
+func (it *boltIterator) inRange(key []byte) bool {
+	if it.prefix != nil && !bytes.HasPrefix(key, it.prefix) {
+		return false
+	}
+	if it.last != nil && bytes.Compare(key, it.last) >= 0 {
+		return false
+	}
+	return true
+}
+
+func (it *boltIterator) Key() []byte {
+	if it.done {
+		return nil
+	}
+	return it.key
+}
+
+func (it *boltIterator) Value() []byte {
+	if it.done {
+		return nil
+	}
+	return it.val
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Release() {
+	it.done = true
+	it.key, it.val = nil, nil
+	it.cursor = nil
+	if it.ownsSnap {
+		it.snap.Release()
+		it.ownsSnap = false
+	}
+}
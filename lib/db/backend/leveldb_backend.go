@@ -0,0 +1,192 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package backend
+
+import (
+	"os"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	dbMaxOpenFiles = 100
+	dbWriteBuffer  = 16 << 20
+)
+
+// leveldbBackend implements Backend on top of goleveldb.
+type leveldbBackend struct {
+	ldb *leveldb.DB
+}
+
+// OpenLevelDB attempts to open the database at the given location, and runs
+// recovery on it if opening fails. Worst case, if recovery is not possible,
+// the database is erased and created from scratch.
+func OpenLevelDB(location string) (Backend, error) {
+	opts := &opt.Options{
+		OpenFilesCacheCapacity: dbMaxOpenFiles,
+		WriteBuffer:            dbWriteBuffer,
+	}
+	ldb, err := openLevelDB(location, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &leveldbBackend{ldb: ldb}, nil
+}
+
+// OpenLevelDBRO attempts to open the database at the given location, read
+// only.
+func OpenLevelDBRO(location string) (Backend, error) {
+	opts := &opt.Options{
+		OpenFilesCacheCapacity: dbMaxOpenFiles,
+		ReadOnly:               true,
+	}
+	ldb, err := openLevelDB(location, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &leveldbBackend{ldb: ldb}, nil
+}
+
+// OpenLevelDBMemory returns a new in-memory database.
+func OpenLevelDBMemory() Backend {
+	ldb, _ := leveldb.Open(storage.NewMemStorage(), nil)
+	return &leveldbBackend{ldb: ldb}
+}
+
+func openLevelDB(location string, opts *opt.Options) (*leveldb.DB, error) {
+	ldb, err := leveldb.OpenFile(location, opts)
+	if leveldbIsCorrupted(err) {
+		ldb, err = leveldb.RecoverFile(location, opts)
+	}
+	if leveldbIsCorrupted(err) {
+		// The database is corrupted, and we've tried to recover it but it
+		// didn't work. At this point there isn't much to do beyond dropping
+		// the database and reindexing...
+		l.Infoln("Database corruption detected, unable to recover. Reinitializing...")
+		if err := os.RemoveAll(location); err != nil {
+			return nil, err
+		}
+		ldb, err = leveldb.OpenFile(location, opts)
+	}
+	return ldb, err
+}
+
+func (b *leveldbBackend) Get(key []byte) ([]byte, error) {
+	val, err := b.ldb.Get(key, nil)
+	return val, wrapLevelDBErr(err)
+}
+
+func (b *leveldbBackend) Has(key []byte) (bool, error) {
+	return b.ldb.Has(key, nil)
+}
+
+func (b *leveldbBackend) NewPrefixIterator(prefix []byte) Iterator {
+	return leveldbIterator{b.ldb.NewIterator(util.BytesPrefix(prefix), nil)}
+}
+
+func (b *leveldbBackend) NewRangeIterator(first, last []byte) Iterator {
+	return leveldbIterator{b.ldb.NewIterator(&util.Range{Start: first, Limit: last}, nil)}
+}
+
+func (b *leveldbBackend) Put(key, val []byte) error {
+	return b.ldb.Put(key, val, nil)
+}
+
+func (b *leveldbBackend) Delete(key []byte) error {
+	return b.ldb.Delete(key, nil)
+}
+
+func (b *leveldbBackend) NewSnapshot() (Snapshot, error) {
+	snap, err := b.ldb.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return leveldbSnapshot{snap}, nil
+}
+
+func (b *leveldbBackend) NewBatch() Batch {
+	return &leveldbBatch{
+		Batch: new(leveldb.Batch),
+		ldb:   b.ldb,
+	}
+}
+
+func (b *leveldbBackend) Close() error {
+	return b.ldb.Close()
+}
+
+type leveldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s leveldbSnapshot) Get(key []byte) ([]byte, error) {
+	val, err := s.snap.Get(key, nil)
+	return val, wrapLevelDBErr(err)
+}
+
+func (s leveldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s leveldbSnapshot) NewPrefixIterator(prefix []byte) Iterator {
+	return leveldbIterator{s.snap.NewIterator(util.BytesPrefix(prefix), nil)}
+}
+
+func (s leveldbSnapshot) NewRangeIterator(first, last []byte) Iterator {
+	return leveldbIterator{s.snap.NewIterator(&util.Range{Start: first, Limit: last}, nil)}
+}
+
+func (s leveldbSnapshot) Release() {
+	s.snap.Release()
+}
+
+type leveldbBatch struct {
+	*leveldb.Batch
+	ldb *leveldb.DB
+}
+
+func (b *leveldbBatch) Size() int {
+	return len(b.Dump())
+}
+
+func (b *leveldbBatch) Write() error {
+	return b.ldb.Write(b.Batch, nil)
+}
+
+type leveldbIterator struct {
+	iterator.Iterator
+}
+
+// wrapLevelDBErr translates leveldb.ErrNotFound to ErrNotFound.
+func wrapLevelDBErr(err error) error {
+	if err == leveldb.ErrNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// A "better" version of leveldb's errors.IsCorrupted.
+func leveldbIsCorrupted(err error) bool {
+	switch {
+	case err == nil:
+		return false
+
+	case errors.IsCorrupted(err):
+		return true
+
+	case strings.Contains(err.Error(), "corrupted"):
+		return true
+	}
+
+	return false
+}
@@ -11,7 +11,6 @@ import (
 	"testing"
 
 	"github.com/syncthing/syncthing/lib/protocol"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 func genBlocks(n int) []protocol.BlockInfo {
@@ -57,7 +56,7 @@ func setup() (*instance, *BlockFinder) {
 }
 
 func dbEmpty(db *instance) bool {
-	iter := db.NewIterator(util.BytesPrefix([]byte{KeyTypeBlock}), nil)
+	iter := db.NewPrefixIterator([]byte{KeyTypeBlock})
 	defer iter.Release()
 	return !iter.Next()
 }
@@ -0,0 +1,187 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// CheckResult describes the inconsistencies found, and repaired, by a
+// consistency check of the database entries of a folder.
+type CheckResult struct {
+	Folder string `json:"folder"`
+	// Global version list entries pointing to files that don't exist.
+	StaleGlobalEntries int `json:"staleGlobalEntries"`
+	// Need entries for files that are not needed, or missing for files
+	// that are.
+	StaleNeedEntries   int `json:"staleNeedEntries"`
+	MissingNeedEntries int `json:"missingNeedEntries"`
+	// Block map entries for blocks not in a local file, or missing for
+	// blocks that are.
+	OrphanedBlockEntries int `json:"orphanedBlockEntries"`
+	MissingBlockEntries  int `json:"missingBlockEntries"`
+	// Whether the folder metadata counts were wrong.
+	WrongCounts bool          `json:"wrongCounts"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Repaired returns true if any inconsistency was found.
+func (r CheckResult) Repaired() bool {
+	return r.StaleGlobalEntries > 0 || r.StaleNeedEntries > 0 || r.MissingNeedEntries > 0 ||
+		r.OrphanedBlockEntries > 0 || r.MissingBlockEntries > 0 || r.WrongCounts
+}
+
+// Check verifies that the global version lists, need entries, block map and
+// metadata counts of the folder agree with the files in the database, and
+// repairs any drift found. Updates to the folder wait for the check to
+// complete.
+func (s *FileSet) Check() CheckResult {
+	l.Debugf("%s Check()", s.folder)
+
+	s.updateMutex.Lock()
+	defer s.updateMutex.Unlock()
+
+	t0 := time.Now()
+	folder := []byte(s.folder)
+	res := CheckResult{Folder: s.folder}
+
+	meta := newMetadataTracker()
+	res.StaleGlobalEntries = s.db.checkGlobals(folder, meta)
+	res.StaleNeedEntries, res.MissingNeedEntries = s.db.checkNeeds(folder)
+	res.OrphanedBlockEntries, res.MissingBlockEntries = s.db.checkBlockMap(folder)
+
+	var deviceID protocol.DeviceID
+	s.db.withAllFolderTruncated(folder, func(device []byte, f FileInfoTruncated) bool {
+		copy(deviceID[:], device)
+		meta.addFile(deviceID, f)
+		return true
+	})
+	if s.meta.repairCounts(meta) {
+		res.WrongCounts = true
+		s.meta.toDB(s.db, folder)
+	}
+
+	res.Duration = time.Since(t0)
+	return res
+}
+
+// checkNeeds makes the need entries match the global version lists. It
+// returns the number of stale entries removed and missing entries added.
+func (db *instance) checkNeeds(folder []byte) (stale, missing int) {
+	t := db.newReadWriteTransaction()
+	defer t.close()
+
+	dbi := t.NewPrefixIterator(db.keyer.GenerateGlobalVersionKey(nil, folder, nil).WithoutName())
+	var dk, nk []byte
+	for dbi.Next() {
+		vl, ok := unmarshalVersionList(dbi.Value())
+		if !ok {
+			continue
+		}
+		name := db.keyer.NameFromGlobalVersionKey(dbi.Key())
+		dk = db.keyer.GenerateDeviceFileKey(dk, folder, vl.Versions[0].Device, name)
+		global, ok := t.getFileByKey(dk)
+		if !ok {
+			continue
+		}
+
+		localFV, haveLocalFV := vl.Get(protocol.LocalDeviceID[:])
+		needed := need(global, haveLocalFV, localFV.Version)
+		nk = db.keyer.GenerateNeedFileKey(nk, folder, name)
+		hasNeed, _ := t.Has(nk)
+		switch {
+		case needed && !hasNeed:
+			l.Debugf("check: missing need entry; folder=%q, name=%q", folder, name)
+			t.Put(nk, nil)
+			missing++
+		case !needed && hasNeed:
+			l.Debugf("check: stale need entry; folder=%q, name=%q", folder, name)
+			t.Delete(nk)
+			stale++
+		default:
+			continue
+		}
+		t.checkFlush()
+	}
+	dbi.Release()
+
+	// Need entries without a global version list
+	dbi = t.NewPrefixIterator(db.keyer.GenerateNeedFileKey(nil, folder, nil).WithoutName())
+	defer dbi.Release()
+	var gk []byte
+	for dbi.Next() {
+		name := db.keyer.NameFromNeedFileKey(dbi.Key())
+		gk = db.keyer.GenerateGlobalVersionKey(gk, folder, name)
+		if ok, _ := t.Has(gk); ok {
+			continue
+		}
+		l.Debugf("check: need entry without global; folder=%q, name=%q", folder, name)
+		t.Delete(dbi.Key())
+		t.checkFlush()
+		stale++
+	}
+
+	return stale, missing
+}
+
+// checkBlockMap makes the block map match the blocks of the local files. It
+// returns the number of orphaned entries removed and missing entries added.
+func (db *instance) checkBlockMap(folder []byte) (orphaned, missing int) {
+	t := db.newReadWriteTransaction()
+	defer t.close()
+
+	dbi := t.NewPrefixIterator(db.keyer.GenerateBlockMapKey(nil, folder, nil, nil).WithoutHashAndName())
+	for dbi.Next() {
+		name := db.keyer.NameFromBlockMapKey(dbi.Key())
+		hash := db.keyer.HashFromBlockMapKey(dbi.Key())
+		index := int(binary.BigEndian.Uint32(dbi.Value()))
+		if f, ok := t.getFile(folder, protocol.LocalDeviceID[:], name); ok && hasBlockMap(f) &&
+			index < len(f.Blocks) && bytes.Equal(f.Blocks[index].Hash, hash) {
+			continue
+		}
+		l.Debugf("check: orphaned block map entry; folder=%q, name=%q, index=%d", folder, name, index)
+		t.Delete(dbi.Key())
+		t.checkFlush()
+		orphaned++
+	}
+	dbi.Release()
+
+	dbi = t.NewPrefixIterator(db.keyer.GenerateDeviceFileKey(nil, folder, protocol.LocalDeviceID[:], nil))
+	defer dbi.Release()
+	var bk []byte
+	blockBuf := make([]byte, 4)
+	for dbi.Next() {
+		var f protocol.FileInfo
+		if err := f.Unmarshal(dbi.Value()); err != nil || !hasBlockMap(f) {
+			continue
+		}
+		name := []byte(f.Name)
+		for i, block := range f.Blocks {
+			bk = db.keyer.GenerateBlockMapKey(bk, folder, block.Hash, name)
+			if ok, _ := t.Has(bk); ok {
+				continue
+			}
+			l.Debugf("check: missing block map entry; folder=%q, name=%q, index=%d", folder, name, i)
+			binary.BigEndian.PutUint32(blockBuf, uint32(i))
+			t.Put(bk, blockBuf)
+			t.checkFlush()
+			missing++
+		}
+	}
+
+	return orphaned, missing
+}
+
+// hasBlockMap returns true if the blocks of the local file are in the block
+// map, see updateLocalFiles.
+func hasBlockMap(f protocol.FileInfo) bool {
+	return !f.IsDirectory() && !f.IsDeleted() && !f.IsInvalid()
+}
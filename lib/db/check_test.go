@@ -0,0 +1,82 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestCheckRepairs(t *testing.T) {
+	ldb := OpenMemory()
+	s := NewFileSet("test", fs.NewFilesystem(fs.FilesystemTypeBasic, "."), ldb)
+	remote := protocol.DeviceID{42}
+	folder := []byte("test")
+
+	s.Update(protocol.LocalDeviceID, []protocol.FileInfo{
+		{Name: "a", Version: protocol.Vector{Counters: []protocol.Counter{{ID: myID, Value: 1}}}, Blocks: genBlocks(2)},
+		{Name: "b", Version: protocol.Vector{Counters: []protocol.Counter{{ID: myID, Value: 1}}}, Blocks: genBlocks(3)},
+	})
+	s.Update(remote, []protocol.FileInfo{
+		{Name: "b", Version: protocol.Vector{Counters: []protocol.Counter{{ID: myID, Value: 1}, {ID: 42, Value: 1}}}, Blocks: genBlocks(4)},
+		{Name: "c", Version: protocol.Vector{Counters: []protocol.Counter{{ID: 42, Value: 1}}}, Blocks: genBlocks(1)},
+	})
+
+	if res := s.Check(); res.Repaired() {
+		t.Fatalf("Consistent database needed repair: %+v", res)
+	}
+
+	// Drift in every possible way.
+
+	db := s.db
+	db.Delete(db.keyer.GenerateNeedFileKey(nil, folder, []byte("b")))
+	db.Put(db.keyer.GenerateNeedFileKey(nil, folder, []byte("a")), nil)
+	db.Put(db.keyer.GenerateNeedFileKey(nil, folder, []byte("gone")), nil)
+	db.Delete(db.keyer.GenerateBlockMapKey(nil, folder, genBlocks(2)[1].Hash, []byte("a")))
+	db.Put(db.keyer.GenerateBlockMapKey(nil, folder, genBlocks(1)[0].Hash, []byte("c")), []byte{0, 0, 0, 0})
+	db.Put(db.keyer.GenerateBlockMapKey(nil, folder, genBlocks(4)[3].Hash, []byte("gone")), []byte{0, 0, 0, 3})
+	s.meta.addFile(protocol.LocalDeviceID, protocol.FileInfo{Name: "phantom", Size: 1234})
+	localSeq := s.Sequence(protocol.LocalDeviceID)
+
+	res := s.Check()
+	if res.StaleNeedEntries != 2 {
+		t.Errorf("Removed %d stale need entries, expected 2", res.StaleNeedEntries)
+	}
+	if res.MissingNeedEntries != 1 {
+		t.Errorf("Added %d missing need entries, expected 1", res.MissingNeedEntries)
+	}
+	if res.OrphanedBlockEntries != 2 {
+		t.Errorf("Removed %d orphaned block entries, expected 2", res.OrphanedBlockEntries)
+	}
+	if res.MissingBlockEntries != 1 {
+		t.Errorf("Added %d missing block entries, expected 1", res.MissingBlockEntries)
+	}
+	if !res.WrongCounts {
+		t.Error("Wrong counts were not detected")
+	}
+
+	if local := s.LocalSize(); local.Files != 2 || local.Bytes != 0 {
+		t.Errorf("Local counts not repaired: %+v", local)
+	}
+	if seq := s.Sequence(protocol.LocalDeviceID); seq != localSeq {
+		t.Errorf("Local sequence changed from %d to %d", localSeq, seq)
+	}
+	var need []string
+	s.WithNeed(protocol.LocalDeviceID, func(f FileIntf) bool {
+		need = append(need, f.FileName())
+		return true
+	})
+	if len(need) != 2 || need[0] != "b" || need[1] != "c" {
+		t.Errorf("Need is %v after repair, expected [b c]", need)
+	}
+
+	if res := s.Check(); res.Repaired() {
+		t.Errorf("Repaired database needed another repair: %+v", res)
+	}
+}
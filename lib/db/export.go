@@ -0,0 +1,300 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:generate go run ../../script/protofmt.go export.proto
+//go:generate protoc -I ../../ -I . --gogofast_out=Mlib/protocol/bep.proto=github.com/syncthing/syncthing/lib/protocol:. export.proto
+
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	exportMagic   uint32 = 0x2ea7d90b
+	exportVersion        = 1
+
+	// Files are written in records of at most this many entries
+	exportBatchFiles = 1000
+	// Records larger than this are considered corrupt
+	maxExportRecordSize = 256 << 20
+)
+
+var errExportMagic = errors.New("not an index export")
+
+// ImportResult describes what was restored by ImportFolder.
+type ImportResult struct {
+	Devices     int
+	LocalFiles  int
+	RemoteFiles int
+	// Local files that didn't match the file on disk and were marked to be
+	// rescanned.
+	MustRescan int
+}
+
+// ExportFolder writes the local and remote index state of the folder, with
+// index IDs and sequence numbers, to w. The device ID is that of the local
+// device and is checked on import.
+func ExportFolder(w io.Writer, ll *Lowlevel, folder string, device protocol.DeviceID) error {
+	s := NewFileSet(folder, nil, ll)
+	ew := &exportWriter{w: bufio.NewWriter(w)}
+
+	if err := binary.Write(ew.w, binary.BigEndian, exportMagic); err != nil {
+		return err
+	}
+	ew.write(&ExportRecord{Header: &ExportHeader{
+		Version:  exportVersion,
+		Folder:   folder,
+		DeviceID: device,
+		Created:  time.Now().UnixNano(),
+	}})
+
+	trailer := &ExportTrailer{}
+	devices := append([]protocol.DeviceID{protocol.LocalDeviceID}, s.ListDevices()...)
+	for _, dev := range devices {
+		ew.write(&ExportRecord{Device: &ExportDevice{
+			DeviceID: dev,
+			IndexID:  s.IndexID(dev),
+			Sequence: s.Sequence(dev),
+		}})
+		trailer.Devices++
+
+		files := &ExportFiles{DeviceID: dev}
+		fn := func(fi FileIntf) bool {
+			files.Files = append(files.Files, fi.(protocol.FileInfo))
+			trailer.Files++
+			if len(files.Files) == exportBatchFiles {
+				ew.write(&ExportRecord{Files: files})
+				files.Files = files.Files[:0]
+			}
+			return ew.err == nil
+		}
+		// Names are kept in wire format. The local files are exported in
+		// sequence order, so that they keep their relative order on import.
+		if dev == protocol.LocalDeviceID {
+			s.db.withHaveSequence([]byte(folder), 0, fn)
+		} else {
+			s.db.withHave([]byte(folder), dev[:], nil, false, fn)
+		}
+		if len(files.Files) > 0 {
+			ew.write(&ExportRecord{Files: files})
+		}
+		if ew.err != nil {
+			return ew.err
+		}
+	}
+
+	ew.write(&ExportRecord{Trailer: trailer})
+	if ew.err != nil {
+		return ew.err
+	}
+	return ew.w.Flush()
+}
+
+// ImportFolder replaces the index state of the folder with that read from
+// r, as written by ExportFolder on the given device. The local files are
+// validated against filesystem; those that don't match the file on disk are
+// marked to be rescanned. The export is read into a temporary folder, which
+// takes the place of the existing one only once it has been read completely,
+// so a failed import leaves the existing index state alone.
+func ImportFolder(r io.Reader, ll *Lowlevel, folder string, device protocol.DeviceID, filesystem fs.Filesystem) (ImportResult, error) {
+	var res ImportResult
+	er := &exportReader{r: bufio.NewReader(r)}
+
+	var magic uint32
+	if err := binary.Read(er.r, binary.BigEndian, &magic); err != nil || magic != exportMagic {
+		return res, errExportMagic
+	}
+	rec, err := er.read()
+	if err != nil {
+		return res, err
+	}
+	switch hdr := rec.Header; {
+	case hdr == nil:
+		return res, errExportMagic
+	case hdr.Version > exportVersion:
+		return res, fmt.Errorf("unsupported index export version %d", hdr.Version)
+	case hdr.Folder != folder:
+		return res, fmt.Errorf("index export is for folder %q, not %q", hdr.Folder, folder)
+	case hdr.DeviceID != device:
+		return res, fmt.Errorf("index export is for device %v, not %v", hdr.DeviceID, device)
+	}
+
+	// Leftovers from an earlier import that didn't complete are dropped.
+	tmp := importTempFolder(folder)
+	DropFolder(ll, tmp)
+	res, err = importRecords(er, ll, tmp, device, filesystem)
+	if err != nil {
+		DropFolder(ll, tmp)
+		return ImportResult{}, err
+	}
+
+	DropFolder(ll, folder)
+	ll.folderIdx.Rename([]byte(tmp), []byte(folder))
+
+	return res, nil
+}
+
+// importTempFolder returns the name of the folder that an import for the
+// given folder is read into. It can't clash with a folder ID from the
+// config.
+func importTempFolder(folder string) string {
+	return "\x00import\x00" + folder
+}
+
+// importRecords reads the records after the header into the given folder,
+// up to and including the trailer.
+func importRecords(er *exportReader, ll *Lowlevel, folder string, device protocol.DeviceID, filesystem fs.Filesystem) (ImportResult, error) {
+	var res ImportResult
+	s := NewFileSet(folder, filesystem, ll)
+
+	var devices []*ExportDevice
+	var rescan []protocol.FileInfo
+	var files int64
+	for {
+		rec, err := er.read()
+		if err == io.EOF {
+			return res, errors.New("index export is truncated")
+		} else if err != nil {
+			return res, err
+		}
+		if rec.Trailer != nil {
+			if rec.Trailer.Devices != int64(len(devices)) || rec.Trailer.Files != files {
+				return res, fmt.Errorf("index export is incomplete: read %d devices and %d files, expected %d and %d", len(devices), files, rec.Trailer.Devices, rec.Trailer.Files)
+			}
+			break
+		}
+
+		switch {
+		case rec.Device != nil:
+			devices = append(devices, rec.Device)
+			if rec.Device.DeviceID != protocol.LocalDeviceID {
+				res.Devices++
+			}
+
+		case rec.Files != nil:
+			files += int64(len(rec.Files.Files))
+			if rec.Files.DeviceID != protocol.LocalDeviceID {
+				s.Update(rec.Files.DeviceID, rec.Files.Files)
+				res.RemoteFiles += len(rec.Files.Files)
+				continue
+			}
+			matching := rec.Files.Files[:0]
+			for _, f := range rec.Files.Files {
+				if matchesDisk(filesystem, f) {
+					matching = append(matching, f)
+					continue
+				}
+				l.Debugf("import: %v doesn't match the file on disk", f)
+				f.SetMustRescan(device.Short())
+				rescan = append(rescan, f)
+			}
+			s.Update(protocol.LocalDeviceID, matching)
+			res.LocalFiles += len(rec.Files.Files)
+		}
+	}
+
+	for _, dev := range devices {
+		s.db.setIndexID(dev.DeviceID[:], []byte(folder), dev.IndexID)
+		s.meta.raiseSequence(dev.DeviceID, dev.Sequence)
+	}
+	s.meta.toDB(s.db, []byte(folder))
+
+	// The files to rescan get sequence numbers above those known to our
+	// peers, so that they are announced as changed.
+	if len(rescan) > 0 {
+		s.Update(protocol.LocalDeviceID, rescan)
+		res.MustRescan = len(rescan)
+	}
+
+	return res, nil
+}
+
+// matchesDisk returns true if the existing, valid, local file has the same
+// type, size and modification time on disk.
+func matchesDisk(filesystem fs.Filesystem, f protocol.FileInfo) bool {
+	if f.IsDeleted() || f.IsInvalid() {
+		// Nothing to validate, the scanner picks up anything new.
+		return true
+	}
+	info, err := filesystem.Lstat(osutil.NativeFilename(f.Name))
+	if err != nil {
+		return false
+	}
+	switch {
+	case f.IsDirectory():
+		return info.IsDir()
+	case f.IsSymlink():
+		return info.IsSymlink()
+	default:
+		return info.IsRegular() && info.Size() == f.Size && info.ModTime().Equal(f.ModTime())
+	}
+}
+
+type exportWriter struct {
+	w   *bufio.Writer
+	buf []byte
+	err error
+}
+
+func (w *exportWriter) write(rec *ExportRecord) {
+	if w.err != nil {
+		return
+	}
+	size := rec.ProtoSize()
+	if cap(w.buf) < size+4 {
+		w.buf = make([]byte, size+4)
+	}
+	w.buf = w.buf[:size+4]
+	binary.BigEndian.PutUint32(w.buf, uint32(size))
+	if _, err := rec.MarshalTo(w.buf[4:]); err != nil {
+		w.err = err
+		return
+	}
+	_, w.err = w.w.Write(w.buf)
+}
+
+type exportReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// read returns the next record, or io.EOF at the end of the stream.
+func (r *exportReader) read() (*ExportRecord, error) {
+	if cap(r.buf) < 4 {
+		r.buf = make([]byte, 4)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:4]); err != nil {
+		return nil, err
+	}
+	size := int(binary.BigEndian.Uint32(r.buf[:4]))
+	if size > maxExportRecordSize {
+		return nil, fmt.Errorf("index export record of %d bytes is too large", size)
+	}
+	if cap(r.buf) < size {
+		r.buf = make([]byte, size)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:size]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	rec := new(ExportRecord)
+	if err := rec.Unmarshal(r.buf[:size]); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
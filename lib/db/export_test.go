@@ -0,0 +1,142 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package db
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-export-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mtime := time.Unix(1234567890, 0)
+	for name, size := range map[string]int{"a": 10, "b": 20} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	me := protocol.DeviceID{1}
+	remote := protocol.DeviceID{42}
+	v1 := protocol.Vector{Counters: []protocol.Counter{{ID: myID, Value: 1}}}
+	local := []protocol.FileInfo{
+		{Name: "a", Size: 10, ModifiedS: mtime.Unix(), Version: v1, Blocks: genBlocks(1)},
+		{Name: "b", Size: 21, ModifiedS: mtime.Unix(), Version: v1, Blocks: genBlocks(2)},
+		{Name: "d", Type: protocol.FileInfoTypeDirectory, Version: v1},
+		{Name: "gone", Size: 30, ModifiedS: mtime.Unix(), Version: v1, Blocks: genBlocks(3)},
+		{Name: "deleted", Deleted: true, Version: v1},
+	}
+
+	src := OpenMemory()
+	s := NewFileSet("test", nil, src)
+	s.Update(protocol.LocalDeviceID, local)
+	s.Update(protocol.LocalDeviceID, local[:1]) // unchanged, no new sequence
+	s.Update(remote, []protocol.FileInfo{
+		{Name: "a", Size: 10, ModifiedS: mtime.Unix(), Version: v1, Blocks: genBlocks(1), Sequence: 7},
+		{Name: "c", Size: 40, Version: protocol.Vector{Counters: []protocol.Counter{{ID: 42, Value: 1}}}, Blocks: genBlocks(4), Sequence: 9},
+	})
+	s.SetIndexID(remote, 1234)
+	localIndexID := s.IndexID(protocol.LocalDeviceID)
+	localSeq := s.Sequence(protocol.LocalDeviceID)
+
+	var buf bytes.Buffer
+	if err := ExportFolder(&buf, src, "test", me); err != nil {
+		t.Fatal(err)
+	}
+	exported := buf.Bytes()
+
+	dst := OpenMemory()
+	filesystem := fs.NewFilesystem(fs.FilesystemTypeBasic, dir)
+	if _, err := ImportFolder(bytes.NewReader(exported), dst, "other", me, filesystem); err == nil {
+		t.Error("Import into the wrong folder succeeded")
+	}
+	if _, err := ImportFolder(bytes.NewReader(exported), dst, "test", remote, filesystem); err == nil {
+		t.Error("Import onto the wrong device succeeded")
+	}
+	if _, err := ImportFolder(bytes.NewReader(exported[:len(exported)-1]), dst, "test", me, filesystem); err == nil {
+		t.Error("Import of a truncated export succeeded")
+	}
+
+	res, err := ImportFolder(bytes.NewReader(exported), dst, "test", me, filesystem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Devices != 1 || res.LocalFiles != 5 || res.RemoteFiles != 2 || res.MustRescan != 2 {
+		t.Errorf("Unexpected import result %+v", res)
+	}
+
+	// Cut off the trailer, such that the export ends on a record boundary.
+	// The import must fail and leave what was imported above alone.
+	trailer := &ExportRecord{Trailer: &ExportTrailer{Devices: 2, Files: 7}}
+	truncated := exported[:len(exported)-4-trailer.ProtoSize()]
+	if _, err := ImportFolder(bytes.NewReader(truncated), dst, "test", me, filesystem); err == nil {
+		t.Error("Import of an export without trailer succeeded")
+	} else if !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("Unexpected error importing an export without trailer: %v", err)
+	}
+	if folders := dst.ListFolders(); len(folders) != 1 || folders[0] != "test" {
+		t.Errorf("Unexpected folders after failed import: %q", folders)
+	}
+
+	s = NewFileSet("test", filesystem, dst)
+	if id := s.IndexID(protocol.LocalDeviceID); id != localIndexID {
+		t.Errorf("Local index ID is %v, expected %v", id, localIndexID)
+	}
+	if id := s.IndexID(remote); id != 1234 {
+		t.Errorf("Remote index ID is %v, expected 1234", id)
+	}
+	if seq := s.Sequence(remote); seq != 9 {
+		t.Errorf("Remote sequence is %d, expected 9", seq)
+	}
+	if seq := s.Sequence(protocol.LocalDeviceID); seq != localSeq+2 {
+		t.Errorf("Local sequence is %d, expected %d", seq, localSeq+2)
+	}
+
+	for _, f := range local {
+		got, ok := s.Get(protocol.LocalDeviceID, f.Name)
+		if !ok {
+			t.Errorf("Local file %q missing after import", f.Name)
+			continue
+		}
+		rescan := f.Name == "b" || f.Name == "gone"
+		if got.MustRescan() != rescan {
+			t.Errorf("Local file %q must rescan is %v, expected %v", f.Name, got.MustRescan(), rescan)
+		}
+		if rescan && got.Sequence <= localSeq {
+			t.Errorf("Local file %q to rescan has old sequence %d", f.Name, got.Sequence)
+		}
+		if !got.Version.Equal(f.Version) {
+			t.Errorf("Local file %q has version %v, expected %v", f.Name, got.Version, f.Version)
+		}
+	}
+	if f, ok := s.Get(remote, "c"); !ok || f.Size != 40 || len(f.Blocks) != 4 {
+		t.Errorf("Remote file not restored: %v", f)
+	}
+	if res := s.Check(); res.Repaired() {
+		t.Errorf("Imported database needed repair: %+v", res)
+	}
+}
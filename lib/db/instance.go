@@ -11,9 +11,8 @@ import (
 	"encoding/binary"
 	"fmt"
 
+	"github.com/syncthing/syncthing/lib/db/backend"
 	"github.com/syncthing/syncthing/lib/protocol"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 type instance struct {
@@ -136,7 +135,7 @@ func (db *instance) withHave(folder, device, prefix []byte, truncate bool, fn It
 		}
 	}
 
-	dbi := t.NewIterator(util.BytesPrefix(db.keyer.GenerateDeviceFileKey(nil, folder, device, prefix)), nil)
+	dbi := t.NewPrefixIterator(db.keyer.GenerateDeviceFileKey(nil, folder, device, prefix))
 	defer dbi.Release()
 
 	for dbi.Next() {
@@ -160,7 +159,7 @@ func (db *instance) withHaveSequence(folder []byte, startSeq int64, fn Iterator)
 	t := db.newReadOnlyTransaction()
 	defer t.close()
 
-	dbi := t.NewIterator(&util.Range{Start: db.keyer.GenerateSequenceKey(nil, folder, startSeq), Limit: db.keyer.GenerateSequenceKey(nil, folder, maxInt64)}, nil)
+	dbi := t.NewRangeIterator(db.keyer.GenerateSequenceKey(nil, folder, startSeq), db.keyer.GenerateSequenceKey(nil, folder, maxInt64))
 	defer dbi.Release()
 
 	for dbi.Next() {
@@ -185,7 +184,7 @@ func (db *instance) withAllFolderTruncated(folder []byte, fn func(device []byte,
 	t := db.newReadWriteTransaction()
 	defer t.close()
 
-	dbi := t.NewIterator(util.BytesPrefix(db.keyer.GenerateDeviceFileKey(nil, folder, nil, nil).WithoutNameAndDevice()), nil)
+	dbi := t.NewPrefixIterator(db.keyer.GenerateDeviceFileKey(nil, folder, nil, nil).WithoutNameAndDevice())
 	defer dbi.Release()
 
 	var gk, keyBuf []byte
@@ -255,7 +254,7 @@ func (db *instance) withGlobal(folder, prefix []byte, truncate bool, fn Iterator
 		}
 	}
 
-	dbi := t.NewIterator(util.BytesPrefix(db.keyer.GenerateGlobalVersionKey(nil, folder, prefix)), nil)
+	dbi := t.NewPrefixIterator(db.keyer.GenerateGlobalVersionKey(nil, folder, prefix))
 	defer dbi.Release()
 
 	var dk []byte
@@ -285,8 +284,8 @@ func (db *instance) withGlobal(folder, prefix []byte, truncate bool, fn Iterator
 
 func (db *instance) availability(folder, file []byte) []protocol.DeviceID {
 	k := db.keyer.GenerateGlobalVersionKey(nil, folder, file)
-	bs, err := db.Get(k, nil)
-	if err == leveldb.ErrNotFound {
+	bs, err := db.Get(k)
+	if backend.IsNotFound(err) {
 		return nil
 	}
 	if err != nil {
@@ -323,7 +322,7 @@ func (db *instance) withNeed(folder, device []byte, truncate bool, fn Iterator)
 	t := db.newReadOnlyTransaction()
 	defer t.close()
 
-	dbi := t.NewIterator(util.BytesPrefix(db.keyer.GenerateGlobalVersionKey(nil, folder, nil).WithoutName()), nil)
+	dbi := t.NewPrefixIterator(db.keyer.GenerateGlobalVersionKey(nil, folder, nil).WithoutName())
 	defer dbi.Release()
 
 	var dk []byte
@@ -384,7 +383,7 @@ func (db *instance) withNeedLocal(folder []byte, truncate bool, fn Iterator) {
 	t := db.newReadOnlyTransaction()
 	defer t.close()
 
-	dbi := t.NewIterator(util.BytesPrefix(db.keyer.GenerateNeedFileKey(nil, folder, nil).WithoutName()), nil)
+	dbi := t.NewPrefixIterator(db.keyer.GenerateNeedFileKey(nil, folder, nil).WithoutName())
 	defer dbi.Release()
 
 	var keyBuf []byte
@@ -425,7 +424,7 @@ func (db *instance) dropDeviceFolder(device, folder []byte, meta *metadataTracke
 	t := db.newReadWriteTransaction()
 	defer t.close()
 
-	dbi := t.NewIterator(util.BytesPrefix(db.keyer.GenerateDeviceFileKey(nil, folder, device, nil)), nil)
+	dbi := t.NewPrefixIterator(db.keyer.GenerateDeviceFileKey(nil, folder, device, nil))
 	defer dbi.Release()
 
 	var gk, keyBuf []byte
@@ -441,14 +440,18 @@ func (db *instance) dropDeviceFolder(device, folder []byte, meta *metadataTracke
 	}
 }
 
-func (db *instance) checkGlobals(folder []byte, meta *metadataTracker) {
+// checkGlobals removes entries pointing to files that don't exist from the
+// global version lists, and adds the global files to the counts in meta. It
+// returns the number of entries removed.
+func (db *instance) checkGlobals(folder []byte, meta *metadataTracker) int {
 	t := db.newReadWriteTransaction()
 	defer t.close()
 
-	dbi := t.NewIterator(util.BytesPrefix(db.keyer.GenerateGlobalVersionKey(nil, folder, nil).WithoutName()), nil)
+	dbi := t.NewPrefixIterator(db.keyer.GenerateGlobalVersionKey(nil, folder, nil).WithoutName())
 	defer dbi.Release()
 
 	var dk []byte
+	removed := 0
 	for dbi.Next() {
 		vl, ok := unmarshalVersionList(dbi.Value())
 		if !ok {
@@ -464,13 +467,13 @@ func (db *instance) checkGlobals(folder []byte, meta *metadataTracker) {
 		var newVL VersionList
 		for i, version := range vl.Versions {
 			dk = db.keyer.GenerateDeviceFileKey(dk, folder, version.Device, name)
-			_, err := t.Get(dk, nil)
-			if err == leveldb.ErrNotFound {
+			_, err := t.Get(dk)
+			if backend.IsNotFound(err) {
 				continue
 			}
 			if err != nil {
 				l.Debugln("surprise error:", err)
-				return
+				return removed
 			}
 			newVL.Versions = append(newVL.Versions, version)
 
@@ -482,15 +485,17 @@ func (db *instance) checkGlobals(folder []byte, meta *metadataTracker) {
 		}
 
 		if len(newVL.Versions) != len(vl.Versions) {
+			removed += len(vl.Versions) - len(newVL.Versions)
 			t.Put(dbi.Key(), mustMarshal(&newVL))
 			t.checkFlush()
 		}
 	}
 	l.Debugf("db check completed for %q", folder)
+	return removed
 }
 
 func (db *instance) getIndexID(device, folder []byte) protocol.IndexID {
-	cur, err := db.Get(db.keyer.GenerateIndexIDKey(nil, device, folder), nil)
+	cur, err := db.Get(db.keyer.GenerateIndexIDKey(nil, device, folder))
 	if err != nil {
 		return 0
 	}
@@ -505,7 +510,7 @@ func (db *instance) getIndexID(device, folder []byte) protocol.IndexID {
 
 func (db *instance) setIndexID(device, folder []byte, id protocol.IndexID) {
 	bs, _ := id.Marshal() // marshalling can't fail
-	if err := db.Put(db.keyer.GenerateIndexIDKey(nil, device, folder), bs, nil); err != nil {
+	if err := db.Put(db.keyer.GenerateIndexIDKey(nil, device, folder), bs); err != nil {
 		panic("storing index ID: " + err.Error())
 	}
 }
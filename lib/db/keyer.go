@@ -76,9 +76,11 @@ type keyer interface {
 	// block map key stuff (former BlockMap)
 	GenerateBlockMapKey(key, folder, hash, name []byte) blockMapKey
 	NameFromBlockMapKey(key []byte) []byte
+	HashFromBlockMapKey(key []byte) []byte
 
 	// file need index
 	GenerateNeedFileKey(key, folder, name []byte) needFileKey
+	NameFromNeedFileKey(key []byte) []byte
 
 	// file sequence index
 	GenerateSequenceKey(key, folder []byte, seq int64) sequenceKey
@@ -173,6 +175,10 @@ func (k defaultKeyer) NameFromBlockMapKey(key []byte) []byte {
 	return key[keyPrefixLen+keyFolderLen+keyHashLen:]
 }
 
+func (k defaultKeyer) HashFromBlockMapKey(key []byte) []byte {
+	return key[keyPrefixLen+keyFolderLen : keyPrefixLen+keyFolderLen+keyHashLen]
+}
+
 func (k blockMapKey) WithoutHashAndName() []byte {
 	return k[:keyPrefixLen+keyFolderLen]
 }
@@ -191,6 +197,10 @@ func (k defaultKeyer) GenerateNeedFileKey(key, folder, name []byte) needFileKey
 	return key
 }
 
+func (k defaultKeyer) NameFromNeedFileKey(key []byte) []byte {
+	return key[keyPrefixLen+keyFolderLen:]
+}
+
 type sequenceKey []byte
 
 func (k sequenceKey) WithoutSequence() []byte {
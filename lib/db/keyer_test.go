@@ -78,3 +78,16 @@ func TestSequenceKey(t *testing.T) {
 		t.Errorf("sequence number mangled, %d != %d", outSeq, seq)
 	}
 }
+
+func TestNeedKey(t *testing.T) {
+	fld := []byte("folder6789012345678901234567890123456789012345678901234567890123")
+	name := []byte("name")
+
+	db := newInstance(OpenMemory())
+
+	key := db.keyer.GenerateNeedFileKey(nil, fld, name)
+	name2 := db.keyer.NameFromNeedFileKey(key)
+	if !bytes.Equal(name2, name) {
+		t.Errorf("wrong name %q != %q", name2, name)
+	}
+}
@@ -7,80 +7,80 @@
 package db
 
 import (
-	"os"
-	"strings"
 	"sync/atomic"
 
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/errors"
-	"github.com/syndtr/goleveldb/leveldb/opt"
-	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syncthing/syncthing/lib/db/backend"
 )
 
-const (
-	dbMaxOpenFiles = 100
-	dbWriteBuffer  = 16 << 20
-	dbFlushBatch   = dbWriteBuffer / 4 // Some leeway for any leveldb in-memory optimizations
-)
+const dbFlushBatch = 4 << 20 // Some leeway for any in-memory optimizations of the backend
+
+// memoryBackend is the backend type used by OpenMemory.
+// Not meant to be changed, but must be changeable for tests
+var memoryBackend = backend.DefaultType
 
 // Lowlevel is the lowest level database interface. It has a very simple
-// purpose: hold the actual *leveldb.DB database, and the in-memory state
+// purpose: hold the actual backend database, and the in-memory state
 // that belong to that database. In the same way that a single on disk
 // database can only be opened once, there should be only one Lowlevel for
-// any given *leveldb.DB.
+// any given backend.
 type Lowlevel struct {
 	committed int64 // atomic, must come first
-	*leveldb.DB
+	backend.Backend
 	location  string
 	folderIdx *smallIndex
 	deviceIdx *smallIndex
 }
 
-// Open attempts to open the database at the given location, and runs
-// recovery on it if opening fails. Worst case, if recovery is not possible,
-// the database is erased and created from scratch.
+// Open attempts to open the database at the given location, using the
+// backend of the existing database or the default backend if there is none.
+// Worst case, if recovery is not possible, the database is erased and
+// created from scratch.
 func Open(location string) (*Lowlevel, error) {
-	opts := &opt.Options{
-		OpenFilesCacheCapacity: dbMaxOpenFiles,
-		WriteBuffer:            dbWriteBuffer,
+	typ, ok := backend.Detect(location)
+	if !ok {
+		typ = backend.DefaultType
 	}
-	return open(location, opts)
+	return OpenType(typ, location)
 }
 
-// OpenRO attempts to open the database at the given location, read only.
-func OpenRO(location string) (*Lowlevel, error) {
-	opts := &opt.Options{
-		OpenFilesCacheCapacity: dbMaxOpenFiles,
-		ReadOnly:               true,
+// OpenType attempts to open the database at the given location using the
+// given backend. An existing database of a different backend type is
+// migrated first.
+func OpenType(typ backend.Type, location string) (*Lowlevel, error) {
+	if existing, ok := backend.Detect(location); ok && existing != typ {
+		l.Infof("Migrating database from %s to %s backend...", existing, typ)
+		if err := backend.Migrate(existing, typ, location); err != nil {
+			return nil, errorSuggestion{err, "failed to migrate database"}
+		}
+		l.Infoln("Database migration complete")
 	}
-	return open(location, opts)
+	b, err := backend.Open(typ, location)
+	if err != nil {
+		return nil, errorSuggestion{err, "is another instance of Syncthing running?"}
+	}
+	return NewLowlevel(b, location), nil
 }
 
-func open(location string, opts *opt.Options) (*Lowlevel, error) {
-	db, err := leveldb.OpenFile(location, opts)
-	if leveldbIsCorrupted(err) {
-		db, err = leveldb.RecoverFile(location, opts)
-	}
-	if leveldbIsCorrupted(err) {
-		// The database is corrupted, and we've tried to recover it but it
-		// didn't work. At this point there isn't much to do beyond dropping
-		// the database and reindexing...
-		l.Infoln("Database corruption detected, unable to recover. Reinitializing...")
-		if err := os.RemoveAll(location); err != nil {
-			return nil, errorSuggestion{err, "failed to delete corrupted database"}
-		}
-		db, err = leveldb.OpenFile(location, opts)
+// OpenRO attempts to open the database at the given location, read only.
+func OpenRO(location string) (*Lowlevel, error) {
+	typ, ok := backend.Detect(location)
+	if !ok {
+		typ = backend.DefaultType
 	}
+	b, err := backend.OpenRO(typ, location)
 	if err != nil {
 		return nil, errorSuggestion{err, "is another instance of Syncthing running?"}
 	}
-	return NewLowlevel(db, location), nil
+	return NewLowlevel(b, location), nil
 }
 
 // OpenMemory returns a new Lowlevel referencing an in-memory database.
 func OpenMemory() *Lowlevel {
-	db, _ := leveldb.Open(storage.NewMemStorage(), nil)
-	return NewLowlevel(db, "<memory>")
+	b, err := backend.OpenMemory(memoryBackend)
+	if err != nil {
+		panic(err)
+	}
+	return NewLowlevel(b, "<memory>")
 }
 
 // ListFolders returns the list of folders currently in the database
@@ -93,64 +93,48 @@ func (db *Lowlevel) Committed() int64 {
 	return atomic.LoadInt64(&db.committed)
 }
 
-func (db *Lowlevel) Put(key, val []byte, wo *opt.WriteOptions) error {
+func (db *Lowlevel) Put(key, val []byte) error {
 	atomic.AddInt64(&db.committed, 1)
-	return db.DB.Put(key, val, wo)
+	return db.Backend.Put(key, val)
 }
 
-func (db *Lowlevel) Delete(key []byte, wo *opt.WriteOptions) error {
+func (db *Lowlevel) Delete(key []byte) error {
 	atomic.AddInt64(&db.committed, 1)
-	return db.DB.Delete(key, wo)
+	return db.Backend.Delete(key)
 }
 
-// NewLowlevel wraps the given *leveldb.DB into a *lowlevel
-func NewLowlevel(db *leveldb.DB, location string) *Lowlevel {
+// NewLowlevel wraps the given backend into a *Lowlevel
+func NewLowlevel(b backend.Backend, location string) *Lowlevel {
 	return &Lowlevel{
-		DB:        db,
+		Backend:   b,
 		location:  location,
-		folderIdx: newSmallIndex(db, []byte{KeyTypeFolderIdx}),
-		deviceIdx: newSmallIndex(db, []byte{KeyTypeDeviceIdx}),
-	}
-}
-
-// A "better" version of leveldb's errors.IsCorrupted.
-func leveldbIsCorrupted(err error) bool {
-	switch {
-	case err == nil:
-		return false
-
-	case errors.IsCorrupted(err):
-		return true
-
-	case strings.Contains(err.Error(), "corrupted"):
-		return true
+		folderIdx: newSmallIndex(b, []byte{KeyTypeFolderIdx}),
+		deviceIdx: newSmallIndex(b, []byte{KeyTypeDeviceIdx}),
 	}
-
-	return false
 }
 
 type batch struct {
-	*leveldb.Batch
+	backend.Batch
 	db *Lowlevel
 }
 
 func (db *Lowlevel) newBatch() *batch {
 	return &batch{
-		Batch: new(leveldb.Batch),
+		Batch: db.NewBatch(),
 		db:    db,
 	}
 }
 
 // checkFlush flushes and resets the batch if its size exceeds dbFlushBatch.
 func (b *batch) checkFlush() {
-	if len(b.Dump()) > dbFlushBatch {
+	if b.Size() > dbFlushBatch {
 		b.flush()
 		b.Reset()
 	}
 }
 
 func (b *batch) flush() {
-	if err := b.db.Write(b.Batch, nil); err != nil {
+	if err := b.Write(); err != nil {
 		panic(err)
 	}
 }
@@ -0,0 +1,71 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/db/backend"
+)
+
+// TestMain runs all tests, including those of the db_test package, once for
+// each backend.
+func TestMain(m *testing.M) {
+	exitCode := 0
+	for _, typ := range backend.Types {
+		memoryBackend = typ
+		if code := m.Run(); code != 0 {
+			exitCode = code
+		}
+	}
+	os.Exit(exitCode)
+}
+
+func TestOpenTypeMigrates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-db-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	location := filepath.Join(dir, "index")
+
+	db, err := OpenType(backend.TypeLevelDB, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = Open(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if typ, _ := backend.Detect(location); typ != backend.TypeLevelDB {
+		t.Fatalf("Open changed the backend to %v", typ)
+	}
+
+	db, err = OpenType(backend.TypeBolt, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if val, err := db.Get([]byte("key")); err != nil {
+		t.Fatal(err)
+	} else if string(val) != "value" {
+		t.Errorf("Got %q after migration, expected %q", val, "value")
+	}
+	if _, err := os.Stat(backend.Location(backend.TypeLevelDB, location)); !os.IsNotExist(err) {
+		t.Error("Old database still exists after migration:", err)
+	}
+}
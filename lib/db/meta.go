@@ -70,7 +70,7 @@ func (m *metadataTracker) toDB(db *instance, folder []byte) error {
 	if err != nil {
 		return err
 	}
-	err = db.Put(key, bs, nil)
+	err = db.Put(key, bs)
 	if err == nil {
 		m.dirty = false
 	}
@@ -82,7 +82,7 @@ func (m *metadataTracker) toDB(db *instance, folder []byte) error {
 // the database under the key corresponding to the given folder
 func (m *metadataTracker) fromDB(db *instance, folder []byte) error {
 	key := db.keyer.GenerateFolderMetaKey(nil, folder)
-	bs, err := db.Get(key, nil)
+	bs, err := db.Get(key)
 	if err != nil {
 		return err
 	}
@@ -145,6 +145,16 @@ func (m *metadataTracker) updateSeqLocked(dev protocol.DeviceID, f FileIntf) {
 	}
 }
 
+// raiseSequence sets the sequence of the device to seq, if it is lower.
+func (m *metadataTracker) raiseSequence(dev protocol.DeviceID, seq int64) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if cp := m.countsPtr(dev, 0); seq > cp.Sequence {
+		cp.Sequence = seq
+		m.dirty = true
+	}
+}
+
 func (m *metadataTracker) addFileLocked(dev protocol.DeviceID, flags uint32, f FileIntf) {
 	cp := m.countsPtr(dev, flags)
 
@@ -254,6 +264,63 @@ func (m *metadataTracker) resetCounts(dev protocol.DeviceID) {
 	m.mut.Unlock()
 }
 
+// repairCounts replaces the counts with the freshly calculated ones given,
+// if they differ, retaining the highest sequence numbers. It returns true if
+// the counts differed.
+func (m *metadataTracker) repairCounts(fresh *metadataTracker) bool {
+	fresh.mut.RLock()
+	defer fresh.mut.RUnlock()
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	differ := false
+	for key, idx := range m.indexes {
+		if !sameCounts(m.counts.Counts[idx], fresh.countsLocked(key)) {
+			differ = true
+		}
+	}
+	for key, idx := range fresh.indexes {
+		if !sameCounts(fresh.counts.Counts[idx], m.countsLocked(key)) {
+			differ = true
+		}
+	}
+	if !differ {
+		return false
+	}
+
+	old := m.counts.Counts
+	oldIndexes := m.indexes
+	m.counts.Counts = append([]Counts(nil), fresh.counts.Counts...)
+	m.indexes = make(map[metaKey]int, len(fresh.indexes))
+	for key, idx := range fresh.indexes {
+		m.indexes[key] = idx
+	}
+	for key, idx := range oldIndexes {
+		if seq := old[idx].Sequence; seq > m.countsPtr(key.dev, key.flags).Sequence {
+			m.countsPtr(key.dev, key.flags).Sequence = seq
+		}
+	}
+	m.counts.Created = time.Now().UnixNano()
+	m.dirty = true
+	return true
+}
+
+// countsLocked returns the counts for the given key, or zero counts. Must be
+// called with the mutex held.
+func (m *metadataTracker) countsLocked(key metaKey) Counts {
+	if idx, ok := m.indexes[key]; ok {
+		return m.counts.Counts[idx]
+	}
+	return Counts{}
+}
+
+// sameCounts returns true if the file, directory, etc. counts are equal,
+// disregarding the sequence number.
+func sameCounts(a, b Counts) bool {
+	return a.Files == b.Files && a.Directories == b.Directories && a.Symlinks == b.Symlinks &&
+		a.Deleted == b.Deleted && a.Bytes == b.Bytes
+}
+
 // Counts returns the counts for the given device ID and flag. `flag` should
 // be zero or have exactly one bit set.
 func (m *metadataTracker) Counts(dev protocol.DeviceID, flag uint32) Counts {
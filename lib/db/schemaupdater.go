@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	"github.com/syncthing/syncthing/lib/protocol"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // List of all dbVersion to dbMinSyncthingVersion pairs for convenience
@@ -94,7 +93,7 @@ func (db *schemaUpdater) updateSchema0to1() {
 	t := db.newReadWriteTransaction()
 	defer t.close()
 
-	dbi := t.NewIterator(util.BytesPrefix([]byte{KeyTypeDevice}), nil)
+	dbi := t.NewPrefixIterator([]byte{KeyTypeDevice})
 	defer dbi.Release()
 
 	symlinkConv := 0
@@ -277,7 +276,7 @@ func (db *schemaUpdater) updateSchema6to7() {
 			name := []byte(f.FileName())
 			global := f.(protocol.FileInfo)
 			gk = db.keyer.GenerateGlobalVersionKey(gk, folder, name)
-			svl, err := t.Get(gk, nil)
+			svl, err := t.Get(gk)
 			if err != nil {
 				// If there is no global list, we hardly need it.
 				t.Delete(t.keyer.GenerateNeedFileKey(nk, folder, name))
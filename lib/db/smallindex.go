@@ -10,16 +10,15 @@ import (
 	"encoding/binary"
 	"sort"
 
+	"github.com/syncthing/syncthing/lib/db/backend"
 	"github.com/syncthing/syncthing/lib/sync"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // A smallIndex is an in memory bidirectional []byte to uint32 map. It gives
 // fast lookups in both directions and persists to the database. Don't use for
 // storing more items than fit comfortably in RAM.
 type smallIndex struct {
-	db     *leveldb.DB
+	db     backend.Backend
 	prefix []byte
 	id2val map[uint32]string
 	val2id map[string]uint32
@@ -27,7 +26,7 @@ type smallIndex struct {
 	mut    sync.Mutex
 }
 
-func newSmallIndex(db *leveldb.DB, prefix []byte) *smallIndex {
+func newSmallIndex(db backend.Backend, prefix []byte) *smallIndex {
 	idx := &smallIndex{
 		db:     db,
 		prefix: prefix,
@@ -42,7 +41,7 @@ func newSmallIndex(db *leveldb.DB, prefix []byte) *smallIndex {
 // load iterates over the prefix space in the database and populates the in
 // memory maps.
 func (i *smallIndex) load() {
-	it := i.db.NewIterator(util.BytesPrefix(i.prefix), nil)
+	it := i.db.NewPrefixIterator(i.prefix)
 	defer it.Release()
 	for it.Next() {
 		val := string(it.Value())
@@ -82,7 +81,7 @@ func (i *smallIndex) ID(val []byte) uint32 {
 	key := make([]byte, len(i.prefix)+8) // prefix plus uint32 id
 	copy(key, i.prefix)
 	binary.BigEndian.PutUint32(key[len(i.prefix):], id)
-	i.db.Put(key, val, nil)
+	i.db.Put(key, val)
 
 	i.mut.Unlock()
 	return id
@@ -115,7 +114,7 @@ func (i *smallIndex) Delete(val []byte) {
 		// Put an empty value into the database. This indicates that the
 		// entry does not exist any more and prevents the ID from being
 		// reused in the future.
-		i.db.Put(key, []byte{}, nil)
+		i.db.Put(key, []byte{})
 
 		// Delete reverse mapping.
 		delete(i.id2val, id)
@@ -125,6 +124,29 @@ func (i *smallIndex) Delete(val []byte) {
 	delete(i.val2id, string(val))
 }
 
+// Rename moves the index number of from over to the value to, which must
+// not be in use, such that everything stored under the one is found under
+// the other.
+func (i *smallIndex) Rename(from, to []byte) {
+	i.mut.Lock()
+	defer i.mut.Unlock()
+
+	id, ok := i.val2id[string(from)]
+	if !ok {
+		return
+	}
+	delete(i.val2id, string(from))
+
+	toStr := string(to)
+	i.val2id[toStr] = id
+	i.id2val[id] = toStr
+
+	key := make([]byte, len(i.prefix)+8) // prefix plus uint32 id
+	copy(key, i.prefix)
+	binary.BigEndian.PutUint32(key[len(i.prefix):], id)
+	i.db.Put(key, to)
+}
+
 // Values returns the set of values in the index
 func (i *smallIndex) Values() []string {
 	// In principle this method should return [][]byte because all the other
@@ -11,15 +11,13 @@ import (
 	"io"
 	"os"
 
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/storage"
-	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/syncthing/syncthing/lib/db/backend"
 )
 
 // writeJSONS serializes the database to a JSON stream that can be checked
 // in to the repo and used for tests.
-func writeJSONS(w io.Writer, db *leveldb.DB) {
-	it := db.NewIterator(&util.Range{}, nil)
+func writeJSONS(w io.Writer, db backend.Backend) {
+	it := db.NewPrefixIterator(nil)
 	defer it.Release()
 	enc := json.NewEncoder(w)
 	for it.Next() {
@@ -34,15 +32,18 @@ func writeJSONS(w io.Writer, db *leveldb.DB) {
 // here and the linter to not complain.
 var _ = writeJSONS
 
-// openJSONS reads a JSON stream file into a leveldb.DB
-func openJSONS(file string) (*leveldb.DB, error) {
+// openJSONS reads a JSON stream file into an in-memory database
+func openJSONS(file string) (backend.Backend, error) {
 	fd, err := os.Open(file)
 	if err != nil {
 		return nil, err
 	}
 	dec := json.NewDecoder(fd)
 
-	db, _ := leveldb.Open(storage.NewMemStorage(), nil)
+	db, err := backend.OpenMemory(memoryBackend)
+	if err != nil {
+		return nil, err
+	}
 
 	for {
 		var row map[string][]byte
@@ -54,7 +55,7 @@ func openJSONS(file string) (*leveldb.DB, error) {
 			return nil, err
 		}
 
-		db.Put(row["k"], row["v"], nil)
+		db.Put(row["k"], row["v"])
 	}
 
 	return db, nil
@@ -94,7 +95,7 @@ func openJSONS(file string) (*leveldb.DB, error) {
 // 			Version: protocol.Vector{Counters: []protocol.Counter{{ID: 42, Value: 1002}}},
 // 		},
 // 	})
-// 	writeJSONS(os.Stdout, db.DB)
+// 	writeJSONS(os.Stdout, db.Backend)
 // }
 
 // TestGenerateUpdate0to3DB generates a database with files with invalid flags, prefixed
@@ -106,5 +107,5 @@ func openJSONS(file string) (*leveldb.DB, error) {
 // 	for devID, files := range haveUpdate0to3 {
 // 		fs.Update(devID, files)
 // 	}
-// 	writeJSONS(os.Stdout, db.DB)
+// 	writeJSONS(os.Stdout, db.Backend)
 // }
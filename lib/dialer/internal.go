@@ -23,6 +23,7 @@ var (
 	l           = logger.DefaultLogger.NewFacility("dialer", "Dialing connections")
 	proxyDialer proxy.Dialer
 	usingProxy  bool
+	envProxy    string
 	noFallback  = os.Getenv("ALL_PROXY_NO_FALLBACK") != ""
 )
 
@@ -36,6 +37,10 @@ func init() {
 	usingProxy = proxyDialer != proxy.Direct
 
 	if usingProxy {
+		if u, err := url.Parse(os.Getenv("all_proxy")); err == nil {
+			envProxy = redactProxy(u)
+		}
+
 		http.DefaultTransport = &http.Transport{
 			Dial:                Dial,
 			Proxy:               http.ProxyFromEnvironment,
@@ -64,7 +69,7 @@ func dialWithFallback(proxyDialFunc dialFunc, fallbackDialFunc dialFunc, network
 		l.Debugf("Dialing %s address %s via proxy - success, %s -> %s", network, addr, conn.LocalAddr(), conn.RemoteAddr())
 		SetTCPOptions(conn)
 		return dialerConn{
-			conn, newDialerAddr(network, addr),
+			conn, newDialerAddr(network, addr), envProxy,
 		}, nil
 	}
 	l.Debugf("Dialing %s address %s via proxy - error %s", network, addr, err)
@@ -133,7 +138,8 @@ func (d *timeoutDirectDialer) Dial(network, addr string) (net.Conn, error) {
 
 type dialerConn struct {
 	net.Conn
-	addr net.Addr
+	addr  net.Addr
+	proxy string
 }
 
 func (c dialerConn) RemoteAddr() net.Addr {
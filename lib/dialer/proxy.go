@@ -0,0 +1,215 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package dialer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// NoProxy is the proxy URL that dials directly, regardless of the
+// configured proxy and the environment.
+const NoProxy = "direct"
+
+var (
+	configuredProxy    *url.URL
+	configuredProxyMut sync.RWMutex
+)
+
+// SetProxy sets the proxy that Dial and DialTimeout use, instead of the one
+// in the environment. The URL is either socks5://[user:pass@]host:port or
+// http://[user:pass@]host:port for a proxy that takes CONNECT requests.
+// Unlike with the environment, there is no falling back to dialing
+// directly, as networks that need a configured proxy don't allow that. An
+// empty URL goes back to using the environment.
+func SetProxy(proxyURL string) error {
+	var u *url.URL
+	if proxyURL != "" {
+		var err error
+		if u, err = parseProxy(proxyURL); err != nil {
+			return err
+		}
+		if u == nil {
+			return errors.New("proxy: direct is not a proxy")
+		}
+	}
+
+	configuredProxyMut.Lock()
+	configuredProxy = u
+	configuredProxyMut.Unlock()
+	return nil
+}
+
+// Proxy returns the proxy that Dial and DialTimeout use, without the
+// credentials, or the empty string if they dial directly.
+func Proxy() string {
+	configuredProxyMut.RLock()
+	u := configuredProxy
+	configuredProxyMut.RUnlock()
+	if u != nil {
+		return redactProxy(u)
+	}
+	if usingProxy {
+		return envProxy
+	}
+	return ""
+}
+
+// ValidateProxy returns an error if the proxy URL isn't one we can dial
+// through. The empty string and NoProxy are valid.
+func ValidateProxy(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	_, err := parseProxy(proxyURL)
+	return err
+}
+
+// DialTimeoutProxy dials through the given proxy rather than the configured
+// one, or directly when the proxy is NoProxy. The empty string means the
+// same as DialTimeout.
+func DialTimeoutProxy(proxyURL, network, addr string, timeout time.Duration) (net.Conn, error) {
+	if proxyURL == "" {
+		return DialTimeout(network, addr, timeout)
+	}
+	u, err := parseProxy(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return net.DialTimeout(network, addr, timeout)
+	}
+	return dialProxy(u, network, addr, timeout)
+}
+
+// ProxyOf returns the proxy the connection was dialed through, without the
+// credentials, or the empty string for direct connections.
+func ProxyOf(conn net.Conn) string {
+	if conn, ok := conn.(dialerConn); ok {
+		return conn.proxy
+	}
+	return ""
+}
+
+func currentProxy() *url.URL {
+	configuredProxyMut.RLock()
+	defer configuredProxyMut.RUnlock()
+	return configuredProxy
+}
+
+// parseProxy returns the proxy URL, or nil for NoProxy.
+func parseProxy(proxyURL string) (*url.URL, error) {
+	if proxyURL == NoProxy {
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "socks", "socks5", "http":
+	default:
+		return nil, fmt.Errorf("proxy: unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("proxy: missing host")
+	}
+	return u, nil
+}
+
+func dialProxy(u *url.URL, network, addr string, timeout time.Duration) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	switch u.Scheme {
+	case "http":
+		conn, err = dialHTTPConnect(u, network, addr, timeout)
+	default:
+		var d proxy.Dialer
+		d, err = socksDialerFunction(u, &timeoutDirectDialer{timeout: timeout})
+		if err == nil {
+			conn, err = d.Dial(network, addr)
+		}
+	}
+	if err != nil {
+		l.Debugf("Dialing %s address %s via proxy %s - error %s", network, addr, redactProxy(u), err)
+		return nil, err
+	}
+
+	l.Debugf("Dialing %s address %s via proxy %s - success, %s -> %s", network, addr, redactProxy(u), conn.LocalAddr(), conn.RemoteAddr())
+	SetTCPOptions(conn)
+	return dialerConn{conn, newDialerAddr(network, addr), redactProxy(u)}, nil
+}
+
+// dialHTTPConnect asks the HTTP proxy to connect us to the address, with
+// basic authentication if the proxy URL has credentials.
+func dialHTTPConnect(u *url.URL, network, addr string, timeout time.Duration) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("proxy: unsupported network %q", network)
+	}
+
+	conn, err := net.DialTimeout("tcp", u.Host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy: CONNECT %s: %s", addr, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		// We're always the first to talk, so there shouldn't be anything
+		// yet, and we can't hand out what's been read ahead.
+		conn.Close()
+		return nil, errors.New("proxy: unexpected data after CONNECT response")
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func redactProxy(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	return redacted.String()
+}
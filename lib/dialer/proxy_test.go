@@ -0,0 +1,200 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package dialer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// echoServer returns the address of a server that echoes what it's sent.
+func echoServer(t *testing.T) string {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := lst.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return lst.Addr().String()
+}
+
+// fakeProxy returns the address of a proxy server, which handles each
+// connection with the given function.
+func fakeProxy(t *testing.T, handle func(conn net.Conn)) string {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := lst.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return lst.Addr().String()
+}
+
+func pipe(a, b net.Conn) {
+	go io.Copy(a, b)
+	io.Copy(b, a)
+	a.Close()
+	b.Close()
+}
+
+// httpConnectProxy handles CONNECT requests with the given credentials.
+func httpConnectProxy(conn net.Conn) {
+	req, err := http.ReadRequest(bufio.NewReader(conn))
+	if err != nil || req.Method != "CONNECT" {
+		conn.Close()
+		return
+	}
+	if user, pass, ok := (&http.Request{Header: http.Header{"Authorization": req.Header["Proxy-Authorization"]}}).BasicAuth(); !ok || user != "user" || pass != "secret" {
+		io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		conn.Close()
+		return
+	}
+	target, err := net.Dial("tcp", req.Host)
+	if err != nil {
+		io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		conn.Close()
+		return
+	}
+	io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+	pipe(conn, target)
+}
+
+// socks5Proxy handles SOCKS5 connect requests to IPv4 addresses, with
+// username and password authentication.
+func socks5Proxy(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, 512)
+
+	// Greeting, choosing username and password authentication
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 2})
+
+	// Username and password
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	user := make([]byte, buf[1])
+	io.ReadFull(conn, user)
+	io.ReadFull(conn, buf[:1])
+	pass := make([]byte, buf[0])
+	io.ReadFull(conn, pass)
+	if string(user) != "user" || string(pass) != "secret" {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	// Connect request
+	if _, err := io.ReadFull(conn, buf[:10]); err != nil || buf[1] != 1 || buf[3] != 1 {
+		return
+	}
+	addr := net.JoinHostPort(net.IP(buf[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(buf[8:10]))))
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+	pipe(conn, target)
+}
+
+func TestDialProxy(t *testing.T) {
+	echo := echoServer(t)
+	httpProxy := fakeProxy(t, httpConnectProxy)
+	socksProxy := fakeProxy(t, socks5Proxy)
+
+	cases := []struct {
+		proxy string
+		ok    bool
+	}{
+		{"http://user:secret@" + httpProxy, true},
+		{"http://user:wrong@" + httpProxy, false},
+		{"socks5://user:secret@" + socksProxy, true},
+		{"socks5://user:wrong@" + socksProxy, false},
+		{NoProxy, true},
+	}
+
+	for _, tc := range cases {
+		conn, err := DialTimeoutProxy(tc.proxy, "tcp", echo, time.Second)
+		if !tc.ok {
+			if err == nil {
+				conn.Close()
+				t.Errorf("Dialing via %s should fail", tc.proxy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Dialing via %s: %v", tc.proxy, err)
+			continue
+		}
+
+		if _, err := conn.Write([]byte("hello")); err != nil {
+			t.Error(err)
+		}
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hello" {
+			t.Errorf("Unexpected echo via %s: %q %v", tc.proxy, buf, err)
+		}
+		if tc.proxy != NoProxy && ProxyOf(conn) == tc.proxy {
+			t.Error("Credentials should be redacted")
+		}
+		conn.Close()
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	echo := echoServer(t)
+	httpProxy := fakeProxy(t, httpConnectProxy)
+
+	if err := SetProxy("ftp://" + httpProxy); err == nil {
+		t.Error("Unsupported proxy should not be set")
+	}
+	if err := SetProxy("http://user:secret@" + httpProxy); err != nil {
+		t.Fatal(err)
+	}
+	defer SetProxy("")
+
+	if p := Proxy(); p != "http://"+httpProxy {
+		t.Error("Unexpected proxy", p)
+	}
+	conn, err := DialTimeout("tcp", echo, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if p := ProxyOf(conn); p != "http://"+httpProxy {
+		t.Error("Connection should be via the proxy, not", p)
+	}
+}
@@ -17,7 +17,12 @@ import (
 
 // Dial tries dialing via proxy if a proxy is configured, and falls back to
 // a direct connection if no proxy is defined, or connecting via proxy fails.
+// A proxy set with SetProxy takes precedence over the environment, and
+// isn't fallen back from.
 func Dial(network, addr string) (net.Conn, error) {
+	if u := currentProxy(); u != nil {
+		return dialProxy(u, network, addr, 0)
+	}
 	if usingProxy {
 		return dialWithFallback(proxyDialer.Dial, net.Dial, network, addr)
 	}
@@ -28,8 +33,11 @@ func Dial(network, addr string) (net.Conn, error) {
 // and falls back to a direct connection if no proxy is defined, or connecting
 // via proxy fails. The timeout can potentially be applied twice, once trying
 // to connect via the proxy connection, and second time trying to connect
-// directly.
+// directly. As with Dial, a proxy set with SetProxy is used instead.
 func DialTimeout(network, addr string, timeout time.Duration) (net.Conn, error) {
+	if u := currentProxy(); u != nil {
+		return dialProxy(u, network, addr, timeout)
+	}
 	if usingProxy {
 		// Because the proxy package is poorly structured, we have to
 		// construct a struct that matches proxy.Dialer but has a timeout
@@ -0,0 +1,435 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/sync"
+	"github.com/syncthing/syncthing/lib/util"
+)
+
+const (
+	// Learned addresses are forgotten when they haven't been shared again
+	// for this long, while imported ones are kept until replaced.
+	maxLearnedAddressAge = 24 * time.Hour
+	// Learning the same addresses again is only written to disk this often.
+	learnedAddressRefresh = time.Hour
+)
+
+// An AddressBook is a finder for the addresses of devices that we got out of
+// band. Those are imported from address book files signed by trusted
+// devices, for networks where neither global nor local discovery work, and
+// learned from our introducers, which share the addresses they know over
+// BEP.
+type AddressBook struct {
+	path     string
+	cert     tls.Certificate
+	myID     protocol.DeviceID
+	addrList AddressLister
+
+	entries map[protocol.DeviceID]AddressBookEntry
+	mut     sync.Mutex
+}
+
+// An AddressBookEntry is what the address book knows about a device.
+type AddressBookEntry struct {
+	Addresses []string          `json:"addresses"`
+	Source    protocol.DeviceID `json:"source"`   // the device vouching for the addresses
+	Imported  bool              `json:"imported"` // from a file, rather than learned over BEP
+	Updated   time.Time         `json:"updated"`
+}
+
+// A SignedAddressBook is the content of an address book file, along with
+// the device that signed it.
+type SignedAddressBook struct {
+	Signer  protocol.DeviceID              `json:"-"`
+	Created time.Time                      `json:"created"`
+	Devices map[protocol.DeviceID][]string `json:"devices"`
+}
+
+func (b SignedAddressBook) MarshalJSON() ([]byte, error) {
+	devices := make(map[string][]string, len(b.Devices))
+	for device, addrs := range b.Devices {
+		devices[device.String()] = addrs
+	}
+	return json.Marshal(struct {
+		Created time.Time           `json:"created"`
+		Devices map[string][]string `json:"devices"`
+	}{b.Created, devices})
+}
+
+// The addressBookFile is what's written to address book files. The address
+// book is kept as the signed bytes, as encoding it again wouldn't
+// necessarily give the same ones. The certificate is that of the signing
+// device, which has the device ID as its hash.
+type addressBookFile struct {
+	Certificate []byte `json:"certificate"`
+	AddressBook []byte `json:"addressBook"`
+	Signature   []byte `json:"signature"`
+}
+
+// NewAddressBook returns an address book kept in the file at path. The
+// certificate is used to sign the exported address books, which include
+// the addresses from the address lister.
+func NewAddressBook(path string, cert tls.Certificate, addrList AddressLister) *AddressBook {
+	b := &AddressBook{
+		path:     path,
+		cert:     cert,
+		myID:     protocol.NewDeviceID(cert.Certificate[0]),
+		addrList: addrList,
+		entries:  make(map[protocol.DeviceID]AddressBookEntry),
+		mut:      sync.NewMutex(),
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(bs, &b.entries)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		l.Warnln("Loading address book:", err)
+	}
+
+	return b
+}
+
+// Lookup returns the addresses in the address book for the device.
+func (b *AddressBook) Lookup(device protocol.DeviceID) (addresses []string, err error) {
+	return b.Addresses(device), nil
+}
+
+func (b *AddressBook) String() string {
+	return "address book"
+}
+
+func (b *AddressBook) Error() error {
+	return nil
+}
+
+func (b *AddressBook) Cache() map[protocol.DeviceID]CacheEntry {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	res := make(map[protocol.DeviceID]CacheEntry, len(b.entries))
+	for device, entry := range b.entries {
+		if !entry.current() {
+			continue
+		}
+		res[device] = CacheEntry{
+			Addresses: entry.Addresses,
+			when:      entry.Updated,
+			found:     true,
+		}
+	}
+	return res
+}
+
+// Entries returns the contents of the address book.
+func (b *AddressBook) Entries() map[protocol.DeviceID]AddressBookEntry {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	res := make(map[protocol.DeviceID]AddressBookEntry, len(b.entries))
+	for device, entry := range b.entries {
+		if entry.current() {
+			res[device] = entry
+		}
+	}
+	return res
+}
+
+// Addresses returns the addresses in the address book for the device, if
+// any.
+func (b *AddressBook) Addresses(device protocol.DeviceID) []string {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	entry, ok := b.entries[device]
+	if !ok || !entry.current() {
+		return nil
+	}
+	return append([]string(nil), entry.Addresses...)
+}
+
+// Import adds the addresses in the address book, which must have been
+// verified and found trustworthy, replacing those from older ones. It
+// returns the number of devices whose addresses were added.
+func (b *AddressBook) Import(book SignedAddressBook) (int, error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	imported := 0
+	for device, addrs := range book.Devices {
+		if device == b.myID || len(addrs) == 0 {
+			continue
+		}
+		if cur, ok := b.entries[device]; ok && cur.Imported && cur.Updated.After(book.Created) {
+			continue
+		}
+		b.entries[device] = AddressBookEntry{
+			Addresses: util.UniqueStrings(addrs),
+			Source:    book.Signer,
+			Imported:  true,
+			Updated:   book.Created,
+		}
+		imported++
+	}
+
+	if imported == 0 {
+		return 0, nil
+	}
+	return imported, b.saveLocked()
+}
+
+// Learn adds the addresses shared by a trusted device, as far as we may
+// dial them. They don't replace the imported ones, which are what the
+// administrator asked for, and are forgotten unless shared again within
+// maxLearnedAddressAge.
+func (b *AddressBook) Learn(from, device protocol.DeviceID, addresses []string) {
+	if device == b.myID {
+		return
+	}
+	usable := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		if UsableSharedAddress(addr) {
+			usable = append(usable, addr)
+		}
+	}
+	if len(usable) == 0 {
+		return
+	}
+	addresses = util.UniqueStrings(usable)
+
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	cur, ok := b.entries[device]
+	if ok && cur.Imported {
+		return
+	}
+	if ok && cur.Source == from && equalStrings(cur.Addresses, addresses) && time.Since(cur.Updated) < learnedAddressRefresh {
+		// Not worth writing to disk just for the time.
+		return
+	}
+	b.entries[device] = AddressBookEntry{
+		Addresses: addresses,
+		Source:    from,
+		Updated:   time.Now(),
+	}
+
+	l.Debugf("discover: Learned addresses %v for %s from %s", addresses, device, from)
+	if err := b.saveLocked(); err != nil {
+		l.Warnln("Saving address book:", err)
+	}
+}
+
+// current returns whether the entry is to be used: imported or recently
+// learned.
+func (e AddressBookEntry) current() bool {
+	return e.Imported || time.Since(e.Updated) < maxLearnedAddressAge
+}
+
+// Export returns an address book file with the addresses of the devices
+// and our own, signed by us.
+func (b *AddressBook) Export(devices map[protocol.DeviceID][]string) ([]byte, error) {
+	ips := interfaceIPs()
+	book := SignedAddressBook{
+		Created: time.Now(),
+		Devices: make(map[protocol.DeviceID][]string, len(devices)+1),
+	}
+	for device, addrs := range devices {
+		if addrs = expandUnspecified(addrs, ips); len(addrs) > 0 {
+			book.Devices[device] = addrs
+		}
+	}
+	book.Devices[b.myID] = expandUnspecified(b.addrList.AllAddresses(), ips)
+
+	return signAddressBook(b.cert, book)
+}
+
+func (b *AddressBook) saveLocked() error {
+	// Keyed by string and pointing at the entries, for the device IDs to
+	// be marshalled as text. Expired learned entries are dropped.
+	entries := make(map[string]*AddressBookEntry, len(b.entries))
+	for device, entry := range b.entries {
+		if !entry.current() {
+			delete(b.entries, device)
+			continue
+		}
+		entry := entry
+		entries[device.String()] = &entry
+	}
+	bs, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return err
+	}
+	fd, err := osutil.CreateAtomic(b.path)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(bs); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+func signAddressBook(cert tls.Certificate, book SignedAddressBook) ([]byte, error) {
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+	bs, err := json.Marshal(book)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(bs)
+	sig, err := signer.Sign(rand.Reader, hash[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(addressBookFile{
+		Certificate: cert.Certificate[0],
+		AddressBook: bs,
+		Signature:   sig,
+	}, "", "    ")
+}
+
+// ReadAddressBook returns the address book in the file, after checking the
+// signature. It's up to the caller to decide whether the signer is to be
+// trusted.
+func ReadAddressBook(bs []byte) (SignedAddressBook, error) {
+	var file addressBookFile
+	if err := json.Unmarshal(bs, &file); err != nil {
+		return SignedAddressBook{}, err
+	}
+	cert, err := x509.ParseCertificate(file.Certificate)
+	if err != nil {
+		return SignedAddressBook{}, err
+	}
+
+	var algo x509.SignatureAlgorithm
+	switch cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		algo = x509.ECDSAWithSHA256
+	case *rsa.PublicKey:
+		algo = x509.SHA256WithRSA
+	default:
+		return SignedAddressBook{}, errors.New("unsupported public key")
+	}
+	if err := cert.CheckSignature(algo, file.AddressBook, file.Signature); err != nil {
+		return SignedAddressBook{}, err
+	}
+
+	var book SignedAddressBook
+	if err := json.Unmarshal(file.AddressBook, &book); err != nil {
+		return SignedAddressBook{}, err
+	}
+	book.Signer = protocol.NewDeviceID(file.Certificate)
+	return book, nil
+}
+
+// UsableSharedAddress returns true if the address, as shared by another
+// device, is one we may dial: a TCP or relay URI with an IP address that's
+// neither loopback, link-local, multicast nor unspecified. Other devices
+// can't point us at ourselves or at whatever is on our own link.
+func UsableSharedAddress(addr string) bool {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6", "relay":
+	default:
+		return false
+	}
+	host, _, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// expandUnspecified returns the addresses with the unspecified ones, like
+// tcp://0.0.0.0:22000, replaced by one for each of the IPs. Others can't
+// tell what those are without seeing where they came from, as they do for
+// announcements.
+func expandUnspecified(addrs []string, ips []net.IP) []string {
+	var res []string
+	for _, addr := range addrs {
+		u, err := url.Parse(addr)
+		if err != nil {
+			continue
+		}
+		host, port, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			res = append(res, addr)
+			continue
+		}
+		ip := net.ParseIP(host)
+		if host != "" && !ip.IsUnspecified() {
+			res = append(res, addr)
+			continue
+		}
+		for _, local := range ips {
+			if ip != nil && ip.To4() != nil && local.To4() == nil {
+				// Listening on IPv4 only.
+				continue
+			}
+			eu := *u
+			eu.Host = net.JoinHostPort(local.String(), port)
+			res = append(res, eu.String())
+		}
+	}
+	return util.UniqueStrings(res)
+}
+
+// interfaceIPs returns the addresses of this host that others could
+// connect to.
+func interfaceIPs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		ips = append(ips, ipnet.IP)
+	}
+	return ips
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
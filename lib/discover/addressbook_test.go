@@ -0,0 +1,141 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/tlsutil"
+)
+
+func TestAddressBook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-addressbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certA, err := tlsutil.NewCertificate(filepath.Join(dir, "a-cert.pem"), filepath.Join(dir, "a-key.pem"), "syncthing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certB, err := tlsutil.NewCertificate(filepath.Join(dir, "b-cert.pem"), filepath.Join(dir, "b-key.pem"), "syncthing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idA := protocol.NewDeviceID(certA.Certificate[0])
+	other, _ := protocol.DeviceIDFromString("AIR6LPZ-7K4PTTV-UXQSMUU-CPQ5YWH-OEDFIIQ-JUG777G-2YQXXR5-YD6AWQR")
+
+	// Device A exports what it knows, which B imports.
+
+	a := NewAddressBook(filepath.Join(dir, "a.json"), certA, &fakeAddressLister{})
+	bs, err := a.Export(map[protocol.DeviceID][]string{
+		other: {"tcp://192.0.2.42:22000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := ReadAddressBook(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Signer != idA {
+		t.Error("Unexpected signer", book.Signer)
+	}
+	if addrs := book.Devices[idA]; len(addrs) == 0 || addrs[len(addrs)-1] != "tcp://192.168.0.1:22000" {
+		t.Error("Unexpected own addresses", addrs)
+	}
+
+	bPath := filepath.Join(dir, "b.json")
+	b := NewAddressBook(bPath, certB, &fakeAddressLister{})
+	if n, err := b.Import(book); err != nil || n != 2 {
+		t.Fatal("Unexpected import", n, err)
+	}
+	if addrs, _ := b.Lookup(other); len(addrs) != 1 || addrs[0] != "tcp://192.0.2.42:22000" {
+		t.Error("Unexpected addresses", addrs)
+	}
+
+	// Tampering with the file breaks the signature.
+
+	var file addressBookFile
+	if err := json.Unmarshal(bs, &file); err != nil {
+		t.Fatal(err)
+	}
+	file.AddressBook = bytes.Replace(file.AddressBook, []byte("192.0.2.42"), []byte("192.0.2.66"), 1)
+	tampered, _ := json.Marshal(file)
+	if _, err := ReadAddressBook(tampered); err == nil {
+		t.Error("Tampered address book should not be valid")
+	}
+
+	// Learned addresses don't replace the imported ones, but are kept for
+	// other devices, also across restarts.
+
+	third := protocol.LocalDeviceID
+	b.Learn(idA, other, []string{"tcp://192.0.2.99:22000"})
+	b.Learn(idA, third, []string{"tcp://192.0.2.100:22000"})
+	if bs, err := ioutil.ReadFile(bPath); err != nil || !bytes.Contains(bs, []byte(idA.String())) {
+		t.Error("The saved address book should name the source by ID", err)
+	}
+	b = NewAddressBook(bPath, certB, &fakeAddressLister{})
+	if addrs := b.Addresses(other); len(addrs) != 1 || addrs[0] != "tcp://192.0.2.42:22000" {
+		t.Error("Imported addresses should remain, not", addrs)
+	}
+	if entry := b.Entries()[third]; entry.Imported || entry.Source != idA || len(entry.Addresses) != 1 {
+		t.Errorf("Unexpected learned entry %+v", entry)
+	}
+	if _, ok := b.Cache()[third]; !ok {
+		t.Error("Learned device should be in the cache")
+	}
+
+	// Only addresses we may dial are learned, and only for a while.
+
+	b.Learn(idA, third, []string{"tcp://127.0.0.1:22000", "tcp://[fe80::1]:22000", "quic://192.0.2.101:22000", "tcp://192.0.2.102:22000"})
+	if addrs := b.Addresses(third); len(addrs) != 1 || addrs[0] != "tcp://192.0.2.102:22000" {
+		t.Error("Unexpected learned addresses", addrs)
+	}
+	b.mut.Lock()
+	entry := b.entries[third]
+	entry.Updated = entry.Updated.Add(-maxLearnedAddressAge)
+	b.entries[third] = entry
+	b.mut.Unlock()
+	if addrs := b.Addresses(third); len(addrs) != 0 {
+		t.Error("Expired addresses should be forgotten, not", addrs)
+	}
+	if _, ok := b.Cache()[third]; ok {
+		t.Error("Expired device should not be in the cache")
+	}
+	if addrs := b.Addresses(other); len(addrs) != 1 {
+		t.Error("Imported addresses don't expire, but got", addrs)
+	}
+}
+
+func TestExpandUnspecified(t *testing.T) {
+	ips := []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")}
+	addrs := expandUnspecified([]string{"tcp://:22000", "tcp://0.0.0.0:22001", "relay://192.0.2.9:22067/?id=x"}, ips)
+	expected := []string{
+		"relay://192.0.2.9:22067/?id=x",
+		"tcp://192.0.2.1:22000",
+		"tcp://192.0.2.1:22001",
+		"tcp://[2001:db8::1]:22000",
+	}
+	if len(addrs) != len(expected) {
+		t.Fatal("Unexpected addresses", addrs)
+	}
+	for i := range addrs {
+		if addrs[i] != expected[i] {
+			t.Errorf("Address %d is %s, expected %s", i, addrs[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	// The prefix of the TXT records holding the addresses of a device, the
+	// same as in the mDNS announcements.
+	dnsAddrPrefix = "addr="
+
+	dnsLookupTimeout = 5 * time.Second
+)
+
+// The dnsResolver is the part of a *net.Resolver we use, so that tests can
+// answer for it.
+type dnsResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+type dnsClient struct {
+	domain   string
+	resolver dnsResolver
+}
+
+// NewDNS returns a finder that looks up the addresses of devices in the DNS,
+// under a name made of the device ID and the domain, like
+// <device id>.sync.example.com. The addresses are taken from TXT records
+// like "addr=tcp://192.0.2.42:22000" at that name, and from the SRV
+// records for _syncthing._tcp.<device id>.sync.example.com which give TCP
+// addresses. This lets managed fleets publish their devices in their own
+// DNS, without a global discovery server.
+func NewDNS(domain string) Finder {
+	return &dnsClient{
+		domain:   strings.Trim(domain, "."),
+		resolver: net.DefaultResolver,
+	}
+}
+
+// DNSName returns the name under the domain holding the records for the
+// device.
+func DNSName(device protocol.DeviceID, domain string) string {
+	return strings.ToLower(device.String()) + "." + strings.Trim(domain, ".")
+}
+
+// Lookup returns the addresses in the TXT and SRV records for the device.
+func (c *dnsClient) Lookup(device protocol.DeviceID) (addresses []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	name := DNSName(device, c.domain)
+
+	txts, txtErr := c.resolver.LookupTXT(ctx, name)
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, dnsAddrPrefix) {
+			addresses = append(addresses, strings.TrimPrefix(txt, dnsAddrPrefix))
+		}
+	}
+
+	_, srvs, srvErr := c.resolver.LookupSRV(ctx, "syncthing", "tcp", name)
+	for _, srv := range srvs {
+		host := strings.TrimSuffix(srv.Target, ".")
+		addresses = append(addresses, "tcp://"+net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+	}
+
+	l.Debugln("dnsClient.Lookup", name, addresses, txtErr, srvErr)
+
+	if len(addresses) > 0 {
+		return addresses, nil
+	}
+	if isDNSNotFound(txtErr) && isDNSNotFound(srvErr) {
+		return nil, lookupError{
+			error:    errors.New("not found"),
+			cacheFor: time.Minute,
+		}
+	}
+	if txtErr != nil {
+		return nil, txtErr
+	}
+	return nil, srvErr
+}
+
+func (c *dnsClient) String() string {
+	return "DNS discovery (" + c.domain + ")"
+}
+
+func (c *dnsClient) Error() error {
+	return nil
+}
+
+func (c *dnsClient) Cache() map[protocol.DeviceID]CacheEntry {
+	// The dnsClient doesn't do caching
+	return nil
+}
+
+// isDNSNotFound returns whether the error says that there are no records,
+// rather than that the lookup failed. Having no error at all counts as
+// well, as it means we got an answer without addresses in it.
+func isDNSNotFound(err error) bool {
+	if err == nil {
+		return true
+	}
+	dnsErr, ok := err.(*net.DNSError)
+	return ok && dnsErr.IsNotFound
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+type fakeResolver struct {
+	txt map[string][]string
+	srv map[string][]*net.SRV
+}
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txt, ok := r.txt[name]; ok {
+		return txt, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	name = "_" + service + "._" + proto + "." + name
+	if srv, ok := r.srv[name]; ok {
+		return name, srv, nil
+	}
+	return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestDNSLookup(t *testing.T) {
+	device, _ := protocol.DeviceIDFromString("AIR6LPZ-7K4PTTV-UXQSMUU-CPQ5YWH-OEDFIIQ-JUG777G-2YQXXR5-YD6AWQR")
+	name := "air6lpz-7k4pttv-uxqsmuu-cpq5ywh-oedfiiq-jug777g-2yqxxr5-yd6awqr.sync.example.com"
+	if n := DNSName(device, "sync.example.com."); n != name {
+		t.Fatal("Unexpected name", n)
+	}
+
+	c := NewDNS("sync.example.com").(*dnsClient)
+	c.resolver = fakeResolver{
+		txt: map[string][]string{
+			name: {"addr=tcp://192.0.2.42:22000", "v=spf1 -all", "addr=relay://192.0.2.43:22067"},
+		},
+		srv: map[string][]*net.SRV{
+			"_syncthing._tcp." + name: {{Target: "host.example.com.", Port: 22001}},
+		},
+	}
+
+	addrs, err := c.Lookup(device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"tcp://192.0.2.42:22000", "relay://192.0.2.43:22067", "tcp://host.example.com:22001"}
+	if len(addrs) != len(expected) {
+		t.Fatal("Unexpected addresses", addrs)
+	}
+	for i := range addrs {
+		if addrs[i] != expected[i] {
+			t.Errorf("Address %d is %s, expected %s", i, addrs[i], expected[i])
+		}
+	}
+
+	// Devices without records aren't found, and the caching mux shouldn't
+	// ask again right away.
+
+	_, err = c.Lookup(protocol.LocalDeviceID)
+	if _, ok := err.(cachedError); !ok {
+		t.Error("Expected a cached error, not", err)
+	}
+}
@@ -71,5 +71,43 @@ Found) is returned.
 If the client has exceeded a rate limit, the server may respond with 429 (Too
 Many Requests).
 
+DNS Discovery
+=============
+
+Devices can also be looked up in the DNS, under a domain that's configured
+in the options. The records for a device are at the lower case device ID
+under the domain, i.e. abc12345-....sync.example.com. Each TXT record there
+that starts with "addr=" holds an address of the device, as in
+
+	abc12345-....sync.example.com. 300 IN TXT "addr=tcp://192.0.2.45:22000"
+
+and each SRV record for _syncthing._tcp.abc12345-....sync.example.com gives
+a TCP address. Devices don't announce themselves in the DNS; the records
+are managed by the administrator, or generated from the database of a
+discovery server with "stdiscosrv -dns-zone sync.example.com".
+
+Address Book
+============
+
+For networks without any of the above, addresses can be carried around in
+address book files. A device exports the addresses it knows, and its own,
+as a JSON object with its certificate, the address book and a signature
+of it by the device key. The address book is imported by devices that
+trust the signer, i.e. that have it as an introducer. Introducers also
+share the addresses in their address book with the devices they're
+connected to, in the cluster config, and those devices add them to
+theirs.
+
+Address Gossip
+==============
+
+Connected devices also tell each other where they see the devices they
+have in common, i.e. the address each such device connected from or was
+dialed at. These gossiped addresses aren't kept by the discovery finders;
+the connections service asks the model for them and tries them after the
+discovered ones, for as long as the gossiping device stays connected.
+Devices can be configured to keep their observed address to themselves,
+or to not use the addresses gossiped by another.
+
 */
 package discover
@@ -210,7 +210,10 @@ func (c *localClient) recvAnnouncements(b beacon.Interface) {
 	}
 }
 
-func (c *localClient) registerDevice(src net.Addr, device Announce) bool {
+// registerDevice caches the addresses of a device announced on the local
+// network, returning true if it's new. It's shared by the local and mDNS
+// discovery.
+func (c *cache) registerDevice(src net.Addr, device Announce) bool {
 	// Remember whether we already had a valid cache entry for this device.
 	// If the instance ID has changed the remote device has restarted since
 	// we last heard from it, so we should treat it as a new device.
@@ -0,0 +1,321 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/beacon"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/rand"
+	"github.com/thejerf/suture"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	MDNSIPv4Addr = "224.0.0.251:5353"
+	MDNSIPv6Addr = "[ff02::fb]:5353"
+
+	// The DNS-SD service type we announce as and browse for. Each device
+	// is an instance named by its device ID, with its addresses in the
+	// TXT record.
+	mdnsService = "_syncthing._tcp.local."
+
+	// How often we ask for devices we don't know the addresses of.
+	mdnsQueryInterval = 10 * time.Second
+)
+
+type mdnsClient struct {
+	*suture.Supervisor
+	myID       protocol.DeviceID
+	addrList   AddressLister
+	name       string
+	ipv4       bool
+	instanceID int64
+
+	beacon         beacon.Interface
+	forcedAnnounce chan struct{}
+	query          chan struct{}
+	stop           chan struct{}
+
+	*cache
+}
+
+// NewMDNS returns a finder announcing and resolving devices using mDNS and
+// DNS-SD, as an alternative to the local discovery for networks that
+// only let mDNS through. The addr is MDNSIPv4Addr or MDNSIPv6Addr.
+func NewMDNS(id protocol.DeviceID, addr string, addrList AddressLister) (FinderService, error) {
+	gaddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &mdnsClient{
+		Supervisor: suture.New("mdns", suture.Spec{
+			PassThroughPanics: true,
+		}),
+		myID:           id,
+		addrList:       addrList,
+		ipv4:           gaddr.IP.To4() != nil,
+		instanceID:     rand.Int63(),
+		beacon:         beacon.NewMulticastFromGroupPort(addr),
+		forcedAnnounce: make(chan struct{}, 1),
+		query:          make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+		cache:          newCache(),
+	}
+	if c.ipv4 {
+		c.name = "IPv4 mDNS"
+	} else {
+		c.name = "IPv6 mDNS"
+	}
+
+	c.Add(c.beacon)
+	go c.recvMessages()
+	go c.sendMessages()
+
+	return c, nil
+}
+
+// Lookup returns the addresses the device announced. If it hasn't, we ask
+// around so that it can be found the next time.
+func (c *mdnsClient) Lookup(device protocol.DeviceID) (addresses []string, err error) {
+	if cache, ok := c.Get(device); ok && time.Since(cache.when) < CacheLifeTime {
+		return cache.Addresses, nil
+	}
+
+	select {
+	case c.query <- struct{}{}:
+	default:
+	}
+	return nil, nil
+}
+
+func (c *mdnsClient) Stop() {
+	close(c.stop)
+	c.Supervisor.Stop()
+}
+
+func (c *mdnsClient) String() string {
+	return c.name
+}
+
+func (c *mdnsClient) Error() error {
+	return c.beacon.Error()
+}
+
+func (c *mdnsClient) sendMessages() {
+	announceTick := time.NewTicker(BroadcastInterval)
+	defer announceTick.Stop()
+
+	// Ask who's there when starting, rather than waiting for the others to
+	// announce themselves.
+	lastQuery := time.Now()
+	if msg, err := mdnsQuery(); err == nil {
+		c.beacon.Send(msg)
+	}
+
+	announce := true
+	for {
+		if announce {
+			if msg, ok := c.announcement(); ok {
+				c.beacon.Send(msg)
+			}
+		}
+
+		announce = true
+		select {
+		case <-announceTick.C:
+		case <-c.forcedAnnounce:
+		case <-c.query:
+			announce = false
+			if time.Since(lastQuery) > mdnsQueryInterval {
+				lastQuery = time.Now()
+				if msg, err := mdnsQuery(); err == nil {
+					c.beacon.Send(msg)
+				}
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *mdnsClient) recvMessages() {
+	for {
+		buf, addr := c.beacon.Recv()
+		c.handleMessage(buf, addr)
+	}
+}
+
+func (c *mdnsClient) handleMessage(buf []byte, addr net.Addr) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf); err != nil {
+		l.Debugf("discover: Failed to unpack mDNS message from %s: %v", addr, err)
+		return
+	}
+
+	if !msg.Header.Response {
+		if c.isQueried(msg.Questions) {
+			c.announceSoon()
+		}
+		return
+	}
+
+	for _, res := range append(msg.Answers, msg.Additionals...) {
+		txt, ok := res.Body.(*dnsmessage.TXTResource)
+		if !ok || !strings.HasSuffix(strings.ToLower(res.Header.Name.String()), strings.ToLower("."+mdnsService)) {
+			continue
+		}
+		pkt, ok := parseMDNSTXT(txt.TXT)
+		if !ok || pkt.ID == c.myID {
+			continue
+		}
+
+		l.Debugf("discover: Received mDNS announcement from %s for %s", addr, pkt.ID)
+		if c.registerDevice(addr, pkt) {
+			// Announce ourselves to the new device right away.
+			c.announceSoon()
+		}
+	}
+}
+
+func (c *mdnsClient) announceSoon() {
+	select {
+	case c.forcedAnnounce <- struct{}{}:
+	default:
+	}
+}
+
+// isQueried returns whether the questions ask for our service or for us.
+func (c *mdnsClient) isQueried(questions []dnsmessage.Question) bool {
+	instance := c.myID.String() + "." + mdnsService
+	for _, q := range questions {
+		name := q.Name.String()
+		if strings.EqualFold(name, mdnsService) || strings.EqualFold(name, instance) {
+			return true
+		}
+	}
+	return false
+}
+
+// announcement returns an mDNS response with the DNS-SD records for our
+// instance, or false if there is nothing to announce.
+func (c *mdnsClient) announcement() ([]byte, bool) {
+	addrs := c.addrList.AllAddresses()
+	if len(addrs) == 0 {
+		return nil, false
+	}
+
+	txt := []string{
+		"id=" + c.myID.String(),
+		"instance=" + strconv.FormatInt(c.instanceID, 10),
+	}
+	port := 22000
+	for _, addr := range addrs {
+		txt = append(txt, "addr="+addr)
+		if u, err := url.Parse(addr); err == nil && strings.HasPrefix(u.Scheme, "tcp") && port == 22000 {
+			if p, err := strconv.Atoi(u.Port()); err == nil {
+				port = p
+			}
+		}
+	}
+
+	instance, err := dnsmessage.NewName(c.myID.String() + "." + mdnsService)
+	if err != nil {
+		return nil, false
+	}
+	host := dnsmessage.MustNewName("syncthing-" + c.myID.Short().String() + ".local.")
+	ttl := uint32(CacheLifeTime / time.Second)
+	header := func(name dnsmessage.Name) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ttl}
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{
+			{Header: header(dnsmessage.MustNewName(mdnsService)), Body: &dnsmessage.PTRResource{PTR: instance}},
+			{Header: header(instance), Body: &dnsmessage.SRVResource{Target: host, Port: uint16(port)}},
+			{Header: header(instance), Body: &dnsmessage.TXTResource{TXT: txt}},
+		},
+	}
+	for _, ip := range c.hostIPs() {
+		if ip4 := ip.To4(); ip4 != nil {
+			var a dnsmessage.AResource
+			copy(a.A[:], ip4)
+			msg.Additionals = append(msg.Additionals, dnsmessage.Resource{Header: header(host), Body: &a})
+		} else {
+			var aaaa dnsmessage.AAAAResource
+			copy(aaaa.AAAA[:], ip)
+			msg.Additionals = append(msg.Additionals, dnsmessage.Resource{Header: header(host), Body: &aaaa})
+		}
+	}
+
+	bs, err := msg.Pack()
+	if err != nil {
+		l.Debugln("discover: Packing mDNS announcement:", err)
+		return nil, false
+	}
+	return bs, true
+}
+
+// hostIPs returns the addresses of this host in the address family of the
+// client, for the records of the SRV target.
+func (c *mdnsClient) hostIPs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || (ipnet.IP.To4() != nil) != c.ipv4 {
+			continue
+		}
+		ips = append(ips, ipnet.IP)
+	}
+	return ips
+}
+
+// mdnsQuery returns a query for the instances of our service.
+func mdnsQuery() ([]byte, error) {
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{
+			{Name: dnsmessage.MustNewName(mdnsService), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET},
+		},
+	}
+	return msg.Pack()
+}
+
+// parseMDNSTXT returns the announcement in the strings of a TXT record, or
+// false if it isn't one.
+func parseMDNSTXT(txt []string) (Announce, bool) {
+	var pkt Announce
+	for _, s := range txt {
+		kv := strings.SplitN(s, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "id":
+			id, err := protocol.DeviceIDFromString(kv[1])
+			if err != nil {
+				return Announce{}, false
+			}
+			pkt.ID = id
+		case "instance":
+			pkt.InstanceID, _ = strconv.ParseInt(kv[1], 10, 64)
+		case "addr":
+			pkt.Addresses = append(pkt.Addresses, kv[1])
+		}
+	}
+	return pkt, pkt.ID != protocol.EmptyDeviceID
+}
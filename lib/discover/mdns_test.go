@@ -0,0 +1,123 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestMDNSAnnouncement(t *testing.T) {
+	device1, _ := protocol.DeviceIDFromString("AIR6LPZ-7K4PTTV-UXQSMUU-CPQ5YWH-OEDFIIQ-JUG777G-2YQXXR5-YD6AWQR")
+	device2, _ := protocol.DeviceIDFromString("GYRZZQB-IRNPV4Z-T7TC52W-EQYJ3TT-FDQW6MW-DFLMU42-SSSU6EM-FBK2VAY")
+
+	newClient := func(id protocol.DeviceID) *mdnsClient {
+		return &mdnsClient{
+			myID:           id,
+			addrList:       &fakeAddressLister{},
+			ipv4:           true,
+			instanceID:     1234,
+			forcedAnnounce: make(chan struct{}, 1),
+			query:          make(chan struct{}, 1),
+			cache:          newCache(),
+		}
+	}
+	c1, c2 := newClient(device1), newClient(device2)
+	src := &net.UDPAddr{IP: net.IP{192, 0, 2, 42}, Port: 5353}
+
+	// The announcement of one is understood by the other, with the
+	// unspecified address replaced by where it came from.
+
+	msg, ok := c1.announcement()
+	if !ok {
+		t.Fatal("Nothing to announce")
+	}
+	if addrs, _ := c2.Lookup(device1); len(addrs) != 0 {
+		t.Fatal("Unexpected addresses before the announcement", addrs)
+	}
+	c2.handleMessage(msg, src)
+	addrs, _ := c2.Lookup(device1)
+	if len(addrs) != 2 || addrs[0] != "tcp://192.0.2.42:22000" || addrs[1] != "tcp://192.168.0.1:22000" {
+		t.Error("Unexpected addresses after the announcement", addrs)
+	}
+	select {
+	case <-c2.forcedAnnounce:
+	default:
+		t.Error("A new device should be answered with an announcement")
+	}
+
+	// Our own announcements are ignored.
+
+	c1.handleMessage(msg, src)
+	if _, ok := c1.Get(device1); ok {
+		t.Error("Registered our own announcement")
+	}
+
+	// Queries for the service are answered.
+
+	query, err := mdnsQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1.handleMessage(query, src)
+	select {
+	case <-c1.forcedAnnounce:
+	default:
+		t.Error("A query should be answered with an announcement")
+	}
+}
+
+type fakeBeacon struct {
+	sent chan []byte
+}
+
+func (b *fakeBeacon) Serve()                   {}
+func (b *fakeBeacon) Stop()                    {}
+func (b *fakeBeacon) Send(data []byte)         { b.sent <- data }
+func (b *fakeBeacon) Recv() ([]byte, net.Addr) { select {} }
+func (b *fakeBeacon) Error() error             { return nil }
+
+func TestMDNSSendMessagesStops(t *testing.T) {
+	id, _ := protocol.DeviceIDFromString("AIR6LPZ-7K4PTTV-UXQSMUU-CPQ5YWH-OEDFIIQ-JUG777G-2YQXXR5-YD6AWQR")
+	b := &fakeBeacon{sent: make(chan []byte, 10)}
+	c := &mdnsClient{
+		myID:           id,
+		addrList:       &fakeAddressLister{},
+		ipv4:           true,
+		beacon:         b,
+		forcedAnnounce: make(chan struct{}, 1),
+		query:          make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+		cache:          newCache(),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.sendMessages()
+		close(done)
+	}()
+
+	// A query and our announcement when starting.
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-b.sent:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for message", i)
+		}
+	}
+
+	close(c.stop)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Sending didn't stop")
+	}
+}
@@ -47,6 +47,11 @@ const (
 	FolderWatchStateChanged
 	ListenAddressesChanged
 	LoginAttempt
+	DatabaseCheckCompleted
+	FileCorrupted
+	EventsDropped
+	UserAction
+	APIKeyUsed
 
 	AllEvents = (1 << iota) - 1
 )
@@ -113,6 +118,16 @@ func (t EventType) String() string {
 		return "LoginAttempt"
 	case FolderWatchStateChanged:
 		return "FolderWatchStateChanged"
+	case DatabaseCheckCompleted:
+		return "DatabaseCheckCompleted"
+	case FileCorrupted:
+		return "FileCorrupted"
+	case EventsDropped:
+		return "EventsDropped"
+	case UserAction:
+		return "UserAction"
+	case APIKeyUsed:
+		return "APIKeyUsed"
 	default:
 		return "Unknown"
 	}
@@ -192,6 +207,16 @@ func UnmarshalEventType(s string) EventType {
 		return LoginAttempt
 	case "FolderWatchStateChanged":
 		return FolderWatchStateChanged
+	case "DatabaseCheckCompleted":
+		return DatabaseCheckCompleted
+	case "FileCorrupted":
+		return FileCorrupted
+	case "EventsDropped":
+		return EventsDropped
+	case "UserAction":
+		return UserAction
+	case "APIKeyUsed":
+		return APIKeyUsed
 	default:
 		return 0
 	}
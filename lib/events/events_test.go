@@ -350,6 +350,62 @@ func TestSinceUsesSubscriptionId(t *testing.T) {
 	}
 }
 
+func TestStream(t *testing.T) {
+	l := NewLogger()
+	defer l.Stop()
+	go l.Serve()
+
+	s := l.Subscribe(AllEvents)
+	defer l.Unsubscribe(s)
+	st := NewStream(s, 4)
+
+	for i := 0; i < 6; i++ {
+		if i%2 == 0 {
+			l.Log(DeviceConnected, i)
+		} else {
+			l.Log(DeviceDisconnected, i)
+		}
+	}
+
+	// Wait for the events to reach the stream
+	t0 := time.Now()
+	for st.Last() < 6 {
+		if time.Since(t0) > timeout {
+			t.Fatal("Timed out waiting for events")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The first two events fell out of the buffer and are reported as
+	// dropped, the rest are filtered by the mask.
+	evs, last := st.Since(0, DeviceConnected, nil, time.Minute)
+	if last != 6 {
+		t.Errorf("Incorrect last ID %d != 6", last)
+	}
+	if len(evs) != 3 {
+		t.Fatal("Incorrect number of events:", len(evs))
+	}
+	if evs[0].Type != EventsDropped || evs[0].GlobalID != 2 || evs[0].Data.(map[string]interface{})["count"] != 2 {
+		t.Errorf("Unexpected first event %+v", evs[0])
+	}
+	if evs[1].GlobalID != 3 || evs[2].GlobalID != 5 {
+		t.Errorf("Unexpected events %+v", evs[1:])
+	}
+
+	// Resuming from the last ID waits for new events.
+	evs, last = st.Since(last, AllEvents, nil, 10*time.Millisecond)
+	if len(evs) != 0 || last != 6 {
+		t.Errorf("Unexpected events %+v after %d", evs, last)
+	}
+
+	// An ID from the future means from now on.
+	l.Log(DeviceConnected, 6)
+	evs, last = st.Since(100, AllEvents, nil, time.Minute)
+	if len(evs) != 1 || evs[0].GlobalID != 7 || last != 7 {
+		t.Errorf("Unexpected events %+v after %d", evs, last)
+	}
+}
+
 func TestUnmarshalEvent(t *testing.T) {
 	var event Event
 
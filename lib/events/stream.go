@@ -0,0 +1,125 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package events
+
+import (
+	"time"
+
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+// A Stream keeps the most recent events of all types, so that consumers can
+// read them by GlobalID and resume where they left off. Events that are no
+// longer available to a consumer, because they fell out of the buffer or
+// never reached the stream, are reported as EventsDropped events instead of
+// leaving a silent gap.
+type Stream struct {
+	sub  *Subscription
+	buf  []Event
+	next int
+	last int // GlobalID of the most recent event
+	mut  sync.Mutex
+	cond *sync.TimeoutCond
+}
+
+// NewStream returns a Stream buffering up to size events from the given
+// subscription, which should normally be for AllEvents.
+func NewStream(s *Subscription, size int) *Stream {
+	st := &Stream{
+		sub: s,
+		buf: make([]Event, size),
+		mut: sync.NewMutex(),
+	}
+	st.cond = sync.NewTimeoutCond(st.mut)
+	go st.pollingLoop()
+	return st
+}
+
+func (s *Stream) pollingLoop() {
+	for ev := range s.sub.C() {
+		s.mut.Lock()
+		s.buf[s.next] = ev
+		s.next = (s.next + 1) % len(s.buf)
+		s.last = ev.GlobalID
+		s.cond.Broadcast()
+		s.mut.Unlock()
+	}
+}
+
+// Last returns the GlobalID of the most recent event in the stream.
+func (s *Stream) Last() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.last
+}
+
+// Since returns the events matching mask with a GlobalID greater than id,
+// waiting up to timeout for one to happen. Missing events are represented by
+// EventsDropped events at their place in the sequence. The returned GlobalID
+// is the one to pass as id in the next call; it can be larger than that of
+// the last returned event, as events not matching the mask are skipped. An
+// id larger than that of the most recent event is taken to be from a
+// previous run and means reading from now on.
+func (s *Stream) Since(id int, mask EventType, into []Event, timeout time.Duration) ([]Event, int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if id > s.last {
+		id = s.last
+	}
+
+	// Check once first before generating the TimeoutCondWaiter
+	if id >= s.last {
+		waiter := s.cond.SetupWait(timeout)
+		defer waiter.Stop()
+
+		for id >= s.last {
+			if eventsAvailable := waiter.Wait(); !eventsAvailable {
+				// Timed out
+				return into, id
+			}
+		}
+	}
+
+	prev := id
+	add := func(ev Event) {
+		if ev.GlobalID <= prev {
+			return
+		}
+		if ev.GlobalID > prev+1 {
+			into = append(into, droppedEvent(prev+1, ev.GlobalID-1))
+		}
+		if ev.Type&mask != 0 {
+			into = append(into, ev)
+		}
+		prev = ev.GlobalID
+	}
+	for i := s.next; i < len(s.buf); i++ {
+		add(s.buf[i])
+	}
+	for i := 0; i < s.next; i++ {
+		add(s.buf[i])
+	}
+
+	return into, prev
+}
+
+// droppedEvent returns an EventsDropped event for the events with GlobalIDs
+// from through to, inclusive. It carries the GlobalID of the last dropped
+// event, so that resuming from it continues after the gap.
+func droppedEvent(from, to int) Event {
+	return Event{
+		GlobalID: to,
+		Time:     time.Now(),
+		Type:     EventsDropped,
+		Data: map[string]interface{}{
+			"from":  from,
+			"to":    to,
+			"count": to - from + 1,
+		},
+	}
+}
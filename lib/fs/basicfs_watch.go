@@ -21,7 +21,9 @@ import (
 // Not meant to be changed, but must be changeable for tests
 var backendBuffer = 500
 
-func (f *BasicFilesystem) Watch(name string, ignore Matcher, ctx context.Context, ignorePerms bool) (<-chan Event, error) {
+var errMaxUserWatches = errors.New("failed to setup inotify handler. Please increase inotify limits, see https://docs.syncthing.net/users/faq.html#inotify-limits")
+
+func (f *BasicFilesystem) Watch(name string, ignore Matcher, ctx context.Context, ignorePerms bool, budget WatchBudget) (<-chan Event, error) {
 	evalRoot, err := evalSymlinks(f.root)
 	if err != nil {
 		return nil, err
@@ -32,14 +34,18 @@ func (f *BasicFilesystem) Watch(name string, ignore Matcher, ctx context.Context
 		return nil, err
 	}
 
-	outChan := make(chan Event)
-	backendChan := make(chan notify.EventInfo, backendBuffer)
-
 	eventMask := subEventMask
 	if !ignorePerms {
 		eventMask |= permEventMask
 	}
 
+	if budget.MaxDirs > 0 {
+		return f.watchBudgeted(name, evalRoot, ignore, ctx, eventMask, budget)
+	}
+
+	outChan := make(chan Event)
+	backendChan := make(chan notify.EventInfo, backendBuffer)
+
 	if ignore.SkipIgnoredDirs() {
 		absShouldIgnore := func(absPath string) bool {
 			return ignore.ShouldIgnore(f.unrootedChecked(absPath, evalRoot))
@@ -51,7 +57,9 @@ func (f *BasicFilesystem) Watch(name string, ignore Matcher, ctx context.Context
 	if err != nil {
 		notify.Stop(backendChan)
 		if reachedMaxUserWatches(err) {
-			err = errors.New("failed to setup inotify handler. Please increase inotify limits, see https://docs.syncthing.net/users/faq.html#inotify-limits")
+			// Watch as much as the backend allows and poll the rest.
+			l.Infof("Not enough inotify watches for %v, watching the most recently active directories only. To watch all directories, please increase inotify limits, see https://docs.syncthing.net/users/faq.html#inotify-limits", f.URI())
+			return f.watchBudgeted(name, evalRoot, ignore, ctx, eventMask, budget)
 		}
 		return nil, err
 	}
@@ -0,0 +1,529 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// +build !solaris,!darwin solaris,cgo darwin,cgo
+
+package fs
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/syncthing/notify"
+)
+
+// pollTick is the interval at which batches of directories that aren't
+// watched are handed out for scanning.
+// Not meant to be changed, but must be changeable for tests
+var pollTick = 10 * time.Second
+
+const defaultPollInterval = time.Hour
+
+// budgetDir is a directory known to a budgeted watch.
+type budgetDir struct {
+	active  time.Time // last observed activity
+	watched bool
+	failed  bool                   // polled as the backend refused to watch it, but not for the limit
+	entries map[string]budgetEntry // what's in it other than directories, as of the last poll, if polled
+}
+
+// budgetEntry is what's noted about a file in a polled directory to tell
+// whether it changed.
+type budgetEntry struct {
+	size    int64
+	modTime int64
+}
+
+// A budgetedWatch watches directories one by one instead of recursively.
+// Only the most recently active directories are watched, up to the budget
+// or until the backend refuses to add more watches. The remaining
+// directories are polled in batches, such that each of them is listed once
+// per poll interval. Polling a directory sends events for the files in it
+// that were added, changed or removed since it was last listed, not for
+// the directory itself, which would have it scanned recursively. Changes
+// to the metadata of polled directories are thus left to the full scans.
+// At the end of each poll cycle
+// the watches are rebalanced if polled directories have been more active
+// than watched ones.
+//
+// All state is owned by the goroutine running loop, after setup.
+type budgetedWatch struct {
+	fs        *BasicFilesystem
+	name      string
+	evalRoot  string
+	ignore    Matcher
+	eventMask notify.Event
+	budget    WatchBudget
+
+	outChan     chan<- Event
+	backendChan chan notify.EventInfo
+
+	dirs      map[string]*budgetDir
+	watched   int
+	limit     int // the number of watches the backend accepted, if it refused any
+	polled    []string
+	pollIndex int
+	reported  WatchState
+}
+
+func (f *BasicFilesystem) watchBudgeted(name, evalRoot string, ignore Matcher, ctx context.Context, eventMask notify.Event, budget WatchBudget) (<-chan Event, error) {
+	w := &budgetedWatch{
+		fs:        f,
+		name:      name,
+		evalRoot:  evalRoot,
+		ignore:    ignore,
+		eventMask: eventMask,
+		budget:    budget,
+		dirs:      make(map[string]*budgetDir),
+	}
+
+	if err := w.collect(name, time.Time{}); err != nil {
+		return nil, err
+	}
+
+	backendChan := make(chan notify.EventInfo, backendBuffer)
+	if err := w.watch(backendChan, false); err != nil {
+		notify.Stop(backendChan)
+		return nil, err
+	}
+	w.backendChan = backendChan
+
+	outChan := make(chan Event)
+	w.outChan = outChan
+
+	l.Debugf("%v %v Watch: Watching %d directories, polling %d", f.Type(), f.URI(), w.watched, len(w.dirs)-w.watched)
+	w.report()
+
+	go w.loop(ctx)
+
+	return outChan, nil
+}
+
+func (w *budgetedWatch) loop(ctx context.Context) {
+	ticker := time.NewTicker(pollTick)
+	defer ticker.Stop()
+
+	for {
+		// Detect channel overflow
+		if len(w.backendChan) == backendBuffer {
+		outer:
+			for {
+				select {
+				case <-w.backendChan:
+				default:
+					break outer
+				}
+			}
+			// When next scheduling a scan, do it on the entire folder as events have been lost.
+			select {
+			case w.outChan <- Event{Name: w.name, Type: NonRemove}:
+				l.Debugln(w.fs.Type(), w.fs.URI(), "Watch: Event overflow, send \".\"")
+			case <-ctx.Done():
+				w.stop()
+				return
+			}
+		}
+
+		select {
+		case ev := <-w.backendChan:
+			if !w.handle(ctx, ev) {
+				w.stop()
+				return
+			}
+		case <-ticker.C:
+			if !w.poll(ctx) {
+				w.stop()
+				return
+			}
+		case <-ctx.Done():
+			w.stop()
+			return
+		}
+
+		w.report()
+	}
+}
+
+func (w *budgetedWatch) stop() {
+	notify.Stop(w.backendChan)
+	l.Debugln(w.fs.Type(), w.fs.URI(), "Watch: Stopped")
+}
+
+// handle forwards a backend event and keeps track of directory activity.
+// It returns false if the context was cancelled.
+func (w *budgetedWatch) handle(ctx context.Context, ev notify.EventInfo) bool {
+	relPath := w.fs.unrootedChecked(ev.Path(), w.evalRoot)
+	if w.ignore.ShouldIgnore(relPath) {
+		l.Debugln(w.fs.Type(), w.fs.URI(), "Watch: Ignoring", relPath)
+		return true
+	}
+	evType := w.fs.eventType(ev.Event())
+	w.noteActivity(relPath, evType)
+	select {
+	case w.outChan <- Event{Name: relPath, Type: evType}:
+		l.Debugln(w.fs.Type(), w.fs.URI(), "Watch: Sending", relPath, evType)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (w *budgetedWatch) noteActivity(relPath string, evType EventType) {
+	now := time.Now()
+	if dir, ok := w.dirs[filepath.Dir(relPath)]; ok {
+		dir.active = now
+	}
+
+	if evType == Remove {
+		w.forget(relPath)
+		return
+	}
+
+	if _, ok := w.dirs[relPath]; ok {
+		return
+	}
+	if info, err := w.fs.Lstat(relPath); err != nil || !info.IsDir() {
+		return
+	}
+
+	// A new directory appeared, watch or poll it and everything below it.
+	before := len(w.dirs)
+	if err := w.collect(relPath, now); err != nil {
+		return
+	}
+	added := make([]string, 0, len(w.dirs)-before)
+	for name, dir := range w.dirs {
+		if !dir.watched && (name == relPath || IsParent(name, relPath)) {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		w.place(name, w.backendChan, false)
+	}
+}
+
+// forget removes a directory and everything below it.
+func (w *budgetedWatch) forget(relPath string) {
+	for name, dir := range w.dirs {
+		if name != relPath && !IsParent(name, relPath) {
+			continue
+		}
+		if dir.watched {
+			w.watched--
+		}
+		delete(w.dirs, name)
+	}
+}
+
+// collect adds all directories below and including name that aren't known
+// yet. A zero active time means to use the directory modification time.
+func (w *budgetedWatch) collect(name string, active time.Time) error {
+	return NewWalkFilesystem(w.fs).Walk(name, func(path string, info FileInfo, err error) error {
+		if err != nil {
+			if path == name {
+				return err
+			}
+			// Anything that can't be walked is taken care of by the scanner.
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != w.name && w.ignore.SkipIgnoredDirs() && w.ignore.ShouldIgnore(path) {
+			return SkipDir
+		}
+		if _, ok := w.dirs[path]; !ok {
+			dir := &budgetDir{active: active}
+			if active.IsZero() {
+				dir.active = info.ModTime()
+			}
+			w.dirs[path] = dir
+		}
+		return nil
+	})
+}
+
+// ranked returns the names of all known directories, the root first and the
+// others by descending activity.
+func (w *budgetedWatch) ranked() []string {
+	names := make([]string, 0, len(w.dirs))
+	for name := range w.dirs {
+		if name != w.name {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ai, aj := w.dirs[names[i]].active, w.dirs[names[j]].active
+		if ai.Equal(aj) {
+			return names[i] < names[j]
+		}
+		return ai.After(aj)
+	})
+	return append([]string{w.name}, names...)
+}
+
+func (w *budgetedWatch) maxDirs() int {
+	if w.limit > 0 && (w.budget.MaxDirs == 0 || w.limit < w.budget.MaxDirs) {
+		return w.limit
+	}
+	return w.budget.MaxDirs
+}
+
+// watch (re)distributes all known directories between being watched on
+// backendChan and being polled. When rebalancing, the previous watches are
+// still in place, so the backend refusing a watch is not taken as a new
+// limit.
+func (w *budgetedWatch) watch(backendChan chan notify.EventInfo, rebalancing bool) error {
+	w.watched = 0
+	w.polled = w.polled[:0]
+	w.pollIndex = 0
+	for _, name := range w.ranked() {
+		w.dirs[name].watched = false
+		w.dirs[name].failed = false
+		if err := w.place(name, backendChan, rebalancing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// place watches the given directory if the budget allows it and polls it
+// otherwise. Only a failure to watch the root during the initial setup is
+// returned as an error.
+func (w *budgetedWatch) place(name string, backendChan chan notify.EventInfo, rebalancing bool) error {
+	if max := w.maxDirs(); max > 0 && w.watched >= max {
+		w.addPolled(name)
+		return nil
+	}
+
+	absName, err := rooted(name, w.evalRoot)
+	if err == nil {
+		err = notify.Watch(absName, backendChan, w.eventMask)
+	}
+	switch {
+	case err == nil:
+		w.dirs[name].watched = true
+		w.dirs[name].entries = nil
+		w.watched++
+		return nil
+	case reachedMaxUserWatches(err):
+		if w.watched == 0 {
+			return errMaxUserWatches
+		}
+		if !rebalancing {
+			w.limit = w.watched
+		}
+	case name == w.name && !rebalancing:
+		return err
+	default:
+		l.Debugln(w.fs.Type(), w.fs.URI(), "Watch: Failed to watch", name, err)
+		w.dirs[name].failed = true
+	}
+	w.addPolled(name)
+	return nil
+}
+
+// addPolled adds the directory to those polled. What's in it is listed now,
+// unless it was polled already, to tell what changes by the first poll.
+func (w *budgetedWatch) addPolled(name string) {
+	w.polled = append(w.polled, name)
+	if dir := w.dirs[name]; dir.entries == nil {
+		dir.entries, _, _ = w.list(name)
+	}
+}
+
+// list returns the entries in the given directory other than directories,
+// by name, and the directories in it that aren't known yet.
+func (w *budgetedWatch) list(name string) (map[string]budgetEntry, []string, error) {
+	names, err := w.fs.DirNames(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries := make(map[string]budgetEntry, len(names))
+	var newDirs []string
+	for _, entry := range names {
+		path := filepath.Join(name, entry)
+		if w.ignore.ShouldIgnore(path) {
+			continue
+		}
+		info, err := w.fs.Lstat(path)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			if _, ok := w.dirs[path]; !ok {
+				newDirs = append(newDirs, path)
+			}
+			continue
+		}
+		entries[entry] = budgetEntry{size: info.Size(), modTime: info.ModTime().UnixNano()}
+	}
+	sort.Strings(newDirs)
+	return entries, newDirs, nil
+}
+
+// poll lists the next batch of directories that aren't watched. It returns
+// false if the context was cancelled.
+func (w *budgetedWatch) poll(ctx context.Context) bool {
+	interval := w.budget.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	batch := int((int64(len(w.polled))*int64(pollTick) + int64(interval) - 1) / int64(interval))
+
+	for i := 0; i < batch && len(w.polled) > 0; i++ {
+		if w.pollIndex >= len(w.polled) {
+			w.pollIndex = 0
+			if w.needsRebalance() {
+				w.rebalance(ctx)
+				return ctx.Err() == nil
+			}
+		}
+
+		name := w.polled[w.pollIndex]
+		dir, ok := w.dirs[name]
+		if !ok || dir.watched {
+			// Removed or since watched, drop it from the poll list.
+			w.polled = append(w.polled[:w.pollIndex], w.polled[w.pollIndex+1:]...)
+			continue
+		}
+		w.pollIndex++
+
+		if !w.pollDir(ctx, name, dir) {
+			return false
+		}
+	}
+	return true
+}
+
+// pollDir sends events for the files in the directory that changed since
+// it was last listed, and for new directories in it. It returns false if
+// the context was cancelled.
+func (w *budgetedWatch) pollDir(ctx context.Context, name string, dir *budgetDir) bool {
+	entries, newDirs, err := w.list(name)
+	if IsNotExist(err) {
+		w.forget(name)
+		return w.send(ctx, Event{Name: name, Type: Remove})
+	} else if err != nil {
+		// The scanner reports it.
+		l.Debugln(w.fs.Type(), w.fs.URI(), "Watch: Failed to poll", name, err)
+		return true
+	}
+
+	var evs []Event
+	for entry, e := range entries {
+		if old, ok := dir.entries[entry]; !ok || old != e {
+			evs = append(evs, Event{Name: filepath.Join(name, entry), Type: NonRemove})
+		}
+	}
+	for entry := range dir.entries {
+		if _, ok := entries[entry]; !ok {
+			evs = append(evs, Event{Name: filepath.Join(name, entry), Type: Remove})
+		}
+	}
+	dir.entries = entries
+	sort.Slice(evs, func(i, j int) bool {
+		return evs[i].Name < evs[j].Name
+	})
+	if len(evs) > 0 {
+		dir.active = time.Now()
+	}
+
+	for _, ev := range evs {
+		if !w.send(ctx, ev) {
+			return false
+		}
+	}
+	for _, newDir := range newDirs {
+		w.noteActivity(newDir, NonRemove)
+		if !w.send(ctx, Event{Name: newDir, Type: NonRemove}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *budgetedWatch) send(ctx context.Context, ev Event) bool {
+	select {
+	case w.outChan <- ev:
+		l.Debugln(w.fs.Type(), w.fs.URI(), "Watch: Polled", ev.Name, ev.Type)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// needsRebalance returns true if there is room for more watches, or if a
+// polled directory has been more active than the least active watched one.
+// Only directories that are polled for lack of budget count, the backend
+// refusing to watch the others for other reasons won't change.
+func (w *budgetedWatch) needsRebalance() bool {
+	max := w.maxDirs()
+	if max == 0 {
+		// Neither a budget nor a limit, so all that can be watched is.
+		return false
+	}
+
+	var leastActive time.Time
+	found := false
+	for name, dir := range w.dirs {
+		if dir.watched && name != w.name && (!found || dir.active.Before(leastActive)) {
+			leastActive = dir.active
+			found = true
+		}
+	}
+	for _, name := range w.polled {
+		dir, ok := w.dirs[name]
+		if !ok || dir.watched || dir.failed {
+			continue
+		}
+		if w.watched < max || (found && dir.active.After(leastActive)) {
+			return true
+		}
+	}
+	return false
+}
+
+// rebalance sets up watches for the currently most active directories on a
+// new backend channel before stopping the old one, such that directories
+// that stay watched don't miss any events.
+func (w *budgetedWatch) rebalance(ctx context.Context) {
+	oldChan := w.backendChan
+	newChan := make(chan notify.EventInfo, backendBuffer)
+	w.watch(newChan, true)
+	w.backendChan = newChan
+	notify.Stop(oldChan)
+
+	l.Debugf("%v %v Watch: Rebalanced to watching %d directories, polling %d", w.fs.Type(), w.fs.URI(), w.watched, len(w.dirs)-w.watched)
+
+	for {
+		select {
+		case ev := <-oldChan:
+			if !w.handle(ctx, ev) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (w *budgetedWatch) report() {
+	state := WatchState{
+		Watched: w.watched,
+		Polled:  len(w.dirs) - w.watched,
+	}
+	if state == w.reported {
+		return
+	}
+	w.reported = state
+	if w.budget.StateChanged != nil {
+		w.budget.StateChanged(state)
+	}
+}
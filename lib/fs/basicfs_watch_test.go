@@ -237,6 +237,145 @@ func TestWatchOverflow(t *testing.T) {
 	testScenario(t, name, testCase, expectedEvents, allowedEvents, fakeMatcher{})
 }
 
+// TestWatchBudget checks that only the most recently modified directories
+// are watched when a budget is given, and the others are polled for
+// changes to the files in them.
+func TestWatchBudget(t *testing.T) {
+	if runtime.GOOS == "openbsd" {
+		t.Skip(failsOnOpenBSD)
+	}
+	name := "budget"
+
+	for i, dir := range []string{"old", "mid", "new"} {
+		dir = filepath.Join(name, dir)
+		if err := testFs.MkdirAll(dir, 0755); err != nil {
+			panic(fmt.Sprintf("Failed to create directory %s: %s", dir, err))
+		}
+		createTestFile(dir, "unchanged")
+		if dir == filepath.Join(name, "old") {
+			createTestFile(dir, "gone")
+		}
+		mtime := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := testFs.Chtimes(dir, mtime, mtime); err != nil {
+			panic(err)
+		}
+	}
+	defer testFs.RemoveAll(name)
+
+	oldPollTick := pollTick
+	pollTick = 100 * time.Millisecond
+	defer func() {
+		pollTick = oldPollTick
+	}()
+
+	states := make(chan WatchState, 10)
+	budget := WatchBudget{
+		MaxDirs:      2,
+		PollInterval: 2 * pollTick,
+		StateChanged: func(state WatchState) {
+			states <- state
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventChan, err := testFs.Watch(name, fakeMatcher{}, ctx, false, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case state := <-states:
+		if exp := (WatchState{Watched: 2, Polled: 2}); state != exp {
+			t.Errorf("Got watch state %v, expected %v", state, exp)
+		}
+	default:
+		t.Fatal("Watch state wasn't reported")
+	}
+
+	createTestFile(filepath.Join(name, "new"), "file")
+	createTestFile(filepath.Join(name, "old"), "file")
+	if err := testFs.Remove(filepath.Join(name, "old", "gone")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]EventType{
+		filepath.Join(name, "new", "file"): NonRemove,
+		filepath.Join(name, "old", "file"): NonRemove,
+		filepath.Join(name, "old", "gone"): Remove,
+	}
+	timeout := time.NewTimer(10 * time.Second)
+	for len(expected) > 0 {
+		select {
+		case ev := <-eventChan:
+			if ev.Name == filepath.Join(name, "new") {
+				// The watched directory itself, for the new file.
+				continue
+			}
+			evType, ok := expected[ev.Name]
+			if !ok || ev.Type != evType {
+				t.Fatalf("Unexpected event %v %v", ev.Name, ev.Type)
+			}
+			delete(expected, ev.Name)
+		case <-timeout.C:
+			t.Fatalf("Timed out before receiving events for %v", expected)
+		}
+	}
+
+	// Polling the directories again without changes sends nothing.
+	quiet := time.After(4 * budget.PollInterval)
+loop:
+	for {
+		select {
+		case ev := <-eventChan:
+			// Late events from the watched directory are fine.
+			if !strings.HasPrefix(ev.Name, filepath.Join(name, "new")) {
+				t.Errorf("Unexpected event %v %v", ev.Name, ev.Type)
+			}
+		case <-quiet:
+			break loop
+		}
+	}
+}
+
+func TestWatchBudgetNeedsRebalance(t *testing.T) {
+	now := time.Now()
+	w := &budgetedWatch{
+		name: ".",
+		dirs: map[string]*budgetDir{
+			".":      {watched: true},
+			"a":      {watched: true, active: now.Add(-time.Hour)},
+			"failed": {failed: true, active: now},
+			"b":      {active: now.Add(-2 * time.Hour)},
+		},
+		watched: 2,
+		polled:  []string{"failed", "b"},
+	}
+
+	// Without a budget or limit, nothing changes by rebalancing.
+	if w.needsRebalance() {
+		t.Error("Rebalance needed without budget")
+	}
+
+	// Directories the backend refused to watch don't count.
+	w.budget.MaxDirs = 2
+	if w.needsRebalance() {
+		t.Error("Rebalance needed for a directory that failed to be watched")
+	}
+
+	w.dirs["b"].active = now
+	if !w.needsRebalance() {
+		t.Error("Rebalance not needed for an active polled directory")
+	}
+
+	w.dirs["b"].active = now.Add(-2 * time.Hour)
+	w.budget.MaxDirs = 3
+	if !w.needsRebalance() {
+		t.Error("Rebalance not needed with room for more watches")
+	}
+}
+
 func TestWatchErrorLinuxInterpretation(t *testing.T) {
 	if runtime.GOOS != "linux" {
 		t.Skip("testing of linux specific error codes")
@@ -290,7 +429,7 @@ func TestWatchSymlinkedRoot(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	if _, err := linkedFs.Watch(".", fakeMatcher{}, ctx, false); err != nil {
+	if _, err := linkedFs.Watch(".", fakeMatcher{}, ctx, false, WatchBudget{}); err != nil {
 		panic(err)
 	}
 
@@ -381,7 +520,7 @@ func testScenario(t *testing.T, name string, testCase func(), expectedEvents, al
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	eventChan, err := testFs.Watch(name, fm, ctx, false)
+	eventChan, err := testFs.Watch(name, fm, ctx, false, WatchBudget{})
 	if err != nil {
 		panic(err)
 	}
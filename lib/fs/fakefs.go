@@ -466,7 +466,7 @@ func (fs *fakefs) Walk(name string, walkFn WalkFunc) error {
 	return errors.New("not implemented")
 }
 
-func (fs *fakefs) Watch(path string, ignore Matcher, ctx context.Context, ignorePerms bool) (<-chan Event, error) {
+func (fs *fakefs) Watch(path string, ignore Matcher, ctx context.Context, ignorePerms bool, budget WatchBudget) (<-chan Event, error) {
 	return nil, ErrWatchNotSupported
 }
 
@@ -36,7 +36,7 @@ type Filesystem interface {
 	Stat(name string) (FileInfo, error)
 	SymlinksSupported() bool
 	Walk(name string, walkFn WalkFunc) error
-	Watch(path string, ignore Matcher, ctx context.Context, ignorePerms bool) (<-chan Event, error)
+	Watch(path string, ignore Matcher, ctx context.Context, ignorePerms bool, budget WatchBudget) (<-chan Event, error)
 	Hide(name string) error
 	Unhide(name string) error
 	Glob(pattern string) ([]string, error)
@@ -134,6 +134,27 @@ func (evType EventType) String() string {
 
 var ErrWatchNotSupported = errors.New("watching is not supported")
 
+// WatchBudget limits the resources used by a filesystem watcher. When the
+// budget is exhausted, or the backend refuses to add more watches, the
+// least recently active directories are polled instead of watched.
+type WatchBudget struct {
+	// MaxDirs is the maximum number of directories to watch. Zero means no
+	// limit, i.e. the whole tree is watched recursively if possible.
+	MaxDirs int
+	// PollInterval is the time in which all directories that are not
+	// watched are handed out for scanning once.
+	PollInterval time.Duration
+	// StateChanged, if set, is called whenever the number of watched or
+	// polled directories changes.
+	StateChanged func(WatchState)
+}
+
+// WatchState describes how a budgeted watch covers the watched tree.
+type WatchState struct {
+	Watched int `json:"watched"`
+	Polled  int `json:"polled"`
+}
+
 // Equivalents from os package.
 
 const ModePerm = FileMode(os.ModePerm)
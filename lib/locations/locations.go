@@ -34,6 +34,9 @@ const (
 	AuditLog      LocationEnum = "auditLog"
 	GUIAssets     LocationEnum = "GUIAssets"
 	DefFolder     LocationEnum = "defFolder"
+	Webhooks      LocationEnum = "webhooks"
+	EventHistory  LocationEnum = "eventHistory"
+	AddressBook   LocationEnum = "addressBook"
 )
 
 type BaseDirEnum string
@@ -87,6 +90,9 @@ var locationTemplates = map[LocationEnum]string{
 	AuditLog:      "${config}/audit-${timestamp}.log",
 	GUIAssets:     "${config}/gui",
 	DefFolder:     "${home}/Sync",
+	Webhooks:      "${config}/webhooks",
+	EventHistory:  "${config}/eventhistory",
+	AddressBook:   "${config}/addressbook.json",
 }
 
 var locations = make(map[LocationEnum]string)
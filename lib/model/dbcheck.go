@@ -0,0 +1,194 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+// CheckDatabase requests a check of the database entries of the given
+// folder, or of all folders if folder is empty, for consistency and a
+// repair of any drift found. The check runs in the background and its
+// results are emitted as DatabaseCheckCompleted events.
+func (m *Model) CheckDatabase(folder string) error {
+	if folder != "" {
+		m.fmut.RLock()
+		_, ok := m.folderFiles[folder]
+		m.fmut.RUnlock()
+		if !ok {
+			return errFolderMissing
+		}
+	}
+	m.dbChecker.request(folder)
+	return nil
+}
+
+// checkDatabase checks the given folders, or all folders if there are none
+// given, and returns the results.
+func (m *Model) checkDatabase(folders []string) []db.CheckResult {
+	if len(folders) == 0 {
+		m.fmut.RLock()
+		for id := range m.folderFiles {
+			folders = append(folders, id)
+		}
+		m.fmut.RUnlock()
+	}
+	sort.Strings(folders)
+
+	results := make([]db.CheckResult, 0, len(folders))
+	for _, id := range folders {
+		m.fmut.RLock()
+		fs, ok := m.folderFiles[id]
+		m.fmut.RUnlock()
+		if !ok {
+			// Removed in the meantime
+			continue
+		}
+
+		res := fs.Check()
+		if res.Repaired() {
+			l.Infof("Database check of folder %q repaired inconsistencies: %+v", id, res)
+		} else {
+			l.Debugf("Database check of folder %q found no inconsistencies (%v)", id, res.Duration)
+		}
+		events.Default.Log(events.DatabaseCheckCompleted, map[string]interface{}{
+			"folder":               res.Folder,
+			"repaired":             res.Repaired(),
+			"staleGlobalEntries":   res.StaleGlobalEntries,
+			"staleNeedEntries":     res.StaleNeedEntries,
+			"missingNeedEntries":   res.MissingNeedEntries,
+			"orphanedBlockEntries": res.OrphanedBlockEntries,
+			"missingBlockEntries":  res.MissingBlockEntries,
+			"wrongCounts":          res.WrongCounts,
+			"duration":             res.Duration.Seconds(),
+		})
+		results = append(results, res)
+	}
+	return results
+}
+
+// The databaseChecker checks all folders at the interval given by the
+// DatabaseCheckIntervalH option, if set, and the folders requested in
+// between.
+type databaseChecker struct {
+	model     *Model
+	interval  time.Duration
+	mut       sync.Mutex
+	timer     *time.Timer
+	requested map[string]struct{} // "" for all folders
+	requests  chan struct{}
+	stop      chan struct{}
+}
+
+func newDatabaseChecker(m *Model, cfg *config.Wrapper) *databaseChecker {
+	c := &databaseChecker{
+		model:     m,
+		mut:       sync.NewMutex(),
+		timer:     time.NewTimer(time.Hour),
+		requested: make(map[string]struct{}),
+		requests:  make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+	c.timer.Stop()
+
+	c.CommitConfiguration(config.Configuration{}, cfg.RawCopy())
+	cfg.Subscribe(c)
+
+	return c
+}
+
+func (c *databaseChecker) Serve() {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-c.timer.C:
+			c.model.checkDatabase(nil)
+
+			c.mut.Lock()
+			if c.interval > 0 {
+				c.timer.Reset(c.interval)
+			}
+			c.mut.Unlock()
+		case <-c.requests:
+			c.mut.Lock()
+			_, all := c.requested[""]
+			folders := make([]string, 0, len(c.requested))
+			for folder := range c.requested {
+				folders = append(folders, folder)
+			}
+			c.requested = make(map[string]struct{})
+			c.mut.Unlock()
+
+			if all {
+				folders = nil
+			}
+			c.model.checkDatabase(folders)
+		}
+	}
+}
+
+// request queues a check of the given folder, or of all folders if empty.
+func (c *databaseChecker) request(folder string) {
+	c.mut.Lock()
+	c.requested[folder] = struct{}{}
+	c.mut.Unlock()
+
+	select {
+	case c.requests <- struct{}{}:
+	default:
+	}
+}
+
+func (c *databaseChecker) Stop() {
+	close(c.stop)
+}
+
+// VerifyConfiguration implements the config.Committer interface
+func (c *databaseChecker) VerifyConfiguration(from, to config.Configuration) error {
+	return nil
+}
+
+// CommitConfiguration implements the config.Committer interface
+func (c *databaseChecker) CommitConfiguration(from, to config.Configuration) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	interval := time.Duration(to.Options.DatabaseCheckIntervalH) * time.Hour
+	if interval < 0 {
+		interval = 0
+	}
+	if interval == c.interval {
+		return true
+	}
+	c.interval = interval
+	if !c.timer.Stop() {
+		// Drain a pending expiry so it doesn't trigger a check right after
+		// the reset.
+		select {
+		case <-c.timer.C:
+		default:
+		}
+	}
+	if interval > 0 {
+		c.timer.Reset(interval)
+	}
+	l.Debugln("database checker: updated interval", interval)
+
+	return true
+}
+
+func (c *databaseChecker) String() string {
+	return fmt.Sprintf("databaseChecker@%p", c)
+}
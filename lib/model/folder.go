@@ -59,6 +59,7 @@ type folder struct {
 	watchChan        chan []string
 	restartWatchChan chan struct{}
 	watchErr         error
+	watchState       fs.WatchState
 	watchMut         sync.Mutex
 
 	puller puller
@@ -122,6 +123,8 @@ func (f *folder) Serve() {
 		f.startWatch()
 	}
 
+	go f.scrubRoutine()
+
 	initialCompleted := f.initialScanFinished
 
 	for {
@@ -560,22 +563,53 @@ func (f *folder) WatchError() error {
 	return f.watchErr
 }
 
+// WatchState returns the number of watched and polled directories, if the
+// watcher is limited to a subset of directories.
+func (f *folder) WatchState() fs.WatchState {
+	f.watchMut.Lock()
+	defer f.watchMut.Unlock()
+	return f.watchState
+}
+
+// watchStateChanged is called by the filesystem watcher when the number of
+// watched or polled directories changes.
+func (f *folder) watchStateChanged(state fs.WatchState) {
+	f.watchMut.Lock()
+	f.watchState = state
+	err := f.watchErr
+	f.watchMut.Unlock()
+	f.logWatchStateChanged(err, err, state)
+}
+
+// logWatchStateChanged logs a FolderWatchStateChanged event. The watcher
+// errors before and after the change are empty when there was none.
+func (f *folder) logWatchStateChanged(from, to error, state fs.WatchState) {
+	data := map[string]interface{}{
+		"folder":  f.ID,
+		"from":    "",
+		"to":      "",
+		"watched": state.Watched,
+		"polled":  state.Polled,
+	}
+	if from != nil {
+		data["from"] = from.Error()
+	}
+	if to != nil {
+		data["to"] = to.Error()
+	}
+	events.Default.Log(events.FolderWatchStateChanged, data)
+}
+
 // stopWatch immediately aborts watching and may be called asynchronously
 func (f *folder) stopWatch() {
 	f.watchMut.Lock()
 	f.watchCancel()
 	prevErr := f.watchErr
 	f.watchErr = errWatchNotStarted
+	f.watchState = fs.WatchState{}
 	f.watchMut.Unlock()
 	if prevErr != errWatchNotStarted {
-		data := map[string]interface{}{
-			"folder": f.ID,
-			"to":     errWatchNotStarted.Error(),
-		}
-		if prevErr != nil {
-			data["from"] = prevErr.Error()
-		}
-		events.Default.Log(events.FolderWatchStateChanged, data)
+		f.logWatchStateChanged(prevErr, errWatchNotStarted, fs.WatchState{})
 	}
 }
 
@@ -620,22 +654,19 @@ func (f *folder) startWatchAsync(ctx context.Context, ignores *ignore.Matcher) {
 	for {
 		select {
 		case <-timer.C:
-			eventChan, err := f.Filesystem().Watch(".", ignores, ctx, f.IgnorePerms)
+			budget := fs.WatchBudget{
+				MaxDirs:      f.FSWatcherMaxDirs,
+				PollInterval: time.Duration(f.FSWatcherPollS) * time.Second,
+				StateChanged: f.watchStateChanged,
+			}
+			eventChan, err := f.Filesystem().Watch(".", ignores, ctx, f.IgnorePerms, budget)
 			f.watchMut.Lock()
 			prevErr := f.watchErr
 			f.watchErr = err
+			state := f.watchState
 			f.watchMut.Unlock()
 			if err != prevErr {
-				data := map[string]interface{}{
-					"folder": f.ID,
-				}
-				if prevErr != nil {
-					data["from"] = prevErr.Error()
-				}
-				if err != nil {
-					data["to"] = err.Error()
-				}
-				events.Default.Log(events.FolderWatchStateChanged, data)
+				f.logWatchStateChanged(prevErr, err, state)
 			}
 			if err != nil {
 				if prevErr == errWatchNotStarted {
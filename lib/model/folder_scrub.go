@@ -0,0 +1,186 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/scanner"
+	"golang.org/x/time/rate"
+)
+
+// scrubRoutine rehashes the contents of all files once per ScrubIntervalH,
+// to detect data that has been corrupted on disk. It returns when the
+// folder is stopped.
+func (f *folder) scrubRoutine() {
+	if f.ScrubIntervalH <= 0 {
+		return
+	}
+	interval := time.Duration(f.ScrubIntervalH) * time.Hour
+
+	select {
+	case <-f.initialScanFinished:
+	case <-f.ctx.Done():
+		return
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		// The time of the last completed scrub is persisted, so that
+		// restarts don't postpone scrubbing indefinitely.
+		next := interval - time.Since(f.model.folderStatRef(f.ID).GetLastScrubTime())
+		if next < 0 {
+			next = 0
+		}
+		l.Debugln(f, "next scrub in", next)
+		timer.Reset(next)
+
+		select {
+		case <-timer.C:
+		case <-f.ctx.Done():
+			return
+		}
+
+		if err := f.scrub(); err != nil {
+			l.Debugln(f, "scrub:", err)
+			return
+		}
+		f.model.folderStatRef(f.ID).ScrubCompleted()
+	}
+}
+
+// scrub verifies the contents of all local files against their block
+// hashes, at the rate given by ScrubRateKiBps. An error is only returned
+// if the scrub was aborted.
+func (f *folder) scrub() error {
+	f.model.fmut.RLock()
+	fset := f.model.folderFiles[f.ID]
+	f.model.fmut.RUnlock()
+	mtimefs := fset.MtimeFS()
+
+	l.Debugln(f, "scrubbing")
+	t0 := time.Now()
+
+	// The files are looked up one by one while hashing, to not keep a
+	// database iterator open for the duration of the scrub.
+	var names []string
+	fset.WithHaveTruncated(protocol.LocalDeviceID, func(fi db.FileIntf) bool {
+		if isScrubbable(fi) {
+			names = append(names, fi.FileName())
+		}
+		return true
+	})
+
+	counter := newScrubCounter(f.ctx, f.ScrubRateKiBps)
+	for _, name := range names {
+		select {
+		case <-f.ctx.Done():
+			return f.ctx.Err()
+		default:
+		}
+
+		file, ok := fset.Get(protocol.LocalDeviceID, name)
+		if !ok || !isScrubbable(file) {
+			continue
+		}
+
+		corrupted, err := scanner.VerifyFile(f.ctx, mtimefs, file, counter)
+		if err != nil {
+			// Modified or removed files are taken care of by the
+			// scanner.
+			l.Debugln(f, "scrub:", name, err)
+			continue
+		}
+		if len(corrupted) > 0 {
+			f.fileCorrupted(fset, file, corrupted)
+		}
+	}
+
+	l.Debugf("%v scrubbed %d files in %v", f, len(names), time.Since(t0))
+	return nil
+}
+
+// fileCorrupted reports a file whose contents don't match its block hashes
+// although its size and modification time are unchanged, and schedules it
+// to be fetched again if configured to do so.
+func (f *folder) fileCorrupted(fset *db.FileSet, file protocol.FileInfo, corrupted []int) {
+	refetch := f.ScrubRefetch && f.Type != config.FolderTypeSendOnly && isAvailableElsewhere(fset, file)
+
+	l.Warnf("Scrub: %s in folder %s is corrupted on disk (%d of %d blocks differ)", file.Name, f.Description(), len(corrupted), len(file.Blocks))
+	events.Default.Log(events.FileCorrupted, map[string]interface{}{
+		"folder":  f.ID,
+		"item":    file.Name,
+		"blocks":  corrupted,
+		"refetch": refetch,
+	})
+
+	if !refetch {
+		return
+	}
+
+	// Make sure the file didn't change in the database while hashing.
+	if cur, ok := fset.Get(protocol.LocalDeviceID, file.Name); !ok || cur.Sequence != file.Sequence {
+		return
+	}
+
+	// Like a revert, resetting the version to the empty vector makes the
+	// global version strictly newer and not in conflict with ours. Without
+	// blocks the puller can't take it for a metadata only change, and
+	// replaces the file, copying only the blocks that still match their
+	// hashes.
+	file.Version = protocol.Vector{}
+	file.Blocks = nil
+	f.model.updateLocals(f.ID, []protocol.FileInfo{file})
+	f.SchedulePull()
+}
+
+// isScrubbable returns true for valid, existing files with content.
+func isScrubbable(f db.FileIntf) bool {
+	return !f.IsDirectory() && !f.IsSymlink() && !f.IsDeleted() && !f.IsInvalid() && f.FileSize() > 0
+}
+
+// isAvailableElsewhere returns true if our version of the file is the global
+// version and another device has it too.
+func isAvailableElsewhere(fset *db.FileSet, file protocol.FileInfo) bool {
+	global, ok := fset.GetGlobalTruncated(file.Name)
+	if !ok || !global.Version.Equal(file.Version) {
+		return false
+	}
+	for _, dev := range fset.Availability(file.Name) {
+		if dev != protocol.LocalDeviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// The scrubCounter limits the hashing rate of a scrub.
+type scrubCounter struct {
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func newScrubCounter(ctx context.Context, kibps int) *scrubCounter {
+	limit := rate.Inf
+	if kibps > 0 {
+		limit = rate.Limit(kibps * 1024)
+	}
+	return &scrubCounter{
+		ctx:     ctx,
+		limiter: rate.NewLimiter(limit, protocol.MaxBlockSize),
+	}
+}
+
+func (c *scrubCounter) Update(bytes int64) {
+	c.limiter.WaitN(c.ctx, int(bytes))
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestScrubRefetch(t *testing.T) {
+	w := createTmpWrapper(defaultCfgWrapper.RawCopy())
+	fcfg, tmpDir := testFolderConfigTmp()
+	fcfg.ScrubRefetch = true
+	w.SetFolder(fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(w)
+	defer func() {
+		m.Stop()
+		os.RemoveAll(tmpDir)
+		os.Remove(w.ConfigPath())
+	}()
+
+	pulled := make(chan protocol.FileInfo, 4)
+	fc.mut.Lock()
+	fc.indexFn = func(folder string, fs []protocol.FileInfo) {
+		for _, f := range fs {
+			if f.Name == "foo" && len(f.Version.Counters) > 0 {
+				pulled <- f
+			}
+		}
+	}
+	fc.mut.Unlock()
+
+	data := []byte("some data that will rot on disk")
+	fc.addFile("foo", 0644, protocol.FileInfoTypeFile, data)
+	fc.sendIndexUpdate()
+	select {
+	case <-pulled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for initial pull")
+	}
+
+	// Corrupt the file without changing its size or modification time.
+	path := filepath.Join(tmpDir, "foo")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotten := append([]byte(nil), data...)
+	rotten[3] ^= 0xff
+	if err := ioutil.WriteFile(path, rotten, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := events.Default.Subscribe(events.FileCorrupted)
+	defer events.Default.Unsubscribe(sub)
+
+	for len(pulled) > 0 {
+		<-pulled
+	}
+
+	m.fmut.RLock()
+	f := m.folderRunners["default"].(*sendReceiveFolder)
+	m.fmut.RUnlock()
+	if err := f.scrub(); err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := sub.Poll(time.Second)
+	if err != nil {
+		t.Fatal("no FileCorrupted event:", err)
+	}
+	evData := ev.Data.(map[string]interface{})
+	if evData["item"] != "foo" || evData["refetch"] != true {
+		t.Errorf("unexpected event data %v", evData)
+	}
+
+	select {
+	case <-pulled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for refetch")
+	}
+	if err := equalContents(path, data); err != nil {
+		t.Error("file not refetched:", err)
+	}
+}
@@ -18,11 +18,13 @@ import (
 	"runtime"
 	"strings"
 	stdsync "sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/connections"
 	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/discover"
 	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/fs"
 	"github.com/syncthing/syncthing/lib/ignore"
@@ -32,6 +34,7 @@ import (
 	"github.com/syncthing/syncthing/lib/stats"
 	"github.com/syncthing/syncthing/lib/sync"
 	"github.com/syncthing/syncthing/lib/upgrade"
+	"github.com/syncthing/syncthing/lib/util"
 	"github.com/syncthing/syncthing/lib/versioner"
 	"github.com/thejerf/suture"
 )
@@ -52,6 +55,18 @@ const (
 	maxBatchSizeFiles = 1000       // Either way, don't include more files than this
 )
 
+// Gossiped addresses are used for at most this long after they were
+// received, as the device may have moved since, even when the one telling
+// us about it stays connected.
+const maxGossipedAddressAge = 24 * time.Hour
+
+// gossipedAddresses are the addresses a device told us it sees another
+// one at.
+type gossipedAddresses struct {
+	addresses []string
+	received  time.Time
+}
+
 type service interface {
 	BringToFront(string)
 	Override(*db.FileSet, func([]protocol.FileInfo))
@@ -65,12 +80,21 @@ type service interface {
 	CheckHealth() error
 	Errors() []FileError
 	WatchError() error
+	WatchState() fs.WatchState
 
 	getState() (folderState, time.Time, error)
 	setState(state folderState)
 	setError(err error)
 }
 
+// An AddressBook keeps the addresses of devices that we got out of band. We
+// share them with the devices we're connected to, and take those shared by
+// our introducers.
+type AddressBook interface {
+	Addresses(device protocol.DeviceID) []string
+	Learn(from, device protocol.DeviceID, addresses []string)
+}
+
 type Availability struct {
 	ID            protocol.DeviceID `json:"id"`
 	FromTemporary bool              `json:"fromTemporary"`
@@ -83,10 +107,12 @@ type Model struct {
 	db                *db.Lowlevel
 	finder            *db.BlockFinder
 	progressEmitter   *ProgressEmitter
+	dbChecker         *databaseChecker
 	id                protocol.DeviceID
 	shortID           protocol.ShortID
 	cacheIgnoredFiles bool
 	protectedFiles    []string
+	addressBook       AddressBook
 
 	clientName    string
 	clientVersion string
@@ -103,13 +129,17 @@ type Model struct {
 
 	pmut                sync.RWMutex // protects the below
 	conn                map[protocol.DeviceID]connections.Connection
+	streams             map[protocol.DeviceID][]connections.Connection // additional connections, besides the one in conn
 	connRequestLimiters map[protocol.DeviceID]*byteSemaphore
 	closed              map[protocol.DeviceID]chan struct{}
 	helloMessages       map[protocol.DeviceID]protocol.HelloResult
 	deviceDownloads     map[protocol.DeviceID]*deviceDownloadState
-	remotePausedFolders map[protocol.DeviceID][]string // deviceID -> folders
+	remotePausedFolders map[protocol.DeviceID][]string                                // deviceID -> folders
+	clusterConfigs      map[protocol.DeviceID]bool                                    // deviceID -> got the first cluster config
+	gossipedAddresses   map[protocol.DeviceID]map[protocol.DeviceID]gossipedAddresses // deviceID -> gossiping deviceID -> addresses
 
-	foldersRunning int32 // for testing only
+	foldersRunning int32  // for testing only
+	requestCounter uint32 // spreads requests over the connections to a device
 }
 
 type folderFactory func(*Model, config.FolderConfiguration, versioner.Versioner, fs.Filesystem) service
@@ -129,6 +159,7 @@ var (
 	// errors about why a connection is closed
 	errIgnoredFolderRemoved = errors.New("folder no longer ignored")
 	errReplacingConnection  = errors.New("replacing connection")
+	errNoPrimaryConnection  = errors.New("no main connection to add to")
 )
 
 // NewModel creates and starts a new model. The model starts in read-only mode,
@@ -160,23 +191,34 @@ func NewModel(cfg *config.Wrapper, id protocol.DeviceID, clientName, clientVersi
 		folderRunnerTokens:  make(map[string][]suture.ServiceToken),
 		folderStatRefs:      make(map[string]*stats.FolderStatisticsReference),
 		conn:                make(map[protocol.DeviceID]connections.Connection),
+		streams:             make(map[protocol.DeviceID][]connections.Connection),
 		connRequestLimiters: make(map[protocol.DeviceID]*byteSemaphore),
 		closed:              make(map[protocol.DeviceID]chan struct{}),
 		helloMessages:       make(map[protocol.DeviceID]protocol.HelloResult),
 		deviceDownloads:     make(map[protocol.DeviceID]*deviceDownloadState),
 		remotePausedFolders: make(map[protocol.DeviceID][]string),
+		clusterConfigs:      make(map[protocol.DeviceID]bool),
+		gossipedAddresses:   make(map[protocol.DeviceID]map[protocol.DeviceID]gossipedAddresses),
 		fmut:                sync.NewRWMutex(),
 		pmut:                sync.NewRWMutex(),
 	}
 	if cfg.Options().ProgressUpdateIntervalS > -1 {
 		m.Add(m.progressEmitter)
 	}
+	m.dbChecker = newDatabaseChecker(m, cfg)
+	m.Add(m.dbChecker)
 	scanLimiter.setCapacity(cfg.Options().MaxConcurrentScans)
 	cfg.Subscribe(m)
 
 	return m
 }
 
+// SetAddressBook sets the address book to share addresses from and learn
+// them into. It must be called before any connections are added.
+func (m *Model) SetAddressBook(book AddressBook) {
+	m.addressBook = book
+}
+
 // StartDeadlockDetector starts a deadlock detector on the models locks which
 // causes panics in case the locks cannot be acquired in the given timeout
 // period.
@@ -524,10 +566,12 @@ type ConnectionInfo struct {
 	Address       string
 	ClientVersion string
 	Type          string
+	Proxy         string
+	Streams       []ConnectionInfo // one for each connection to the device
 }
 
 func (info ConnectionInfo) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	res := map[string]interface{}{
 		"at":            info.At,
 		"inBytesTotal":  info.InBytesTotal,
 		"outBytesTotal": info.OutBytesTotal,
@@ -536,7 +580,25 @@ func (info ConnectionInfo) MarshalJSON() ([]byte, error) {
 		"address":       info.Address,
 		"clientVersion": info.ClientVersion,
 		"type":          info.Type,
-	})
+		"proxy":         info.Proxy,
+	}
+	if info.Streams != nil {
+		res["streams"] = info.Streams
+	}
+	return json.Marshal(res)
+}
+
+func newStreamInfo(conn connections.Connection) ConnectionInfo {
+	ci := ConnectionInfo{
+		Statistics: conn.Statistics(),
+		Connected:  true,
+		Type:       conn.Type(),
+		Proxy:      conn.Proxy(),
+	}
+	if addr := conn.RemoteAddr(); addr != nil {
+		ci.Address = addr.String()
+	}
+	return ci
 }
 
 // ConnectionStats returns a map with connection statistics for each device.
@@ -559,11 +621,22 @@ func (m *Model) ConnectionStats() map[string]interface{} {
 		}
 		if conn, ok := m.conn[device]; ok {
 			ci.Type = conn.Type()
+			ci.Proxy = conn.Proxy()
 			ci.Connected = ok
 			ci.Statistics = conn.Statistics()
 			if addr := conn.RemoteAddr(); addr != nil {
 				ci.Address = addr.String()
 			}
+
+			// The device totals cover all the connections to it, each of
+			// which is also reported on its own.
+			ci.Streams = []ConnectionInfo{newStreamInfo(conn)}
+			for _, stream := range m.streams[device] {
+				si := newStreamInfo(stream)
+				ci.InBytesTotal += si.InBytesTotal
+				ci.OutBytesTotal += si.OutBytesTotal
+				ci.Streams = append(ci.Streams, si)
+			}
 		}
 
 		conns[device.String()] = ci
@@ -967,6 +1040,7 @@ func (m *Model) ClusterConfig(deviceID protocol.DeviceID, cm protocol.ClusterCon
 	m.pmut.RLock()
 	conn, ok := m.conn[deviceID]
 	hello := m.helloMessages[deviceID]
+	update := m.clusterConfigs[deviceID]
 	m.pmut.RUnlock()
 	if !ok {
 		panic("bug: ClusterConfig called on closed or nonexistent connection")
@@ -975,6 +1049,19 @@ func (m *Model) ClusterConfig(deviceID protocol.DeviceID, cm protocol.ClusterCon
 	changed := false
 	deviceCfg := m.cfg.Devices()[deviceID]
 
+	if update {
+		// The device sends further cluster configs, as we said it may in
+		// our hello, to update the addresses it sees others at. The
+		// folders were set up by the first one.
+		m.pmut.Lock()
+		m.addGossipedAddressesLocked(deviceCfg, cm)
+		m.pmut.Unlock()
+		if deviceCfg.Introducer {
+			m.learnAddresses(deviceID, cm)
+		}
+		return
+	}
+
 	// See issue #3802 - in short, we can't send modern symlink entries to older
 	// clients.
 	dropSymlinks := false
@@ -1101,9 +1188,12 @@ func (m *Model) ClusterConfig(deviceID protocol.DeviceID, cm protocol.ClusterCon
 
 	m.pmut.Lock()
 	m.remotePausedFolders[deviceID] = paused
+	m.clusterConfigs[deviceID] = true
+	m.addGossipedAddressesLocked(deviceCfg, cm)
 	m.pmut.Unlock()
 
-	// This breaks if we send multiple CM messages during the same connection.
+	// Further cluster configs on this connection are taken as updates to
+	// the addresses above.
 	if len(tempIndexFolders) > 0 {
 		m.pmut.RLock()
 		conn, ok := m.conn[deviceID]
@@ -1116,6 +1206,7 @@ func (m *Model) ClusterConfig(deviceID protocol.DeviceID, cm protocol.ClusterCon
 	}
 
 	if deviceCfg.Introducer {
+		m.learnAddresses(deviceID, cm)
 		foldersDevices, introduced := m.handleIntroductions(deviceCfg, cm)
 		if introduced {
 			changed = true
@@ -1262,7 +1353,7 @@ func (m *Model) handleAutoAccepts(deviceCfg config.DeviceConfiguration, folder p
 				continue
 			}
 
-			fcfg := config.NewFolderConfiguration(m.id, folder.ID, folder.Label, fs.FilesystemTypeBasic, filepath.Join(defaultPath, path))
+			fcfg := m.cfg.Defaults().NewFolder(m.id, folder.ID, folder.Label, fs.FilesystemTypeBasic, filepath.Join(defaultPath, path))
 			fcfg.Devices = append(fcfg.Devices, config.FolderDeviceConfiguration{
 				DeviceID: deviceCfg.DeviceID,
 			})
@@ -1295,24 +1386,165 @@ func (m *Model) handleAutoAccepts(deviceCfg config.DeviceConfiguration, folder p
 	}
 }
 
-func (m *Model) introduceDevice(device protocol.Device, introducerCfg config.DeviceConfiguration) {
-	addresses := []string{"dynamic"}
-	for _, addr := range device.Addresses {
-		if addr != "dynamic" {
-			addresses = append(addresses, addr)
+// addGossipedAddressesLocked keeps the addresses the device observed for
+// the others in the cluster config, for as long as it's connected and at
+// most maxGossipedAddressAge. Only addresses we may dial are kept.
+func (m *Model) addGossipedAddressesLocked(fromCfg config.DeviceConfiguration, cm protocol.ClusterConfig) {
+	if !m.cfg.Options().AddressGossipEnabled || fromCfg.IgnoreGossipedAddresses {
+		return
+	}
+	from := fromCfg.DeviceID
+	now := time.Now()
+	for _, folder := range cm.Folders {
+		for _, device := range folder.Devices {
+			if device.ID == m.id || device.ID == from || len(device.ObservedAddresses) == 0 {
+				continue
+			}
+			var addrs []string
+			for _, addr := range device.ObservedAddresses {
+				if discover.UsableSharedAddress(addr) {
+					addrs = append(addrs, addr)
+				} else {
+					l.Debugf("Ignoring address %s for %s gossiped by %s", addr, device.ID, from)
+				}
+			}
+			gossiped, ok := m.gossipedAddresses[device.ID]
+			if !ok {
+				gossiped = make(map[protocol.DeviceID]gossipedAddresses)
+				m.gossipedAddresses[device.ID] = gossiped
+			}
+			if len(addrs) == 0 {
+				delete(gossiped, from)
+				continue
+			}
+			gossiped[from] = gossipedAddresses{
+				addresses: addrs,
+				received:  now,
+			}
+		}
+	}
+}
+
+// GossipedAddresses returns the addresses where the devices we're connected
+// to see the given device, as of at most maxGossipedAddressAge ago.
+func (m *Model) GossipedAddresses(device protocol.DeviceID) []string {
+	m.pmut.RLock()
+	defer m.pmut.RUnlock()
+	var addrs []string
+	for _, gossiped := range m.gossipedAddresses[device] {
+		if time.Since(gossiped.received) < maxGossipedAddressAge {
+			addrs = append(addrs, gossiped.addresses...)
+		}
+	}
+	return util.UniqueStrings(addrs)
+}
+
+// observedAddresses returns the addresses we see the connected devices at,
+// for those we may tell others about.
+func (m *Model) observedAddresses() map[protocol.DeviceID]string {
+	observed := make(map[protocol.DeviceID]string)
+	if !m.cfg.Options().AddressGossipEnabled {
+		return observed
+	}
+	devices := m.cfg.Devices()
+	m.pmut.RLock()
+	defer m.pmut.RUnlock()
+	for device, conn := range m.conn {
+		if devices[device].HideObservedAddresses {
+			continue
+		}
+		if addr, ok := connections.ObservedAddress(conn); ok {
+			observed[device] = addr
+		}
+	}
+	return observed
+}
+
+// sendClusterConfigUpdates sends a new cluster config, with the address we
+// see the device that just connected at, to the other connected devices
+// that take updates and have a folder in common with it.
+func (m *Model) sendClusterConfigUpdates(connected protocol.DeviceID, conn connections.Connection) {
+	if !m.cfg.Options().AddressGossipEnabled {
+		return
+	}
+	if dev, ok := m.cfg.Device(connected); !ok || dev.HideObservedAddresses {
+		return
+	}
+	if _, ok := connections.ObservedAddress(conn); !ok {
+		return
+	}
+
+	m.pmut.RLock()
+	others := make(map[protocol.DeviceID]connections.Connection)
+	for device, other := range m.conn {
+		if device != connected && m.helloMessages[device].ClusterConfigUpdates {
+			others[device] = other
 		}
 	}
+	m.pmut.RUnlock()
 
+	for device, other := range others {
+		cm := m.generateClusterConfig(device)
+		if observesDevice(cm, connected) {
+			other.ClusterConfig(cm)
+		}
+	}
+}
+
+// observesDevice returns true if the cluster config has observed addresses
+// for the device.
+func observesDevice(cm protocol.ClusterConfig, device protocol.DeviceID) bool {
+	for _, folder := range cm.Folders {
+		for _, dev := range folder.Devices {
+			if dev.ID == device && len(dev.ObservedAddresses) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// learnAddresses adds the addresses the introducer shared for the devices
+// in the cluster config to the address book.
+func (m *Model) learnAddresses(introducer protocol.DeviceID, cm protocol.ClusterConfig) {
+	if m.addressBook == nil {
+		return
+	}
+	for _, folder := range cm.Folders {
+		for _, device := range folder.Devices {
+			if device.ID != m.id && len(device.LearnedAddresses) > 0 {
+				m.addressBook.Learn(introducer, device.ID, device.LearnedAddresses)
+			}
+		}
+	}
+}
+
+func (m *Model) introduceDevice(device protocol.Device, introducerCfg config.DeviceConfiguration) {
 	l.Infof("Adding device %v to config (vouched for by introducer %v)", device.ID, introducerCfg.DeviceID)
-	newDeviceCfg := config.DeviceConfiguration{
-		DeviceID:     device.ID,
-		Name:         device.Name,
-		Compression:  introducerCfg.Compression,
-		Addresses:    addresses,
-		CertName:     device.CertName,
-		IntroducedBy: introducerCfg.DeviceID,
+	defaults := m.cfg.Defaults()
+	newDeviceCfg := defaults.NewDevice(device.ID, device.Name)
+	if !defaults.HasDevice() {
+		// Without a default device to go by, we talk to it like we talk to
+		// the introducer.
+		newDeviceCfg.Compression = introducerCfg.Compression
+	}
+
+	// The addresses of the default device, usually just "dynamic", and
+	// those the introducer knows.
+	known := make(map[string]bool, len(newDeviceCfg.Addresses))
+	for _, addr := range newDeviceCfg.Addresses {
+		known[addr] = true
+	}
+	for _, addr := range device.Addresses {
+		if !known[addr] {
+			newDeviceCfg.Addresses = append(newDeviceCfg.Addresses, addr)
+			known[addr] = true
+		}
 	}
 
+	newDeviceCfg.CertName = device.CertName
+	newDeviceCfg.IntroducedBy = introducerCfg.DeviceID
+
 	// The introducers' introducers are also our introducers.
 	if device.Introducer {
 		l.Infof("Device %v is now also an introducer", device.ID)
@@ -1333,14 +1565,26 @@ func (m *Model) Closed(conn protocol.Connection, err error) {
 		m.progressEmitter.temporaryIndexUnsubscribe(conn)
 	}
 	delete(m.conn, device)
+	streams := m.streams[device]
+	delete(m.streams, device)
 	delete(m.connRequestLimiters, device)
 	delete(m.helloMessages, device)
 	delete(m.deviceDownloads, device)
 	delete(m.remotePausedFolders, device)
+	delete(m.clusterConfigs, device)
+	for _, gossiped := range m.gossipedAddresses {
+		delete(gossiped, device)
+	}
 	closed := m.closed[device]
 	delete(m.closed, device)
 	m.pmut.Unlock()
 
+	// The additional connections are no use without the one that carries
+	// the indexes.
+	for _, stream := range streams {
+		stream.Close(err)
+	}
+
 	l.Infof("Connection to %s at %s closed: %v", device, conn.Name(), err)
 	events.Default.Log(events.DeviceDisconnected, map[string]string{
 		"id":    device.String(),
@@ -1349,6 +1593,29 @@ func (m *Model) Closed(conn protocol.Connection, err error) {
 	close(closed)
 }
 
+// StreamClosed is called when an additional connection, added with
+// AddStream, has been closed.
+func (m *Model) StreamClosed(conn connections.Connection, err error) {
+	device := conn.ID()
+
+	m.pmut.Lock()
+	streams := m.streams[device]
+	for i := range streams {
+		if streams[i] == conn {
+			streams = append(streams[:i:i], streams[i+1:]...)
+			break
+		}
+	}
+	if len(streams) == 0 {
+		delete(m.streams, device)
+	} else {
+		m.streams[device] = streams
+	}
+	m.pmut.Unlock()
+
+	l.Infof("Additional connection to %s at %s closed: %v", device, conn.Name(), err)
+}
+
 // close will close the underlying connection for a given device
 func (m *Model) close(device protocol.DeviceID, err error) {
 	m.pmut.Lock()
@@ -1696,16 +1963,70 @@ func (m *Model) OnHello(remoteID protocol.DeviceID, addr net.Addr, hello protoco
 // GetHello is called when we are about to connect to some remote device.
 func (m *Model) GetHello(id protocol.DeviceID) protocol.HelloIntf {
 	name := ""
-	if _, ok := m.cfg.Device(id); ok {
+	var numConnections int32
+	if dev, ok := m.cfg.Device(id); ok {
 		name = m.cfg.MyName()
+		numConnections = int32(dev.NumConnections)
 	}
 	return &protocol.Hello{
-		DeviceName:    name,
-		ClientName:    m.clientName,
-		ClientVersion: m.clientVersion,
+		DeviceName:           name,
+		ClientName:           m.clientName,
+		ClientVersion:        m.clientVersion,
+		NumConnections:       numConnections,
+		ClusterConfigUpdates: true,
 	}
 }
 
+// NumConnections returns the number of connections to the device, counting
+// both the one added with AddConnection and those added with AddStream.
+func (m *Model) NumConnections(device protocol.DeviceID) int {
+	m.pmut.RLock()
+	defer m.pmut.RUnlock()
+	if _, ok := m.conn[device]; !ok {
+		return 0
+	}
+	return 1 + len(m.streams[device])
+}
+
+// RemoteNumConnections returns the number of connections the device said
+// it wants to have with us, when the connection to it was established.
+// Devices that don't know about more than one connection want one.
+func (m *Model) RemoteNumConnections(device protocol.DeviceID) int {
+	m.pmut.RLock()
+	hello := m.helloMessages[device]
+	m.pmut.RUnlock()
+	if hello.NumConnections < 1 {
+		return 1
+	}
+	return int(hello.NumConnections)
+}
+
+// AddStream adds an additional connection to an already connected device.
+// Block requests are spread over all the connections to a device, while
+// index updates and everything else keep going over the one added with
+// AddConnection, so that their ordering is kept. The connection is closed
+// if there isn't one to add it to.
+func (m *Model) AddStream(conn connections.Connection) {
+	deviceID := conn.ID()
+
+	m.pmut.Lock()
+	if _, ok := m.conn[deviceID]; !ok {
+		m.pmut.Unlock()
+		l.Infoln("Not adding connection", conn, "to", deviceID, "as it is no longer connected")
+		conn.Close(errNoPrimaryConnection)
+		return
+	}
+	m.streams[deviceID] = append(m.streams[deviceID], conn)
+	l.Infof("Added connection to %s at %s (%d in total)", deviceID, conn, 1+len(m.streams[deviceID]))
+	conn.Start()
+	m.pmut.Unlock()
+
+	// The other side needs a cluster config before it accepts requests on
+	// this connection too, which it then ignores.
+	cm := m.generateClusterConfig(deviceID)
+	conn.ClusterConfig(cm)
+}
+
 // AddConnection adds a new peer connection to the model. An initial index will
 // be sent to the connected peer, thereafter index updates whenever the local
 // folder changes.
@@ -1768,6 +2089,7 @@ func (m *Model) AddConnection(conn connections.Connection, hello protocol.HelloR
 	// Acquires fmut, so has to be done outside of pmut.
 	cm := m.generateClusterConfig(deviceID)
 	conn.ClusterConfig(cm)
+	go m.sendClusterConfigUpdates(deviceID, conn)
 
 	if (device.Name == "" || m.cfg.Options().OverwriteRemoteDevNames) && hello.DeviceName != "" {
 		device.Name = hello.DeviceName
@@ -2038,6 +2360,13 @@ func (m *Model) diskChangeDetected(folderCfg config.FolderConfiguration, files [
 func (m *Model) requestGlobal(deviceID protocol.DeviceID, folder, name string, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
 	m.pmut.RLock()
 	nc, ok := m.conn[deviceID]
+	if streams := m.streams[deviceID]; ok && len(streams) > 0 {
+		// Take turns between all the connections to the device, the main
+		// one included.
+		if i := int(atomic.AddUint32(&m.requestCounter, 1) % uint32(len(streams)+1)); i > 0 {
+			nc = streams[i-1]
+		}
+	}
 	m.pmut.RUnlock()
 
 	if !ok {
@@ -2147,6 +2476,9 @@ func (m *Model) numHashers(folder string) int {
 func (m *Model) generateClusterConfig(device protocol.DeviceID) protocol.ClusterConfig {
 	var message protocol.ClusterConfig
 
+	// Takes pmut, so has to be done outside of fmut.
+	observed := m.observedAddresses()
+
 	m.fmut.RLock()
 	defer m.fmut.RUnlock()
 
@@ -2181,6 +2513,12 @@ func (m *Model) generateClusterConfig(device protocol.DeviceID) protocol.Cluster
 				CertName:    deviceCfg.CertName,
 				Introducer:  deviceCfg.Introducer,
 			}
+			if m.addressBook != nil {
+				protocolDevice.LearnedAddresses = m.addressBook.Addresses(deviceCfg.DeviceID)
+			}
+			if addr, ok := observed[deviceCfg.DeviceID]; ok {
+				protocolDevice.ObservedAddresses = []string{addr}
+			}
 
 			if fs != nil {
 				if deviceCfg.DeviceID == m.id {
@@ -2233,6 +2571,15 @@ func (m *Model) WatchError(folder string) error {
 	return m.folderRunners[folder].WatchError()
 }
 
+func (m *Model) WatchState(folder string) (fs.WatchState, error) {
+	m.fmut.RLock()
+	defer m.fmut.RUnlock()
+	if err := m.checkFolderRunningLocked(folder); err != nil {
+		return fs.WatchState{}, err
+	}
+	return m.folderRunners[folder].WatchState(), nil
+}
+
 func (m *Model) Override(folder string) {
 	// Grab the runner and the file set.
 
@@ -17,6 +17,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/fs"
 	"github.com/syncthing/syncthing/lib/ignore"
 	"github.com/syncthing/syncthing/lib/osutil"
@@ -408,6 +410,10 @@ func (f *fakeConnection) Type() string {
 func (f *fakeConnection) Transport() string {
 	return "fake"
 }
+func (f *fakeConnection) Proxy() string {
+	return ""
+}
+
 func (f *fakeConnection) Priority() int {
 	return 9000
 }
@@ -553,6 +559,7 @@ func TestDeviceRename(t *testing.T) {
 		ClientVersion: "v0.9.4",
 	}
 	defer testOs.Remove("testdata/tmpconfig.xml")
+	defer testOs.RemoveAll("testdata/" + config.HistoryDir)
 
 	rawCfg := config.New(device1)
 	rawCfg.Devices = []config.DeviceConfiguration{
@@ -1085,6 +1092,408 @@ func TestIntroducer(t *testing.T) {
 	}
 }
 
+func TestIntroducerUsesDefaultDevice(t *testing.T) {
+	cfg := config.Configuration{
+		Version: config.CurrentVersion,
+		Devices: []config.DeviceConfiguration{
+			{
+				DeviceID:   device1,
+				Introducer: true,
+			},
+		},
+		Folders: []config.FolderConfiguration{
+			{
+				ID:   "folder1",
+				Path: "testdata",
+				Devices: []config.FolderDeviceConfiguration{
+					{DeviceID: device1},
+				},
+			},
+		},
+	}
+	cfg.Defaults.Device.Compression = protocol.CompressAlways
+	cfg.Defaults.Device.MaxRecvKbps = 1000
+	cfg.Defaults.Device.Addresses = []string{"dynamic", "tcp://192.0.2.42:22000"}
+	wcfg, m := newState(cfg)
+	defer os.Remove(wcfg.ConfigPath())
+	m.ClusterConfig(device1, protocol.ClusterConfig{
+		Folders: []protocol.Folder{
+			{
+				ID: "folder1",
+				Devices: []protocol.Device{
+					{
+						ID:        device2,
+						Addresses: []string{"dynamic", "tcp://192.0.2.43:22000"},
+					},
+				},
+			},
+		},
+	})
+
+	newDev, ok := wcfg.Device(device2)
+	if !ok {
+		t.Fatal("device 2 should have been introduced")
+	}
+	if newDev.Compression != protocol.CompressAlways || newDev.MaxRecvKbps != 1000 || newDev.IntroducedBy != device1 {
+		t.Errorf("expected the default device settings, got %+v", newDev)
+	}
+	if expected := []string{"dynamic", "tcp://192.0.2.42:22000", "tcp://192.0.2.43:22000"}; !reflect.DeepEqual(newDev.Addresses, expected) {
+		t.Errorf("expected addresses %v, got %v", expected, newDev.Addresses)
+	}
+}
+
+func TestIntroducerInheritsCompression(t *testing.T) {
+	wcfg, m := newState(config.Configuration{
+		Version: config.CurrentVersion,
+		Devices: []config.DeviceConfiguration{
+			{
+				DeviceID:    device1,
+				Introducer:  true,
+				Compression: protocol.CompressNever,
+			},
+		},
+		Folders: []config.FolderConfiguration{
+			{
+				ID:   "folder1",
+				Path: "testdata",
+				Devices: []config.FolderDeviceConfiguration{
+					{DeviceID: device1},
+				},
+			},
+		},
+	})
+	defer os.Remove(wcfg.ConfigPath())
+	m.ClusterConfig(device1, protocol.ClusterConfig{
+		Folders: []protocol.Folder{
+			{
+				ID: "folder1",
+				Devices: []protocol.Device{
+					{ID: device2},
+				},
+			},
+		},
+	})
+
+	newDev, ok := wcfg.Device(device2)
+	if !ok {
+		t.Fatal("device 2 should have been introduced")
+	}
+	if newDev.Compression != protocol.CompressNever {
+		t.Errorf("expected the compression of the introducer, got %v", newDev.Compression)
+	}
+}
+
+type fakeAddressBook map[protocol.DeviceID][]string
+
+func (b fakeAddressBook) Addresses(device protocol.DeviceID) []string {
+	return b[device]
+}
+
+func (b fakeAddressBook) Learn(from, device protocol.DeviceID, addresses []string) {
+	b[device] = addresses
+}
+
+func TestAddressBookSharing(t *testing.T) {
+	wcfg, m := newState(config.Configuration{
+		Version: config.CurrentVersion,
+		Devices: []config.DeviceConfiguration{
+			{DeviceID: device1, Introducer: true},
+			{DeviceID: device2},
+		},
+		Folders: []config.FolderConfiguration{
+			{
+				ID:   "folder1",
+				Path: "testdata",
+				Devices: []config.FolderDeviceConfiguration{
+					{DeviceID: device1},
+					{DeviceID: device2},
+				},
+			},
+		},
+	})
+	defer os.Remove(wcfg.ConfigPath())
+	book := fakeAddressBook{device2: {"tcp://192.0.2.42:22000"}}
+	m.SetAddressBook(book)
+
+	// The addresses we know are shared with the devices we're connected to.
+
+	cm := m.generateClusterConfig(device1)
+	for _, dev := range cm.Folders[0].Devices {
+		if dev.ID == device2 && !reflect.DeepEqual(dev.LearnedAddresses, book[device2]) {
+			t.Error("Unexpected learned addresses", dev.LearnedAddresses)
+		}
+	}
+
+	// And we learn those shared by our introducers, but not by others.
+
+	shared := protocol.ClusterConfig{
+		Folders: []protocol.Folder{
+			{
+				ID: "folder1",
+				Devices: []protocol.Device{
+					{ID: device2, LearnedAddresses: []string{"tcp://192.0.2.43:22000"}},
+				},
+			},
+		},
+	}
+	m.ClusterConfig(device2, shared)
+	if addrs := book[device2]; !reflect.DeepEqual(addrs, []string{"tcp://192.0.2.42:22000"}) {
+		t.Error("Addresses shouldn't be learned from others, got", addrs)
+	}
+	m.ClusterConfig(device1, shared)
+	if addrs := book[device2]; !reflect.DeepEqual(addrs, []string{"tcp://192.0.2.43:22000"}) {
+		t.Error("Addresses should be learned from introducers, got", addrs)
+	}
+}
+
+func TestGossipedAddresses(t *testing.T) {
+	wcfg, m := newState(config.Configuration{
+		Version: config.CurrentVersion,
+		Devices: []config.DeviceConfiguration{
+			{DeviceID: device1},
+			{DeviceID: device2, IgnoreGossipedAddresses: true},
+		},
+		Options: config.OptionsConfiguration{
+			AddressGossipEnabled: true,
+		},
+		Folders: []config.FolderConfiguration{
+			{
+				ID:   "folder1",
+				Path: "testdata",
+				Devices: []config.FolderDeviceConfiguration{
+					{DeviceID: device1},
+					{DeviceID: device2},
+				},
+			},
+		},
+	})
+	defer os.Remove(wcfg.ConfigPath())
+
+	gossip := func(about protocol.DeviceID, addrs ...string) protocol.ClusterConfig {
+		return protocol.ClusterConfig{
+			Folders: []protocol.Folder{
+				{
+					ID: "folder1",
+					Devices: []protocol.Device{
+						{ID: about, ObservedAddresses: addrs},
+					},
+				},
+			},
+		}
+	}
+
+	// Device 1 tells us where it sees device 2, which we ignore the other
+	// way around.
+
+	m.ClusterConfig(device1, gossip(device2, "tcp://192.0.2.42:22000"))
+	m.ClusterConfig(device2, gossip(device1, "tcp://192.0.2.43:22000"))
+	if addrs := m.GossipedAddresses(device2); !reflect.DeepEqual(addrs, []string{"tcp://192.0.2.42:22000"}) {
+		t.Error("Unexpected gossiped addresses", addrs)
+	}
+	if addrs := m.GossipedAddresses(device1); len(addrs) != 0 {
+		t.Error("Addresses from device 2 should be ignored, got", addrs)
+	}
+
+	// Further cluster configs update them, and only addresses we may dial
+	// are taken.
+
+	m.ClusterConfig(device1, gossip(device2, "tcp://127.0.0.1:22000", "tcp://[fe80::1]:22000", "tcp://0.0.0.0:22000", "dynamic+https://192.0.2.1/", "tcp://example.com:22000", "tcp://192.0.2.44:22000"))
+	if addrs := m.GossipedAddresses(device2); !reflect.DeepEqual(addrs, []string{"tcp://192.0.2.44:22000"}) {
+		t.Error("Unexpected gossiped addresses after update", addrs)
+	}
+
+	// Old ones aren't used.
+
+	m.pmut.Lock()
+	gossiped := m.gossipedAddresses[device2][device1]
+	gossiped.received = gossiped.received.Add(-maxGossipedAddressAge)
+	m.gossipedAddresses[device2][device1] = gossiped
+	m.pmut.Unlock()
+	if addrs := m.GossipedAddresses(device2); len(addrs) != 0 {
+		t.Error("Old gossiped addresses should not be used, got", addrs)
+	}
+	m.ClusterConfig(device1, gossip(device2, "tcp://192.0.2.42:22000"))
+
+	// They're forgotten when device 1 goes away.
+
+	m.Closed(&fakeConnection{id: device1}, protocol.ErrTimeout)
+	if addrs := m.GossipedAddresses(device2); len(addrs) != 0 {
+		t.Error("Gossiped addresses should be forgotten, got", addrs)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	m := setupModel(defaultCfgWrapper)
+	defer m.Stop()
+
+	// An additional connection needs a main one to go with.
+
+	orphan := &fakeConnection{id: device1, model: m}
+	m.AddStream(orphan)
+	if !orphan.Closed() {
+		t.Error("Connection without a main one should be closed")
+	}
+
+	requests := make(map[*fakeConnection]int)
+	countRequests := func(fc *fakeConnection) {
+		fc.requestFn = func(folder, name string, offset int64, size int, hash []byte, fromTemporary bool) ([]byte, error) {
+			requests[fc]++
+			return nil, nil
+		}
+	}
+
+	// Devices say how many connections they want in their hello, and want
+	// one if they don't.
+
+	fc := addFakeConn(m, device1)
+	if n := m.RemoteNumConnections(device1); n != 1 {
+		t.Error("Expected one connection wanted, got", n)
+	}
+
+	countRequests(fc)
+	stream := &fakeConnection{id: device1, model: m}
+	countRequests(stream)
+	m.AddStream(stream)
+	if n := m.NumConnections(device1); n != 2 {
+		t.Fatal("Expected two connections, got", n)
+	}
+
+	// Requests take turns between the connections.
+
+	for i := 0; i < 10; i++ {
+		if _, err := m.requestGlobal(device1, "default", "foo", 0, 1, nil, 0, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests[fc] != 5 || requests[stream] != 5 {
+		t.Errorf("Requests should be spread evenly, got %d and %d", requests[fc], requests[stream])
+	}
+
+	// Each connection is reported on its own.
+
+	conns := m.ConnectionStats()["connections"].(map[string]ConnectionInfo)
+	if streams := conns[device1.String()].Streams; len(streams) != 2 {
+		t.Error("Expected two streams, got", len(streams))
+	}
+
+	// An additional connection closes on its own, while closing the main
+	// connection closes the others too.
+
+	m.StreamClosed(stream, protocol.ErrTimeout)
+	if n := m.NumConnections(device1); n != 1 {
+		t.Error("Expected one connection, got", n)
+	}
+	stream = &fakeConnection{id: device1, model: m}
+	m.AddStream(stream)
+	m.Closed(fc, protocol.ErrTimeout)
+	if !stream.Closed() {
+		t.Error("Additional connection should be closed with the main one")
+	}
+	if n := m.NumConnections(device1); n != 0 {
+		t.Error("Expected no connections, got", n)
+	}
+
+	m.AddConnection(&fakeConnection{id: device1, model: m}, protocol.HelloResult{NumConnections: 3})
+	if n := m.RemoteNumConnections(device1); n != 3 {
+		t.Error("Expected three connections wanted, got", n)
+	}
+}
+
+// observedConnection is a connection to a device at a TCP address, which
+// keeps the cluster configs sent on it.
+type observedConnection struct {
+	*fakeConnection
+	addr    *net.TCPAddr
+	configs chan protocol.ClusterConfig
+}
+
+func (c *observedConnection) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func (c *observedConnection) Type() string {
+	return "tcp-client"
+}
+
+func (c *observedConnection) ClusterConfig(cm protocol.ClusterConfig) {
+	c.configs <- cm
+}
+
+func TestClusterConfigUpdates(t *testing.T) {
+	wcfg := createTmpWrapper(config.Configuration{
+		Version: config.CurrentVersion,
+		Devices: []config.DeviceConfiguration{
+			{DeviceID: device1},
+			{DeviceID: device2},
+		},
+		Options: config.OptionsConfiguration{
+			AddressGossipEnabled: true,
+		},
+		Folders: []config.FolderConfiguration{
+			{
+				ID:   "folder1",
+				Path: "testdata",
+				Devices: []config.FolderDeviceConfiguration{
+					{DeviceID: device1},
+					{DeviceID: device2},
+				},
+			},
+		},
+	})
+	defer os.Remove(wcfg.ConfigPath())
+	m := setupModel(wcfg)
+	defer m.Stop()
+
+	conn := func(dev protocol.DeviceID, ip string) *observedConnection {
+		return &observedConnection{
+			fakeConnection: &fakeConnection{id: dev, model: m},
+			addr:           &net.TCPAddr{IP: net.ParseIP(ip), Port: 22000},
+			configs:        make(chan protocol.ClusterConfig, 10),
+		}
+	}
+	receive := func(c *observedConnection) protocol.ClusterConfig {
+		t.Helper()
+		select {
+		case cm := <-c.configs:
+			return cm
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for a cluster config")
+		}
+		return protocol.ClusterConfig{}
+	}
+
+	// Device 1 takes updates, so it's told where we see device 2 once
+	// that connects.
+
+	conn1 := conn(device1, "192.0.2.1")
+	m.AddConnection(conn1, protocol.HelloResult{ClusterConfigUpdates: true})
+	if cm := receive(conn1); observesDevice(cm, device2) {
+		t.Error("Device 2 observed before it connected")
+	}
+	conn2 := conn(device2, "192.0.2.2")
+	m.AddConnection(conn2, protocol.HelloResult{})
+	receive(conn2)
+	cm := receive(conn1)
+	if !observesDevice(cm, device2) {
+		t.Fatal("Update doesn't have the address of device 2")
+	}
+	for _, dev := range cm.Folders[0].Devices {
+		if dev.ID == device2 && !reflect.DeepEqual(dev.ObservedAddresses, []string{"tcp://192.0.2.2:22000"}) {
+			t.Error("Unexpected observed addresses", dev.ObservedAddresses)
+		}
+	}
+
+	// Device 2 doesn't take updates.
+
+	m.Closed(conn1, protocol.ErrTimeout)
+	m.AddConnection(conn(device1, "192.0.2.1"), protocol.HelloResult{})
+	select {
+	case <-conn2.configs:
+		t.Error("Update sent to a device that doesn't take them")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestIssue4897(t *testing.T) {
 	wcfg, m := newState(config.Configuration{
 		Devices: []config.DeviceConfiguration{
@@ -1208,6 +1617,35 @@ func TestAutoAcceptNewFolder(t *testing.T) {
 	}
 }
 
+func TestAutoAcceptUsesDefaultFolder(t *testing.T) {
+	cfg := defaultAutoAcceptCfg.Copy()
+	cfg.Defaults.Folder.IgnorePerms = true
+	cfg.Defaults.Folder.RescanIntervalS = 600
+	cfg.Defaults.Folder.Versioning = config.VersioningConfiguration{
+		Type:   "simple",
+		Params: map[string]string{"keep": "3"},
+	}
+	wcfg, m := newState(cfg)
+	defer os.Remove(wcfg.ConfigPath())
+	id := srand.String(8)
+	defer os.RemoveAll(id)
+	m.ClusterConfig(device1, protocol.ClusterConfig{
+		Folders: []protocol.Folder{
+			{
+				ID:    id,
+				Label: id,
+			},
+		},
+	})
+	fcfg, ok := wcfg.Folder(id)
+	if !ok || !fcfg.SharedWith(device1) || !fcfg.SharedWith(myID) {
+		t.Fatal("expected shared", id)
+	}
+	if !fcfg.IgnorePerms || fcfg.RescanIntervalS != 600 || fcfg.Versioning.Type != "simple" || fcfg.Versioning.Params["keep"] != "3" {
+		t.Errorf("expected the default folder settings, got %+v", fcfg)
+	}
+}
+
 func TestAutoAcceptNewFolderFromTwoDevices(t *testing.T) {
 	wcfg, m := newState(defaultAutoAcceptCfg)
 	defer os.Remove(wcfg.ConfigPath())
@@ -3744,3 +4182,29 @@ func TestSanitizePath(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckDatabase(t *testing.T) {
+	m := setupModel(defaultCfgWrapper)
+	defer m.Stop()
+
+	if err := m.CheckDatabase("nonexistent"); err != errFolderMissing {
+		t.Fatal("Expected a missing folder error, got", err)
+	}
+
+	sub := events.Default.Subscribe(events.DatabaseCheckCompleted)
+	defer events.Default.Unsubscribe(sub)
+
+	// The check happens in the background and is reported by event.
+
+	if err := m.CheckDatabase("default"); err != nil {
+		t.Fatal(err)
+	}
+	ev, err := sub.Poll(10 * time.Second)
+	if err != nil {
+		t.Fatal("No DatabaseCheckCompleted event:", err)
+	}
+	data := ev.Data.(map[string]interface{})
+	if data["folder"] != "default" || data["repaired"] != false {
+		t.Errorf("Unexpected event data %v", data)
+	}
+}
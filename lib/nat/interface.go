@@ -24,3 +24,9 @@ type Device interface {
 	AddPortMapping(protocol Protocol, internalPort, externalPort int, description string, duration time.Duration) (int, error)
 	GetExternalIPAddress() (net.IP, error)
 }
+
+// A Superseder is a Device which maps ports on the same gateway as other
+// devices, which are then left unused.
+type Superseder interface {
+	Supersedes() []string // the IDs of the other devices
+}
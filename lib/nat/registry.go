@@ -48,5 +48,13 @@ func discoverAll(renewal, timeout time.Duration) map[string]Device {
 	close(c)
 	<-done
 
+	for _, dev := range nats {
+		if s, ok := dev.(Superseder); ok {
+			for _, id := range s.Supersedes() {
+				delete(nats, id)
+			}
+		}
+	}
+
 	return nats
 }
@@ -0,0 +1,46 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package nat
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+type fakeDevice struct {
+	id         string
+	supersedes []string
+}
+
+func (d fakeDevice) ID() string                { return d.id }
+func (d fakeDevice) GetLocalIPAddress() net.IP { return nil }
+func (d fakeDevice) AddPortMapping(protocol Protocol, internalPort, externalPort int, description string, duration time.Duration) (int, error) {
+	return externalPort, nil
+}
+func (d fakeDevice) GetExternalIPAddress() (net.IP, error) { return nil, nil }
+func (d fakeDevice) Supersedes() []string                  { return d.supersedes }
+
+func TestDiscoverAllSuperseded(t *testing.T) {
+	defer func(orig []DiscoverFunc) {
+		providers = orig
+	}(providers)
+
+	providers = []DiscoverFunc{
+		func(renewal, timeout time.Duration) []Device {
+			return []Device{fakeDevice{id: "old@gw"}, fakeDevice{id: "other@gw"}}
+		},
+		func(renewal, timeout time.Duration) []Device {
+			return []Device{fakeDevice{id: "new@gw", supersedes: []string{"old@gw"}}}
+		},
+	}
+
+	nats := discoverAll(time.Minute, time.Second)
+	if _, ok := nats["old@gw"]; ok || len(nats) != 2 {
+		t.Error("Superseded device not removed:", nats)
+	}
+}
@@ -142,3 +142,19 @@ func (a Address) String() string {
 func (a Address) GoString() string {
 	return a.String()
 }
+
+// GlobalIPv6Among returns the first global IPv6 address among the
+// addresses, as those of an interface. Unique local addresses aren't
+// reachable from the outside, so those don't count.
+func GlobalIPv6Among(addrs []net.Addr) net.IP {
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipnet.IP.To4() == nil && ipnet.IP.IsGlobalUnicast() && ipnet.IP[0]&0xfe != 0xfc {
+			return ipnet.IP
+		}
+	}
+	return nil
+}
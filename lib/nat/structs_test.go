@@ -67,3 +67,18 @@ func TestMappingClearAddresses(t *testing.T) {
 	// Now try and remove the mapped port; prior to #4829 this deadlocked
 	natSvc.RemoveMapping(m)
 }
+
+func TestGlobalIPv6Among(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("192.168.1.2")},
+		&net.IPNet{IP: net.ParseIP("fe80::2")},
+		&net.IPNet{IP: net.ParseIP("fd00::2")},
+		&net.IPNet{IP: net.ParseIP("2001:db8::2")},
+	}
+	if ip := GlobalIPv6Among(addrs); !ip.Equal(net.ParseIP("2001:db8::2")) {
+		t.Error("Unexpected global address", ip)
+	}
+	if ip := GlobalIPv6Among(addrs[:3]); ip != nil {
+		t.Error("Unexpected global address", ip)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package pcp
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ipv6Gateway returns the router of the IPv6 default route, and the name of
+// the interface it's on, as the router is usually given by its link local
+// address.
+func ipv6Gateway() (net.IP, string) {
+	fd, err := os.Open("/proc/net/ipv6_route")
+	if err != nil {
+		l.Debugln("Failed to read IPv6 routes", err)
+		return nil, ""
+	}
+	defer fd.Close()
+	return parseIPv6Routes(fd)
+}
+
+// parseIPv6Routes returns the gateway of the default route with the lowest
+// metric in the routes, as listed by /proc/net/ipv6_route.
+func parseIPv6Routes(r io.Reader) (net.IP, string) {
+	const rtfGateway = 0x2
+
+	var gw net.IP
+	var intf string
+	bestMetric := uint64(1<<64 - 1)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		// destination, prefix length, source, prefix length, next hop,
+		// metric, reference count, use count, flags, interface
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 10 || fields[1] != "00" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[8], 16, 32)
+		if err != nil || flags&rtfGateway == 0 {
+			continue
+		}
+		metric, err := strconv.ParseUint(fields[5], 16, 32)
+		if err != nil || metric >= bestMetric {
+			continue
+		}
+		hop, err := hex.DecodeString(fields[4])
+		if err != nil || len(hop) != net.IPv6len || net.IP(hop).IsUnspecified() {
+			continue
+		}
+		gw, intf, bestMetric = net.IP(hop), fields[9], metric
+	}
+
+	return gw, intf
+}
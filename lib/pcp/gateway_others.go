@@ -0,0 +1,17 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// +build !linux
+
+package pcp
+
+import "net"
+
+// ipv6Gateway is only implemented on Linux, elsewhere PCP is only used on
+// the IPv4 gateway.
+func ipv6Gateway() (net.IP, string) {
+	return nil, ""
+}
@@ -0,0 +1,344 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package pcp implements port mappings with the Port Control Protocol
+// (RFC 6887). Besides port forwards on IPv4 NAT gateways, it's used to open
+// pinholes in the firewalls of IPv6 gateways, which don't translate
+// addresses but drop incoming connections.
+package pcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jackpal/gateway"
+	"github.com/syncthing/syncthing/lib/nat"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+func init() {
+	nat.Register(Discover)
+}
+
+const (
+	serverPort        = 5351
+	version           = 2
+	opAnnounce        = 0
+	opMap             = 1
+	responseBit       = 0x80
+	headerLen         = 24
+	mapLen            = 36
+	nonceLen          = 12
+	maxMessageLen     = 1100
+	initialRetransmit = 3 * time.Second
+)
+
+// The mapping nonce proves that a request comes from whoever created the
+// mapping. It's kept for as long as we run, so that we can renew our
+// mappings; those from a previous run are taken over once they expire.
+var nonce = []byte(rand.String(nonceLen))
+
+var errUnsupportedVersion = errors.New("PCP not supported by server")
+
+// A resultError is a result code other than success in a PCP response.
+type resultError byte
+
+var resultNames = map[resultError]string{
+	1:  "unsupported version",
+	2:  "not authorized",
+	3:  "malformed request",
+	4:  "unsupported opcode",
+	5:  "unsupported option",
+	6:  "malformed option",
+	7:  "network failure",
+	8:  "no resources",
+	9:  "unsupported protocol",
+	10: "user exceeded quota",
+	11: "cannot provide external address",
+	12: "address mismatch",
+	13: "excessive remote peers",
+}
+
+func (e resultError) Error() string {
+	if name, ok := resultNames[e]; ok {
+		return "PCP: " + name
+	}
+	return fmt.Sprintf("PCP: result code %d", byte(e))
+}
+
+// Discover returns the PCP servers on our IPv4 and IPv6 default gateways.
+func Discover(renewal, timeout time.Duration) []nat.Device {
+	var servers []*net.UDPAddr
+	if ip, err := gateway.DiscoverGateway(); err != nil {
+		l.Debugln("Failed to discover gateway", err)
+	} else if ip != nil && !ip.IsUnspecified() {
+		servers = append(servers, &net.UDPAddr{IP: ip, Port: serverPort})
+	}
+	locals := make([]net.IP, len(servers))
+	if ip, intf := ipv6Gateway(); ip != nil {
+		// Left to itself, the kernel talks to a link local router from our
+		// link local address, which is of no use for pinholes.
+		if local := globalIPv6(intf); local == nil {
+			l.Debugln("No global IPv6 address on", intf)
+		} else {
+			server := &net.UDPAddr{IP: ip, Port: serverPort}
+			if ip.IsLinkLocalUnicast() {
+				server.Zone = intf
+			}
+			servers = append(servers, server)
+			locals = append(locals, local)
+		}
+	}
+
+	var wg sync.WaitGroup
+	devices := make([]nat.Device, len(servers))
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server *net.UDPAddr) {
+			defer wg.Done()
+			l.Debugln("Probing for PCP on", server)
+			c := &client{server: server, local: locals[i], timeout: timeout}
+			if err := c.announce(); err != nil {
+				l.Debugln("No PCP available on", server, err)
+				return
+			}
+			localIP, err := c.localIP()
+			if err != nil {
+				l.Debugln("Failed to lookup local IP", err)
+			}
+			devices[i] = &wrapper{
+				renewal: renewal,
+				localIP: localIP,
+				client:  c,
+			}
+		}(i, server)
+	}
+	wg.Wait()
+
+	var res []nat.Device
+	for _, dev := range devices {
+		if dev != nil {
+			res = append(res, dev)
+		}
+	}
+	return res
+}
+
+type wrapper struct {
+	renewal time.Duration
+	localIP net.IP
+	client  *client
+
+	externalIP net.IP
+	mut        sync.Mutex
+}
+
+func (w *wrapper) ID() string {
+	return fmt.Sprintf("PCP@%s", w.client.server)
+}
+
+// Supersedes returns the NAT-PMP device on the same gateway, if it's an
+// IPv4 one. PCP servers also speak NAT-PMP, and there's no point in mapping
+// the same port twice.
+func (w *wrapper) Supersedes() []string {
+	if w.client.server.IP.To4() == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("NAT-PMP@%s", w.client.server.IP)}
+}
+
+func (w *wrapper) GetLocalIPAddress() net.IP {
+	return w.localIP
+}
+
+func (w *wrapper) AddPortMapping(protocol nat.Protocol, internalPort, externalPort int, description string, duration time.Duration) (int, error) {
+	// As with NAT-PMP, a lifetime of zero deletes the mapping.
+	if duration == 0 {
+		duration = w.renewal
+	}
+
+	// We have no preference for the external address, only for the port,
+	// which is ignored for IPv6 pinholes.
+	suggested := net.IPv6unspecified
+	if w.localIP.To4() != nil {
+		suggested = net.IPv4zero
+	}
+
+	m, err := w.client.addMapping(protocol, internalPort, externalPort, suggested, duration)
+	if err != nil {
+		return 0, err
+	}
+	l.Debugf("Mapped %s port %d to %s port %d on %s for %v", protocol, internalPort, m.ip, m.port, w.ID(), m.lifetime)
+
+	w.mut.Lock()
+	w.externalIP = m.ip
+	w.mut.Unlock()
+
+	return m.port, nil
+}
+
+func (w *wrapper) GetExternalIPAddress() (net.IP, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.externalIP == nil {
+		return nil, errors.New("no mapping yet")
+	}
+	return w.externalIP, nil
+}
+
+type client struct {
+	server  *net.UDPAddr
+	local   net.IP // the address to send from, or nil to let the kernel pick
+	timeout time.Duration
+}
+
+type mapping struct {
+	ip       net.IP
+	port     int
+	lifetime time.Duration
+}
+
+// announce checks whether the server speaks PCP.
+func (c *client) announce() error {
+	_, err := c.request(opAnnounce, 0, nil)
+	return err
+}
+
+func (c *client) addMapping(protocol nat.Protocol, internalPort, externalPort int, externalIP net.IP, lifetime time.Duration) (mapping, error) {
+	payload := make([]byte, mapLen)
+	copy(payload, nonce)
+	switch protocol {
+	case nat.TCP:
+		payload[12] = 6
+	case nat.UDP:
+		payload[12] = 17
+	default:
+		return mapping{}, fmt.Errorf("unsupported protocol %s", protocol)
+	}
+	binary.BigEndian.PutUint16(payload[16:], uint16(internalPort))
+	binary.BigEndian.PutUint16(payload[18:], uint16(externalPort))
+	copy(payload[20:], externalIP.To16())
+
+	res, err := c.request(opMap, lifetime, payload)
+	if err != nil {
+		return mapping{}, err
+	}
+	if len(res.payload) < mapLen || !bytes.Equal(res.payload[:nonceLen], nonce) {
+		return mapping{}, errors.New("PCP: unexpected map response")
+	}
+
+	return mapping{
+		ip:       net.IP(append([]byte(nil), res.payload[20:36]...)),
+		port:     int(binary.BigEndian.Uint16(res.payload[18:])),
+		lifetime: res.lifetime,
+	}, nil
+}
+
+func (c *client) localIP() (net.IP, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+func (c *client) dial() (*net.UDPConn, error) {
+	var laddr *net.UDPAddr
+	if c.local != nil {
+		laddr = &net.UDPAddr{IP: c.local}
+	}
+	return net.DialUDP("udp", laddr, c.server)
+}
+
+type response struct {
+	lifetime time.Duration
+	payload  []byte
+}
+
+// request sends the request to the server and waits for the response,
+// retransmitting with increasing intervals until the timeout.
+func (c *client) request(op byte, lifetime time.Duration, payload []byte) (response, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return response{}, err
+	}
+	defer conn.Close()
+
+	req := make([]byte, headerLen+len(payload))
+	req[0] = version
+	req[1] = op
+	binary.BigEndian.PutUint32(req[4:], uint32(lifetime/time.Second))
+	copy(req[8:], conn.LocalAddr().(*net.UDPAddr).IP.To16())
+	copy(req[headerLen:], payload)
+
+	deadline := time.Now().Add(c.timeout)
+	buf := make([]byte, maxMessageLen)
+	for wait := initialRetransmit; ; wait *= 2 {
+		if _, err := conn.Write(req); err != nil {
+			return response{}, err
+		}
+		next := time.Now().Add(wait)
+		if next.After(deadline) {
+			next = deadline
+		}
+		conn.SetReadDeadline(next)
+
+		for {
+			n, err := conn.Read(buf)
+			if err, ok := err.(net.Error); ok && err.Timeout() && time.Now().Before(deadline) {
+				break
+			}
+			if err != nil {
+				return response{}, err
+			}
+			if res, err := parseResponse(op, buf[:n]); err != errUnrelated {
+				return res, err
+			}
+		}
+	}
+}
+
+var errUnrelated = errors.New("unrelated response")
+
+func parseResponse(op byte, bs []byte) (response, error) {
+	if len(bs) < 4 {
+		return response{}, errUnrelated
+	}
+	if bs[0] != version {
+		// A NAT-PMP server responds with its own version.
+		return response{}, errUnsupportedVersion
+	}
+	if bs[1] != op|responseBit || len(bs) < headerLen {
+		return response{}, errUnrelated
+	}
+	if bs[3] != 0 {
+		return response{}, resultError(bs[3])
+	}
+	return response{
+		lifetime: time.Duration(binary.BigEndian.Uint32(bs[4:])) * time.Second,
+		payload:  bs[headerLen:],
+	}, nil
+}
+
+// globalIPv6 returns a global IPv6 address of the named interface, if it
+// has one.
+func globalIPv6(intfName string) net.IP {
+	intf, err := net.InterfaceByName(intfName)
+	if err != nil {
+		return nil
+	}
+	addrs, err := intf.Addrs()
+	if err != nil {
+		return nil
+	}
+	return nat.GlobalIPv6Among(addrs)
+}
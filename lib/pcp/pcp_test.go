@@ -0,0 +1,137 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package pcp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/nat"
+)
+
+// fakeServer answers PCP requests on the loopback address with the given
+// handler, which returns the response to a request.
+func fakeServer(t *testing.T, handle func(req []byte) []byte) *net.UDPAddr {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		defer conn.Close()
+		buf := make([]byte, maxMessageLen)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if res := handle(buf[:n]); res != nil {
+				conn.WriteToUDP(res, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+// pinholeServer behaves like an IPv6 firewall, which opens the requested
+// port on the client's address.
+func pinholeServer(req []byte) []byte {
+	res := make([]byte, len(req))
+	copy(res, req)
+	res[1] |= responseBit
+	binary.BigEndian.PutUint32(res[4:], 7200)
+	if req[1] == opMap {
+		copy(res[headerLen+18:], req[headerLen+16:headerLen+18])
+		copy(res[headerLen+20:], net.ParseIP("2001:db8::42"))
+	}
+	return res
+}
+
+func TestMapping(t *testing.T) {
+	server := fakeServer(t, pinholeServer)
+	w := &wrapper{
+		renewal: time.Hour,
+		localIP: net.ParseIP("2001:db8::42"),
+		client:  &client{server: server, timeout: time.Second},
+	}
+
+	if err := w.client.announce(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.GetExternalIPAddress(); err == nil {
+		t.Error("Should not have an external address before mapping")
+	}
+
+	port, err := w.AddPortMapping(nat.TCP, 22000, 31337, "syncthing-31337", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 22000 {
+		t.Error("Unexpected port", port)
+	}
+	ip, err := w.GetExternalIPAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.ParseIP("2001:db8::42")) {
+		t.Error("Unexpected external address", ip)
+	}
+}
+
+func TestMappingErrors(t *testing.T) {
+	// A NAT-PMP only server says so in its own version.
+	server := fakeServer(t, func(req []byte) []byte {
+		return []byte{0, 128 + req[1], 0, 1, 0, 0, 0, 0}
+	})
+	c := &client{server: server, timeout: time.Second}
+	if err := c.announce(); err != errUnsupportedVersion {
+		t.Error("Expected unsupported version, not", err)
+	}
+
+	// Failures are reported with their result code.
+	server = fakeServer(t, func(req []byte) []byte {
+		res := pinholeServer(req)
+		res[3] = 2
+		return res
+	})
+	c = &client{server: server, timeout: time.Second}
+	if _, err := c.addMapping(nat.TCP, 22000, 22000, net.IPv6unspecified, time.Hour); err != resultError(2) {
+		t.Error("Expected not authorized, not", err)
+	}
+
+	// Servers that don't answer time out.
+	server = fakeServer(t, func(req []byte) []byte {
+		return nil
+	})
+	c = &client{server: server, timeout: 100 * time.Millisecond}
+	if err := c.announce(); err == nil {
+		t.Error("Expected a timeout")
+	}
+}
+
+func TestLocalAddress(t *testing.T) {
+	// Requests are sent from the local address when one is given, as the
+	// client address in them is what pinholes are opened for.
+	server := fakeServer(t, pinholeServer)
+	local := net.IPv4(127, 0, 0, 2)
+	c := &client{server: server, local: local, timeout: time.Second}
+	if ip, err := c.localIP(); err != nil || !ip.Equal(local) {
+		t.Errorf("Unexpected local address %v, %v", ip, err)
+	}
+
+	// A PCP server on an IPv4 gateway also speaks NAT-PMP, which is then
+	// not used.
+	w := &wrapper{client: &client{server: &net.UDPAddr{IP: net.IPv4(192, 168, 1, 1), Port: serverPort}}}
+	if ids := w.Supersedes(); len(ids) != 1 || ids[0] != "NAT-PMP@192.168.1.1" {
+		t.Error("Unexpected superseded devices", ids)
+	}
+	w = &wrapper{client: &client{server: &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: serverPort}}}
+	if ids := w.Supersedes(); len(ids) != 0 {
+		t.Error("Unexpected superseded devices", ids)
+	}
+}
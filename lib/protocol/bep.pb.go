@@ -59,7 +59,7 @@ func (x MessageType) String() string {
 	return proto.EnumName(MessageType_name, int32(x))
 }
 func (MessageType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{0}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{0}
 }
 
 type MessageCompression int32
@@ -82,7 +82,7 @@ func (x MessageCompression) String() string {
 	return proto.EnumName(MessageCompression_name, int32(x))
 }
 func (MessageCompression) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{1}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{1}
 }
 
 type Compression int32
@@ -108,7 +108,7 @@ func (x Compression) String() string {
 	return proto.EnumName(Compression_name, int32(x))
 }
 func (Compression) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{2}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{2}
 }
 
 type FileInfoType int32
@@ -140,7 +140,7 @@ func (x FileInfoType) String() string {
 	return proto.EnumName(FileInfoType_name, int32(x))
 }
 func (FileInfoType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{3}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{3}
 }
 
 type ErrorCode int32
@@ -169,7 +169,7 @@ func (x ErrorCode) String() string {
 	return proto.EnumName(ErrorCode_name, int32(x))
 }
 func (ErrorCode) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{4}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{4}
 }
 
 type FileDownloadProgressUpdateType int32
@@ -192,20 +192,23 @@ func (x FileDownloadProgressUpdateType) String() string {
 	return proto.EnumName(FileDownloadProgressUpdateType_name, int32(x))
 }
 func (FileDownloadProgressUpdateType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{5}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{5}
 }
 
 type Hello struct {
-	DeviceName    string `protobuf:"bytes,1,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
-	ClientName    string `protobuf:"bytes,2,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`
-	ClientVersion string `protobuf:"bytes,3,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	DeviceName           string `protobuf:"bytes,1,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	ClientName           string `protobuf:"bytes,2,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`
+	ClientVersion        string `protobuf:"bytes,3,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	NumConnections       int32  `protobuf:"varint,4,opt,name=num_connections,json=numConnections,proto3" json:"num_connections,omitempty"`
+	Additional           bool   `protobuf:"varint,5,opt,name=additional,proto3" json:"additional,omitempty"`
+	ClusterConfigUpdates bool   `protobuf:"varint,6,opt,name=cluster_config_updates,json=clusterConfigUpdates,proto3" json:"cluster_config_updates,omitempty"`
 }
 
 func (m *Hello) Reset()         { *m = Hello{} }
 func (m *Hello) String() string { return proto.CompactTextString(m) }
 func (*Hello) ProtoMessage()    {}
 func (*Hello) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{0}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{0}
 }
 func (m *Hello) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -243,7 +246,7 @@ func (m *Header) Reset()         { *m = Header{} }
 func (m *Header) String() string { return proto.CompactTextString(m) }
 func (*Header) ProtoMessage()    {}
 func (*Header) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{1}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{1}
 }
 func (m *Header) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -280,7 +283,7 @@ func (m *ClusterConfig) Reset()         { *m = ClusterConfig{} }
 func (m *ClusterConfig) String() string { return proto.CompactTextString(m) }
 func (*ClusterConfig) ProtoMessage()    {}
 func (*ClusterConfig) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{2}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{2}
 }
 func (m *ClusterConfig) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -324,7 +327,7 @@ func (m *Folder) Reset()         { *m = Folder{} }
 func (m *Folder) String() string { return proto.CompactTextString(m) }
 func (*Folder) ProtoMessage()    {}
 func (*Folder) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{3}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{3}
 }
 func (m *Folder) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -363,13 +366,15 @@ type Device struct {
 	Introducer               bool        `protobuf:"varint,7,opt,name=introducer,proto3" json:"introducer,omitempty"`
 	IndexID                  IndexID     `protobuf:"varint,8,opt,name=index_id,json=indexId,proto3,customtype=IndexID" json:"index_id"`
 	SkipIntroductionRemovals bool        `protobuf:"varint,9,opt,name=skip_introduction_removals,json=skipIntroductionRemovals,proto3" json:"skip_introduction_removals,omitempty"`
+	LearnedAddresses         []string    `protobuf:"bytes,10,rep,name=learned_addresses,json=learnedAddresses,proto3" json:"learned_addresses,omitempty"`
+	ObservedAddresses        []string    `protobuf:"bytes,11,rep,name=observed_addresses,json=observedAddresses,proto3" json:"observed_addresses,omitempty"`
 }
 
 func (m *Device) Reset()         { *m = Device{} }
 func (m *Device) String() string { return proto.CompactTextString(m) }
 func (*Device) ProtoMessage()    {}
 func (*Device) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{4}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{4}
 }
 func (m *Device) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -407,7 +412,7 @@ func (m *Index) Reset()         { *m = Index{} }
 func (m *Index) String() string { return proto.CompactTextString(m) }
 func (*Index) ProtoMessage()    {}
 func (*Index) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{5}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{5}
 }
 func (m *Index) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -445,7 +450,7 @@ func (m *IndexUpdate) Reset()         { *m = IndexUpdate{} }
 func (m *IndexUpdate) String() string { return proto.CompactTextString(m) }
 func (*IndexUpdate) ProtoMessage()    {}
 func (*IndexUpdate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{6}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{6}
 }
 func (m *IndexUpdate) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -500,7 +505,7 @@ type FileInfo struct {
 func (m *FileInfo) Reset()      { *m = FileInfo{} }
 func (*FileInfo) ProtoMessage() {}
 func (*FileInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{7}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{7}
 }
 func (m *FileInfo) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -539,7 +544,7 @@ type BlockInfo struct {
 func (m *BlockInfo) Reset()      { *m = BlockInfo{} }
 func (*BlockInfo) ProtoMessage() {}
 func (*BlockInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{8}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{8}
 }
 func (m *BlockInfo) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -576,7 +581,7 @@ func (m *Vector) Reset()         { *m = Vector{} }
 func (m *Vector) String() string { return proto.CompactTextString(m) }
 func (*Vector) ProtoMessage()    {}
 func (*Vector) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{9}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{9}
 }
 func (m *Vector) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -614,7 +619,7 @@ func (m *Counter) Reset()         { *m = Counter{} }
 func (m *Counter) String() string { return proto.CompactTextString(m) }
 func (*Counter) ProtoMessage()    {}
 func (*Counter) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{10}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{10}
 }
 func (m *Counter) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -658,7 +663,7 @@ func (m *Request) Reset()         { *m = Request{} }
 func (m *Request) String() string { return proto.CompactTextString(m) }
 func (*Request) ProtoMessage()    {}
 func (*Request) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{11}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{11}
 }
 func (m *Request) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -697,7 +702,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{12}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{12}
 }
 func (m *Response) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -735,7 +740,7 @@ func (m *DownloadProgress) Reset()         { *m = DownloadProgress{} }
 func (m *DownloadProgress) String() string { return proto.CompactTextString(m) }
 func (*DownloadProgress) ProtoMessage()    {}
 func (*DownloadProgress) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{13}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{13}
 }
 func (m *DownloadProgress) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -775,7 +780,7 @@ func (m *FileDownloadProgressUpdate) Reset()         { *m = FileDownloadProgress
 func (m *FileDownloadProgressUpdate) String() string { return proto.CompactTextString(m) }
 func (*FileDownloadProgressUpdate) ProtoMessage()    {}
 func (*FileDownloadProgressUpdate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{14}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{14}
 }
 func (m *FileDownloadProgressUpdate) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -811,7 +816,7 @@ func (m *Ping) Reset()         { *m = Ping{} }
 func (m *Ping) String() string { return proto.CompactTextString(m) }
 func (*Ping) ProtoMessage()    {}
 func (*Ping) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{15}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{15}
 }
 func (m *Ping) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -848,7 +853,7 @@ func (m *Close) Reset()         { *m = Close{} }
 func (m *Close) String() string { return proto.CompactTextString(m) }
 func (*Close) ProtoMessage()    {}
 func (*Close) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bep_83d45b003aedf660, []int{16}
+	return fileDescriptor_bep_d788f879bfc9be46, []int{16}
 }
 func (m *Close) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -935,6 +940,31 @@ func (m *Hello) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintBep(dAtA, i, uint64(len(m.ClientVersion)))
 		i += copy(dAtA[i:], m.ClientVersion)
 	}
+	if m.NumConnections != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintBep(dAtA, i, uint64(m.NumConnections))
+	}
+	if m.Additional {
+		dAtA[i] = 0x28
+		i++
+		if m.Additional {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.ClusterConfigUpdates {
+		dAtA[i] = 0x30
+		i++
+		if m.ClusterConfigUpdates {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -1175,6 +1205,36 @@ func (m *Device) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i++
 	}
+	if len(m.LearnedAddresses) > 0 {
+		for _, s := range m.LearnedAddresses {
+			dAtA[i] = 0x52
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.ObservedAddresses) > 0 {
+		for _, s := range m.ObservedAddresses {
+			dAtA[i] = 0x5a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
 	return i, nil
 }
 
@@ -1727,6 +1787,15 @@ func (m *Hello) ProtoSize() (n int) {
 	if l > 0 {
 		n += 1 + l + sovBep(uint64(l))
 	}
+	if m.NumConnections != 0 {
+		n += 1 + sovBep(uint64(m.NumConnections))
+	}
+	if m.Additional {
+		n += 2
+	}
+	if m.ClusterConfigUpdates {
+		n += 2
+	}
 	return n
 }
 
@@ -1835,6 +1904,18 @@ func (m *Device) ProtoSize() (n int) {
 	if m.SkipIntroductionRemovals {
 		n += 2
 	}
+	if len(m.LearnedAddresses) > 0 {
+		for _, s := range m.LearnedAddresses {
+			l = len(s)
+			n += 1 + l + sovBep(uint64(l))
+		}
+	}
+	if len(m.ObservedAddresses) > 0 {
+		for _, s := range m.ObservedAddresses {
+			l = len(s)
+			n += 1 + l + sovBep(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -2237,6 +2318,65 @@ func (m *Hello) Unmarshal(dAtA []byte) error {
 			}
 			m.ClientVersion = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumConnections", wireType)
+			}
+			m.NumConnections = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumConnections |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Additional", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Additional = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClusterConfigUpdates", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ClusterConfigUpdates = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipBep(dAtA[iNdEx:])
@@ -2909,6 +3049,64 @@ func (m *Device) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.SkipIntroductionRemovals = bool(v != 0)
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LearnedAddresses", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LearnedAddresses = append(m.LearnedAddresses, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ObservedAddresses", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ObservedAddresses = append(m.ObservedAddresses, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipBep(dAtA[iNdEx:])
@@ -4756,121 +4954,127 @@ var (
 	ErrIntOverflowBep   = fmt.Errorf("proto: integer overflow")
 )
 
-func init() { proto.RegisterFile("bep.proto", fileDescriptor_bep_83d45b003aedf660) }
+func init() { proto.RegisterFile("bep.proto", fileDescriptor_bep_d788f879bfc9be46) }
 
-var fileDescriptor_bep_83d45b003aedf660 = []byte{
-	// 1800 bytes of a gzipped FileDescriptorProto
+var fileDescriptor_bep_d788f879bfc9be46 = []byte{
+	// 1889 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4f, 0x6f, 0xdb, 0xc8,
-	0x15, 0x17, 0x25, 0xea, 0xdf, 0x93, 0xec, 0xa5, 0x27, 0x89, 0xcb, 0x72, 0xb3, 0x12, 0xa3, 0x24,
-	0x1b, 0xad, 0xb1, 0x4d, 0xd2, 0xdd, 0x6d, 0x8b, 0x16, 0x6d, 0x01, 0xfd, 0xa1, 0x1d, 0xa1, 0x8e,
-	0xe4, 0x8e, 0xe4, 0x6c, 0xb3, 0x87, 0x12, 0xb4, 0x38, 0x92, 0x89, 0x50, 0x1c, 0x95, 0xa4, 0xec,
-	0x68, 0x3f, 0x82, 0x4e, 0x3d, 0xf6, 0x22, 0x60, 0x81, 0x9e, 0xfa, 0x4d, 0x72, 0x4c, 0x7b, 0x28,
-	0x8a, 0x1e, 0x8c, 0xae, 0x73, 0xd9, 0x63, 0x3f, 0x41, 0x51, 0xcc, 0x0c, 0x29, 0x51, 0x76, 0xb2,
-	0xc8, 0x61, 0x4f, 0x9c, 0x79, 0xef, 0x37, 0x33, 0x7c, 0xbf, 0xf7, 0x7b, 0x6f, 0x06, 0x8a, 0x27,
-	0x64, 0xfa, 0x70, 0xea, 0xd3, 0x90, 0xa2, 0x02, 0xff, 0x0c, 0xa9, 0xab, 0xdd, 0xf5, 0xc9, 0x94,
-	0x06, 0x8f, 0xf8, 0xfc, 0x64, 0x36, 0x7a, 0x34, 0xa6, 0x63, 0xca, 0x27, 0x7c, 0x24, 0xe0, 0xb5,
-	0x29, 0x64, 0x9f, 0x10, 0xd7, 0xa5, 0xa8, 0x0a, 0x25, 0x9b, 0x9c, 0x39, 0x43, 0x62, 0x7a, 0xd6,
-	0x84, 0xa8, 0x92, 0x2e, 0xd5, 0x8b, 0x18, 0x84, 0xa9, 0x6b, 0x4d, 0x08, 0x03, 0x0c, 0x5d, 0x87,
-	0x78, 0xa1, 0x00, 0xa4, 0x05, 0x40, 0x98, 0x38, 0xe0, 0x3e, 0x6c, 0x47, 0x80, 0x33, 0xe2, 0x07,
-	0x0e, 0xf5, 0xd4, 0x0c, 0xc7, 0x6c, 0x09, 0xeb, 0x33, 0x61, 0xac, 0x05, 0x90, 0x7b, 0x42, 0x2c,
-	0x9b, 0xf8, 0xe8, 0x13, 0x90, 0xc3, 0xf9, 0x54, 0x9c, 0xb5, 0xfd, 0xd9, 0xad, 0x87, 0xf1, 0x9f,
-	0x3f, 0x7c, 0x4a, 0x82, 0xc0, 0x1a, 0x93, 0xc1, 0x7c, 0x4a, 0x30, 0x87, 0xa0, 0xdf, 0x42, 0x69,
-	0x48, 0x27, 0x53, 0x9f, 0x04, 0x7c, 0xe3, 0x34, 0x5f, 0x71, 0xfb, 0xda, 0x8a, 0xd6, 0x1a, 0x83,
-	0x93, 0x0b, 0x6a, 0x0d, 0xd8, 0x6a, 0xb9, 0xb3, 0x20, 0x24, 0x7e, 0x8b, 0x7a, 0x23, 0x67, 0x8c,
-	0x1e, 0x43, 0x7e, 0x44, 0x5d, 0x9b, 0xf8, 0x81, 0x2a, 0xe9, 0x99, 0x7a, 0xe9, 0x33, 0x65, 0xbd,
-	0xd9, 0x3e, 0x77, 0x34, 0xe5, 0x57, 0x17, 0xd5, 0x14, 0x8e, 0x61, 0xb5, 0xbf, 0xa6, 0x21, 0x27,
-	0x3c, 0x68, 0x17, 0xd2, 0x8e, 0x2d, 0x28, 0x6a, 0xe6, 0x2e, 0x2f, 0xaa, 0xe9, 0x4e, 0x1b, 0xa7,
-	0x1d, 0x1b, 0xdd, 0x84, 0xac, 0x6b, 0x9d, 0x10, 0x37, 0x22, 0x47, 0x4c, 0xd0, 0x87, 0x50, 0xf4,
-	0x89, 0x65, 0x9b, 0xd4, 0x73, 0xe7, 0x9c, 0x92, 0x02, 0x2e, 0x30, 0x43, 0xcf, 0x73, 0xe7, 0xe8,
-	0x27, 0x80, 0x9c, 0xb1, 0x47, 0x7d, 0x62, 0x4e, 0x89, 0x3f, 0x71, 0xf8, 0xdf, 0x06, 0xaa, 0xcc,
-	0x51, 0x3b, 0xc2, 0x73, 0xb4, 0x76, 0xa0, 0xbb, 0xb0, 0x15, 0xc1, 0x6d, 0xe2, 0x92, 0x90, 0xa8,
-	0x59, 0x8e, 0x2c, 0x0b, 0x63, 0x9b, 0xdb, 0xd0, 0x63, 0xb8, 0x69, 0x3b, 0x81, 0x75, 0xe2, 0x12,
-	0x33, 0x24, 0x93, 0xa9, 0xe9, 0x78, 0x36, 0x79, 0x49, 0x02, 0x35, 0xc7, 0xb1, 0x28, 0xf2, 0x0d,
-	0xc8, 0x64, 0xda, 0x11, 0x1e, 0xb4, 0x0b, 0xb9, 0xa9, 0x35, 0x0b, 0x88, 0xad, 0xe6, 0x39, 0x26,
-	0x9a, 0x31, 0x96, 0x84, 0x02, 0x02, 0x55, 0xb9, 0xca, 0x52, 0x9b, 0x3b, 0x62, 0x96, 0x22, 0x58,
-	0xed, 0xbf, 0x69, 0xc8, 0x09, 0x0f, 0xfa, 0x78, 0xc5, 0x52, 0xb9, 0xb9, 0xcb, 0x50, 0xff, 0xbe,
-	0xa8, 0x16, 0x84, 0xaf, 0xd3, 0x4e, 0xb0, 0x86, 0x40, 0x4e, 0x28, 0x8a, 0x8f, 0xd1, 0x6d, 0x28,
-	0x5a, 0xb6, 0xcd, 0xb2, 0x47, 0x02, 0x35, 0xa3, 0x67, 0xea, 0x45, 0xbc, 0x36, 0xa0, 0x5f, 0x6c,
-	0xaa, 0x41, 0xbe, 0xaa, 0x9f, 0x77, 0xc9, 0x80, 0xa5, 0x62, 0x48, 0xfc, 0x48, 0xc1, 0x59, 0x7e,
-	0x5e, 0x81, 0x19, 0xb8, 0x7e, 0xef, 0x40, 0x79, 0x62, 0xbd, 0x34, 0x03, 0xf2, 0xa7, 0x19, 0xf1,
-	0x86, 0x84, 0xd3, 0x95, 0xc1, 0xa5, 0x89, 0xf5, 0xb2, 0x1f, 0x99, 0x50, 0x05, 0xc0, 0xf1, 0x42,
-	0x9f, 0xda, 0xb3, 0x21, 0xf1, 0x23, 0xae, 0x12, 0x16, 0xf4, 0x33, 0x28, 0x70, 0xb2, 0x4d, 0xc7,
-	0x56, 0x0b, 0xba, 0x54, 0x97, 0x9b, 0x5a, 0x14, 0x78, 0x9e, 0x53, 0xcd, 0xe3, 0x8e, 0x87, 0x38,
-	0xcf, 0xb1, 0x1d, 0x1b, 0xfd, 0x1a, 0xb4, 0xe0, 0x85, 0xc3, 0x12, 0x25, 0x76, 0x0a, 0x1d, 0xea,
-	0x99, 0x3e, 0x99, 0xd0, 0x33, 0xcb, 0x0d, 0xd4, 0x22, 0x3f, 0x46, 0x65, 0x88, 0x4e, 0x02, 0x80,
-	0x23, 0x7f, 0xad, 0x07, 0x59, 0xbe, 0x23, 0xcb, 0xa2, 0x10, 0x6b, 0x54, 0xbd, 0xd1, 0x0c, 0x3d,
-	0x84, 0xec, 0xc8, 0x71, 0x49, 0xa0, 0xa6, 0x79, 0x0e, 0x51, 0x42, 0xe9, 0x8e, 0x4b, 0x3a, 0xde,
-	0x88, 0x46, 0x59, 0x14, 0xb0, 0xda, 0x31, 0x94, 0xf8, 0x86, 0xc7, 0x53, 0xdb, 0x0a, 0xc9, 0x0f,
-	0xb6, 0xed, 0x85, 0x0c, 0x85, 0xd8, 0xb3, 0x4a, 0xba, 0x94, 0x48, 0xfa, 0x5e, 0xd4, 0x0f, 0x44,
-	0x75, 0xef, 0x5e, 0xdf, 0x2f, 0xd1, 0x10, 0x10, 0xc8, 0x81, 0xf3, 0x35, 0xe1, 0xf5, 0x94, 0xc1,
-	0x7c, 0x8c, 0x74, 0x28, 0x5d, 0x2d, 0xa2, 0x2d, 0x9c, 0x34, 0xa1, 0x8f, 0x00, 0x26, 0xd4, 0x76,
-	0x46, 0x0e, 0xb1, 0xcd, 0x80, 0x0b, 0x20, 0x83, 0x8b, 0xb1, 0xa5, 0x8f, 0x54, 0x26, 0x77, 0x56,
-	0x42, 0x76, 0x54, 0x2b, 0xf1, 0x14, 0xd5, 0x21, 0xef, 0x78, 0x67, 0x96, 0xeb, 0x44, 0x15, 0xd2,
-	0xdc, 0xbe, 0xbc, 0xa8, 0x02, 0xb6, 0xce, 0x3b, 0xc2, 0x8a, 0x63, 0x37, 0xeb, 0x82, 0x1e, 0xdd,
-	0x28, 0xe6, 0x02, 0xdf, 0x6a, 0xcb, 0xa3, 0xc9, 0x42, 0x7e, 0x0c, 0xf9, 0xb8, 0x4b, 0xb2, 0xfc,
-	0x6e, 0x54, 0xd6, 0x33, 0x32, 0x0c, 0xe9, 0xaa, 0xff, 0x44, 0x30, 0xa4, 0x41, 0x61, 0x25, 0x4d,
-	0xe0, 0x7f, 0xbe, 0x9a, 0xb3, 0xde, 0xbc, 0x8a, 0xcb, 0x0b, 0xd4, 0x92, 0x2e, 0xd5, 0xb3, 0x78,
-	0x15, 0x6a, 0x97, 0x1d, 0xb7, 0x06, 0x9c, 0xcc, 0xd5, 0x32, 0xd7, 0xe6, 0x07, 0xb1, 0x36, 0xfb,
-	0xa7, 0xd4, 0x0f, 0x3b, 0xed, 0xf5, 0x8a, 0xe6, 0x1c, 0x3d, 0x02, 0x38, 0x71, 0xe9, 0xf0, 0x85,
-	0xc9, 0x69, 0xde, 0x62, 0x3b, 0x36, 0x95, 0xcb, 0x8b, 0x6a, 0x19, 0x5b, 0xe7, 0x4d, 0xe6, 0xe8,
-	0x3b, 0x5f, 0x13, 0x5c, 0x3c, 0x89, 0x87, 0xe8, 0xa7, 0x90, 0xe3, 0xf6, 0xb8, 0x55, 0xdc, 0x58,
-	0x07, 0xc4, 0xed, 0x09, 0x41, 0x44, 0x40, 0xc6, 0x55, 0x30, 0x9f, 0xb8, 0x8e, 0xf7, 0xc2, 0x0c,
-	0x2d, 0x7f, 0x4c, 0x42, 0x75, 0x47, 0xdc, 0x18, 0x91, 0x75, 0xc0, 0x8d, 0x2c, 0xaf, 0x2e, 0x1d,
-	0x5a, 0xae, 0x39, 0x72, 0xad, 0x71, 0xa0, 0x7e, 0x97, 0xe7, 0x89, 0x05, 0x6e, 0xdb, 0x67, 0xa6,
-	0x5f, 0xc9, 0x7f, 0xf9, 0xa6, 0x9a, 0xaa, 0x79, 0x50, 0x5c, 0x9d, 0xc4, 0x54, 0x4b, 0x47, 0xa3,
-	0x80, 0x84, 0x5c, 0x62, 0x19, 0x1c, 0xcd, 0x56, 0xc2, 0x49, 0x73, 0x8e, 0x84, 0x70, 0x10, 0xc8,
-	0xa7, 0x56, 0x70, 0xca, 0xc5, 0x54, 0xc6, 0x7c, 0xcc, 0x5a, 0xc5, 0x39, 0xb1, 0x5e, 0x98, 0xdc,
-	0x21, 0xa4, 0x54, 0x60, 0x86, 0x27, 0x56, 0x70, 0x1a, 0x9d, 0xf7, 0x1b, 0xc8, 0x89, 0x54, 0xa1,
-	0xcf, 0xa1, 0x30, 0xa4, 0x33, 0x2f, 0x5c, 0x5f, 0x27, 0x3b, 0xc9, 0x6e, 0xc4, 0x3d, 0x51, 0xec,
-	0x2b, 0x60, 0x6d, 0x1f, 0xf2, 0x91, 0x0b, 0xdd, 0x5f, 0xb5, 0x4a, 0xb9, 0x79, 0xeb, 0x4a, 0x56,
-	0x36, 0xef, 0x97, 0x33, 0xcb, 0x9d, 0x89, 0x9f, 0x97, 0xb1, 0x98, 0xd4, 0xfe, 0x2e, 0x41, 0x1e,
-	0x33, 0x25, 0x04, 0x61, 0xe2, 0x66, 0xca, 0x6e, 0xdc, 0x4c, 0xeb, 0x1a, 0x4e, 0x6f, 0xd4, 0x70,
-	0x5c, 0x86, 0x99, 0x44, 0x19, 0xae, 0x99, 0x93, 0xdf, 0xca, 0x5c, 0xf6, 0x2d, 0xcc, 0xe5, 0x12,
-	0xcc, 0xdd, 0x87, 0xed, 0x91, 0x4f, 0x27, 0xfc, 0xee, 0xa1, 0xbe, 0xe5, 0xcf, 0xa3, 0x46, 0xb9,
-	0xc5, 0xac, 0x83, 0xd8, 0xb8, 0x49, 0x70, 0x61, 0x93, 0xe0, 0x9a, 0x09, 0x05, 0x4c, 0x82, 0x29,
-	0xf5, 0x02, 0xf2, 0xce, 0x98, 0x10, 0xc8, 0xb6, 0x15, 0x5a, 0x3c, 0xa2, 0x32, 0xe6, 0x63, 0xf4,
-	0x00, 0xe4, 0x21, 0xb5, 0x45, 0x3c, 0xdb, 0x49, 0x09, 0x1a, 0xbe, 0x4f, 0xfd, 0x16, 0xb5, 0x09,
-	0xe6, 0x80, 0xda, 0x14, 0x94, 0x36, 0x3d, 0xf7, 0x5c, 0x6a, 0xd9, 0x47, 0x3e, 0x1d, 0xb3, 0x0b,
-	0xe2, 0x9d, 0x8d, 0xae, 0x0d, 0xf9, 0x19, 0x6f, 0x85, 0x71, 0xab, 0xbb, 0xb7, 0xd9, 0x9a, 0xae,
-	0x6e, 0x24, 0xfa, 0x66, 0x5c, 0xbf, 0xd1, 0xd2, 0xda, 0x3f, 0x25, 0xd0, 0xde, 0x8d, 0x46, 0x1d,
-	0x28, 0x09, 0xa4, 0x99, 0x78, 0x13, 0xd5, 0xdf, 0xe7, 0x20, 0xde, 0x15, 0x61, 0xb6, 0x1a, 0xbf,
-	0xf5, 0x42, 0x4d, 0xf4, 0x9b, 0xcc, 0xfb, 0xf5, 0x9b, 0x07, 0xb0, 0x25, 0x1a, 0x40, 0xfc, 0x7c,
-	0x90, 0xf5, 0x4c, 0x3d, 0xdb, 0x4c, 0x2b, 0x29, 0x5c, 0x3e, 0x11, 0x65, 0xc6, 0xed, 0xb5, 0x1c,
-	0xc8, 0x47, 0x8e, 0x37, 0xae, 0x55, 0x21, 0xdb, 0x72, 0x29, 0x4f, 0x58, 0xce, 0x27, 0x56, 0x40,
-	0xbd, 0x98, 0x47, 0x31, 0xdb, 0xfb, 0x47, 0x1a, 0x4a, 0x89, 0xa7, 0x1d, 0x7a, 0x0c, 0xdb, 0xad,
-	0xc3, 0xe3, 0xfe, 0xc0, 0xc0, 0x66, 0xab, 0xd7, 0xdd, 0xef, 0x1c, 0x28, 0x29, 0xed, 0xf6, 0x62,
-	0xa9, 0xab, 0x93, 0x35, 0x68, 0xf3, 0xd5, 0x56, 0x85, 0x6c, 0xa7, 0xdb, 0x36, 0xfe, 0xa0, 0x48,
-	0xda, 0xcd, 0xc5, 0x52, 0x57, 0x12, 0x40, 0x71, 0x05, 0x7e, 0x0a, 0x65, 0x0e, 0x30, 0x8f, 0x8f,
-	0xda, 0x8d, 0x81, 0xa1, 0xa4, 0x35, 0x6d, 0xb1, 0xd4, 0x77, 0xaf, 0xe2, 0x22, 0xce, 0xef, 0x42,
-	0x1e, 0x1b, 0xbf, 0x3f, 0x36, 0xfa, 0x03, 0x25, 0xa3, 0xed, 0x2e, 0x96, 0x3a, 0x4a, 0x00, 0xe3,
-	0x92, 0xba, 0x0f, 0x05, 0x6c, 0xf4, 0x8f, 0x7a, 0xdd, 0xbe, 0xa1, 0xc8, 0xda, 0x8f, 0x16, 0x4b,
-	0xfd, 0xc6, 0x06, 0x2a, 0x52, 0xe9, 0xcf, 0x61, 0xa7, 0xdd, 0xfb, 0xb2, 0x7b, 0xd8, 0x6b, 0xb4,
-	0xcd, 0x23, 0xdc, 0x3b, 0xc0, 0x46, 0xbf, 0xaf, 0x64, 0xb5, 0xea, 0x62, 0xa9, 0x7f, 0x98, 0xc0,
-	0x5f, 0x13, 0xdd, 0x47, 0x20, 0x1f, 0x75, 0xba, 0x07, 0x4a, 0x4e, 0xbb, 0xb1, 0x58, 0xea, 0x1f,
-	0x24, 0xa0, 0x8c, 0x54, 0x16, 0x71, 0xeb, 0xb0, 0xd7, 0x37, 0x94, 0xfc, 0xb5, 0x88, 0x39, 0xd9,
-	0x7b, 0x7f, 0x04, 0x74, 0xfd, 0xf1, 0x8b, 0xee, 0x81, 0xdc, 0xed, 0x75, 0x0d, 0x25, 0x25, 0xe2,
-	0xbf, 0x8e, 0xe8, 0x52, 0x8f, 0xa0, 0x1a, 0x64, 0x0e, 0xbf, 0xfa, 0x42, 0x91, 0xb4, 0x1f, 0x2f,
-	0x96, 0xfa, 0xad, 0xeb, 0xa0, 0xc3, 0xaf, 0xbe, 0xd8, 0xa3, 0x50, 0x4a, 0x6e, 0x5c, 0x83, 0xc2,
-	0x53, 0x63, 0xd0, 0x68, 0x37, 0x06, 0x0d, 0x25, 0x25, 0x7e, 0x29, 0x76, 0x3f, 0x25, 0xa1, 0xc5,
-	0x8b, 0xf0, 0x36, 0x64, 0xbb, 0xc6, 0x33, 0x03, 0x2b, 0x92, 0xb6, 0xb3, 0x58, 0xea, 0x5b, 0x31,
-	0xa0, 0x4b, 0xce, 0x88, 0x8f, 0x2a, 0x90, 0x6b, 0x1c, 0x7e, 0xd9, 0x78, 0xde, 0x57, 0xd2, 0x1a,
-	0x5a, 0x2c, 0xf5, 0xed, 0xd8, 0xdd, 0x70, 0xcf, 0xad, 0x79, 0xb0, 0xf7, 0x3f, 0x09, 0xca, 0xc9,
-	0x0b, 0x1f, 0x55, 0x40, 0xde, 0xef, 0x1c, 0x1a, 0xf1, 0x71, 0x49, 0x1f, 0x1b, 0xa3, 0x3a, 0x14,
-	0xdb, 0x1d, 0x6c, 0xb4, 0x06, 0x3d, 0xfc, 0x3c, 0x8e, 0x25, 0x09, 0x6a, 0x3b, 0x3e, 0x17, 0xf8,
-	0x1c, 0xfd, 0x12, 0xca, 0xfd, 0xe7, 0x4f, 0x0f, 0x3b, 0xdd, 0xdf, 0x99, 0x7c, 0xc7, 0xb4, 0xf6,
-	0x60, 0xb1, 0xd4, 0xef, 0x6c, 0x80, 0xc9, 0xd4, 0x27, 0x43, 0x2b, 0x24, 0x76, 0x5f, 0xdc, 0x41,
-	0xcc, 0x59, 0x90, 0x50, 0x0b, 0x76, 0xe2, 0xa5, 0xeb, 0xc3, 0x32, 0xda, 0xa7, 0x8b, 0xa5, 0xfe,
-	0xf1, 0xf7, 0xae, 0x5f, 0x9d, 0x5e, 0x90, 0xd0, 0x3d, 0xc8, 0x47, 0x9b, 0xc4, 0x4a, 0x4a, 0x2e,
-	0x8d, 0x16, 0xec, 0xfd, 0x4d, 0x82, 0xe2, 0xaa, 0x5d, 0x31, 0xc2, 0xbb, 0x3d, 0xd3, 0xc0, 0xb8,
-	0x87, 0x63, 0x06, 0x56, 0xce, 0x2e, 0xe5, 0x43, 0x74, 0x07, 0xf2, 0x07, 0x46, 0xd7, 0xc0, 0x9d,
-	0x56, 0x5c, 0x18, 0x2b, 0xc8, 0x01, 0xf1, 0x88, 0xef, 0x0c, 0xd1, 0x27, 0x50, 0xee, 0xf6, 0xcc,
-	0xfe, 0x71, 0xeb, 0x49, 0x1c, 0x3a, 0x3f, 0x3f, 0xb1, 0x55, 0x7f, 0x36, 0x3c, 0xe5, 0x7c, 0xee,
-	0xb1, 0x1a, 0x7a, 0xd6, 0x38, 0xec, 0xb4, 0x05, 0x34, 0xa3, 0xa9, 0x8b, 0xa5, 0x7e, 0x73, 0x05,
-	0x8d, 0x9e, 0x3c, 0x0c, 0xbb, 0x67, 0x43, 0xe5, 0xfb, 0x1b, 0x13, 0xd2, 0x21, 0xd7, 0x38, 0x3a,
-	0x32, 0xba, 0xed, 0xf8, 0xef, 0xd7, 0xbe, 0xc6, 0x74, 0x4a, 0x3c, 0x9b, 0x21, 0xf6, 0x7b, 0xf8,
-	0xc0, 0x18, 0xc4, 0x3f, 0xbf, 0x46, 0xec, 0x53, 0xf6, 0x00, 0x68, 0xd6, 0x5f, 0x7d, 0x5b, 0x49,
-	0xbd, 0xfe, 0xb6, 0x92, 0x7a, 0x75, 0x59, 0x91, 0x5e, 0x5f, 0x56, 0xa4, 0xff, 0x5c, 0x56, 0x52,
-	0xdf, 0x5d, 0x56, 0xa4, 0x3f, 0xbf, 0xa9, 0xa4, 0xbe, 0x79, 0x53, 0x91, 0x5e, 0xbf, 0xa9, 0xa4,
-	0xfe, 0xf5, 0xa6, 0x92, 0x3a, 0xc9, 0xf1, 0xa6, 0xf6, 0xf9, 0xff, 0x03, 0x00, 0x00, 0xff, 0xff,
-	0x02, 0x4d, 0x9a, 0x1c, 0x11, 0x0f, 0x00, 0x00,
+	0x15, 0x17, 0x25, 0x4a, 0xa2, 0x9e, 0x64, 0x87, 0x9e, 0x24, 0xae, 0xaa, 0xcd, 0x4a, 0x8c, 0x92,
+	0x6c, 0xb4, 0xee, 0x6e, 0x92, 0xee, 0xa6, 0x2d, 0x5a, 0xb4, 0x05, 0xf4, 0x87, 0x76, 0x84, 0x3a,
+	0x92, 0x3b, 0x92, 0xb3, 0xcd, 0x1e, 0x4a, 0x50, 0xe2, 0xc8, 0x21, 0x42, 0x71, 0x54, 0x92, 0xb2,
+	0xa3, 0xfd, 0x08, 0x3a, 0xf5, 0xd8, 0x8b, 0x80, 0x05, 0x7a, 0xea, 0x37, 0xc9, 0xa1, 0x87, 0xb4,
+	0x87, 0xa2, 0xe8, 0xc1, 0xe8, 0x3a, 0x97, 0xbd, 0xf5, 0x1b, 0x14, 0xc5, 0xcc, 0x90, 0x14, 0x65,
+	0x27, 0x8b, 0x3d, 0xf4, 0xc4, 0x99, 0xf7, 0x7e, 0x33, 0xc3, 0xf7, 0x9b, 0xdf, 0x7b, 0x6f, 0xa0,
+	0x30, 0x22, 0xb3, 0x07, 0x33, 0x8f, 0x06, 0x14, 0x29, 0xfc, 0x33, 0xa6, 0x4e, 0xe5, 0x8e, 0x47,
+	0x66, 0xd4, 0x7f, 0xc8, 0xe7, 0xa3, 0xf9, 0xe4, 0xe1, 0x09, 0x3d, 0xa1, 0x7c, 0xc2, 0x47, 0x02,
+	0x5e, 0xff, 0x8f, 0x04, 0xd9, 0x27, 0xc4, 0x71, 0x28, 0xaa, 0x41, 0xd1, 0x22, 0xa7, 0xf6, 0x98,
+	0x18, 0xae, 0x39, 0x25, 0x65, 0x49, 0x93, 0x1a, 0x05, 0x0c, 0xc2, 0xd4, 0x33, 0xa7, 0x84, 0x01,
+	0xc6, 0x8e, 0x4d, 0xdc, 0x40, 0x00, 0xd2, 0x02, 0x20, 0x4c, 0x1c, 0x70, 0x0f, 0xb6, 0x43, 0xc0,
+	0x29, 0xf1, 0x7c, 0x9b, 0xba, 0xe5, 0x0c, 0xc7, 0x6c, 0x09, 0xeb, 0x33, 0x61, 0x44, 0xf7, 0xe1,
+	0x9a, 0x3b, 0x9f, 0x1a, 0x63, 0xea, 0xba, 0x64, 0x1c, 0xd8, 0xd4, 0xf5, 0xcb, 0xb2, 0x26, 0x35,
+	0xb2, 0x78, 0xdb, 0x9d, 0x4f, 0xdb, 0x6b, 0x2b, 0xaa, 0x02, 0x98, 0x96, 0x65, 0xb3, 0x89, 0xe9,
+	0x94, 0xb3, 0x9a, 0xd4, 0x50, 0x70, 0xc2, 0x82, 0x1e, 0xc3, 0xee, 0xd8, 0x99, 0xfb, 0x01, 0xf1,
+	0xd8, 0x66, 0x13, 0xfb, 0xc4, 0x98, 0xcf, 0x2c, 0x33, 0x20, 0x7e, 0x39, 0xc7, 0xb1, 0x37, 0x42,
+	0x6f, 0x9b, 0x3b, 0x8f, 0x85, 0xaf, 0xee, 0x43, 0xee, 0x09, 0x31, 0x2d, 0xe2, 0xa1, 0x8f, 0x41,
+	0x0e, 0x16, 0x33, 0x11, 0xea, 0xf6, 0x67, 0x37, 0x1f, 0x44, 0xcc, 0x3d, 0x78, 0x4a, 0x7c, 0xdf,
+	0x3c, 0x21, 0xc3, 0xc5, 0x8c, 0x60, 0x0e, 0x41, 0xbf, 0x86, 0xe2, 0x98, 0x4e, 0x67, 0x1e, 0xf1,
+	0x79, 0x5c, 0x69, 0xbe, 0xe2, 0xd6, 0x95, 0x15, 0xed, 0x35, 0x06, 0x27, 0x17, 0xd4, 0x9b, 0xb0,
+	0xd5, 0x4e, 0xfe, 0x0c, 0x7a, 0x04, 0xf9, 0x09, 0x75, 0x2c, 0xe2, 0xf9, 0x65, 0x49, 0xcb, 0x34,
+	0x8a, 0x9f, 0xa9, 0xeb, 0xcd, 0xf6, 0xb9, 0xa3, 0x25, 0xbf, 0x3e, 0xaf, 0xa5, 0x70, 0x04, 0xab,
+	0xff, 0x39, 0x0d, 0x39, 0xe1, 0x41, 0xbb, 0x90, 0xb6, 0x2d, 0x71, 0x43, 0xad, 0xdc, 0xc5, 0x79,
+	0x2d, 0xdd, 0xed, 0xe0, 0xb4, 0x6d, 0xa1, 0x1b, 0x90, 0x75, 0xcc, 0x11, 0x71, 0xc2, 0xbb, 0x11,
+	0x13, 0xf4, 0x01, 0x14, 0x3c, 0x62, 0x5a, 0x06, 0x75, 0x9d, 0x05, 0xbf, 0x11, 0x05, 0x2b, 0xcc,
+	0xd0, 0x77, 0x9d, 0x05, 0xfa, 0x14, 0x90, 0x7d, 0xe2, 0x52, 0x8f, 0x18, 0x33, 0xe2, 0x4d, 0x6d,
+	0xdf, 0x8f, 0xef, 0x43, 0xc1, 0x3b, 0xc2, 0x73, 0xb4, 0x76, 0xa0, 0x3b, 0xb0, 0x15, 0xc2, 0x2d,
+	0xe2, 0x90, 0x80, 0x84, 0xb7, 0x52, 0x12, 0xc6, 0x0e, 0xb7, 0xa1, 0x47, 0x70, 0xc3, 0xb2, 0x7d,
+	0x73, 0xe4, 0x10, 0x23, 0x20, 0xd3, 0x99, 0x61, 0xbb, 0x16, 0x79, 0x15, 0xdf, 0x0a, 0x0a, 0x7d,
+	0x43, 0x32, 0x9d, 0x75, 0x85, 0x07, 0xed, 0x42, 0x6e, 0x66, 0xce, 0x7d, 0x62, 0x95, 0xf3, 0x1c,
+	0x13, 0xce, 0x18, 0x4b, 0x42, 0x80, 0x7e, 0x59, 0xbd, 0xcc, 0x52, 0x87, 0x3b, 0x22, 0x96, 0x42,
+	0x58, 0xfd, 0xaf, 0x19, 0xc8, 0x09, 0x0f, 0xfa, 0x28, 0x66, 0xa9, 0xd4, 0xda, 0x65, 0xa8, 0x7f,
+	0x9d, 0xd7, 0x14, 0xe1, 0xeb, 0x76, 0x12, 0xac, 0x21, 0x90, 0x13, 0x82, 0xe6, 0x63, 0x74, 0x0b,
+	0x0a, 0xa6, 0x65, 0xb1, 0xdb, 0x23, 0x7e, 0x39, 0xa3, 0x65, 0x1a, 0x05, 0xbc, 0x36, 0xa0, 0x9f,
+	0x6d, 0xaa, 0x41, 0xbe, 0xac, 0x9f, 0xf7, 0xc9, 0x80, 0x5d, 0xc5, 0x98, 0x78, 0x61, 0x02, 0x65,
+	0xf9, 0x79, 0x0a, 0x33, 0xf0, 0xf4, 0xb9, 0x0d, 0xa5, 0xa9, 0xf9, 0xca, 0xf0, 0xc9, 0x1f, 0xe6,
+	0xc4, 0x1d, 0x13, 0x4e, 0x57, 0x06, 0x17, 0xa7, 0xe6, 0xab, 0x41, 0x68, 0x62, 0x19, 0x61, 0xbb,
+	0x81, 0x47, 0xad, 0xf9, 0x98, 0x78, 0x21, 0x57, 0x09, 0x0b, 0xfa, 0x09, 0x28, 0x9c, 0x6c, 0xc3,
+	0xb6, 0xca, 0x8a, 0x26, 0x35, 0xe4, 0x56, 0x25, 0x0c, 0x3c, 0xcf, 0xa9, 0xe6, 0x71, 0x47, 0x43,
+	0x9c, 0xe7, 0xd8, 0xae, 0x85, 0x7e, 0x09, 0x15, 0xff, 0xa5, 0x3d, 0x33, 0xa2, 0x9d, 0x58, 0x7e,
+	0x19, 0x1e, 0x99, 0xd2, 0x53, 0xd3, 0xf1, 0xcb, 0x05, 0x7e, 0x4c, 0x99, 0x21, 0xba, 0x09, 0x00,
+	0x0e, 0xfd, 0xe8, 0x47, 0xb0, 0xe3, 0x10, 0xd3, 0x73, 0x89, 0x65, 0xac, 0x39, 0x03, 0xce, 0x99,
+	0x1a, 0x3a, 0x9a, 0x31, 0x75, 0x9f, 0x02, 0xa2, 0x23, 0x9f, 0x78, 0xa7, 0x1b, 0xe8, 0x22, 0x47,
+	0xef, 0x44, 0x9e, 0x18, 0x5e, 0xef, 0x43, 0x96, 0xff, 0x2d, 0x53, 0x88, 0x48, 0x84, 0xb0, 0x30,
+	0x85, 0x33, 0xf4, 0x00, 0xb2, 0x13, 0xdb, 0x21, 0x7e, 0x39, 0xcd, 0xf5, 0x81, 0x12, 0x59, 0x64,
+	0x3b, 0xa4, 0xeb, 0x4e, 0x68, 0xa8, 0x10, 0x01, 0xab, 0x1f, 0x43, 0x91, 0x6f, 0x28, 0xaa, 0xc1,
+	0xff, 0x6d, 0xdb, 0x73, 0x19, 0x94, 0xc8, 0x13, 0x0b, 0x4a, 0x4a, 0x08, 0x6a, 0x2f, 0xac, 0x35,
+	0xa2, 0x72, 0xec, 0x5e, 0xdd, 0x2f, 0x51, 0x6c, 0x10, 0xc8, 0xbe, 0xfd, 0x15, 0xe1, 0xb9, 0x9a,
+	0xc1, 0x7c, 0x8c, 0x34, 0x28, 0x5e, 0x4e, 0xd0, 0x2d, 0x9c, 0x34, 0xa1, 0x0f, 0x01, 0xa6, 0xd4,
+	0xb2, 0x27, 0x36, 0xb1, 0x0c, 0x9f, 0x8b, 0x2b, 0x83, 0x0b, 0x91, 0x65, 0x80, 0xca, 0x2c, 0x95,
+	0x58, 0x7a, 0x5a, 0x61, 0x1e, 0x46, 0x53, 0xd4, 0x80, 0xbc, 0xed, 0x9e, 0x9a, 0x8e, 0x1d, 0x66,
+	0x5f, 0x6b, 0xfb, 0xe2, 0xbc, 0x06, 0xd8, 0x3c, 0xeb, 0x0a, 0x2b, 0x8e, 0xdc, 0xac, 0xc0, 0xbb,
+	0x74, 0xa3, 0x50, 0x28, 0x7c, 0xab, 0x2d, 0x97, 0x26, 0x8b, 0xc4, 0x23, 0xc8, 0x47, 0x0d, 0x80,
+	0x69, 0x67, 0x23, 0x6b, 0x9f, 0x91, 0x71, 0x40, 0xe3, 0xda, 0x16, 0xc2, 0x50, 0x05, 0x94, 0x58,
+	0xf6, 0xc0, 0xff, 0x3c, 0x9e, 0xb3, 0xb6, 0x13, 0xc7, 0xe5, 0x32, 0xa9, 0xb0, 0x56, 0x11, 0x87,
+	0xda, 0x63, 0xc7, 0xad, 0x01, 0xa3, 0x45, 0xb9, 0xc4, 0x75, 0x7f, 0x2d, 0xd2, 0xfd, 0xe0, 0x05,
+	0xf5, 0x82, 0x6e, 0x67, 0xbd, 0xa2, 0xb5, 0x40, 0x0f, 0x01, 0x46, 0x0e, 0x1d, 0xbf, 0x34, 0x38,
+	0xcd, 0x5b, 0x6c, 0xc7, 0x96, 0x7a, 0x71, 0x5e, 0x2b, 0x61, 0xf3, 0xac, 0xc5, 0x1c, 0x03, 0xfb,
+	0x2b, 0x82, 0x0b, 0xa3, 0x68, 0x88, 0x7e, 0x0c, 0x39, 0x6e, 0x8f, 0xca, 0xd0, 0xf5, 0x75, 0x40,
+	0xdc, 0x9e, 0x10, 0x44, 0x08, 0x64, 0x5c, 0xf9, 0x8b, 0xa9, 0x63, 0xbb, 0x2f, 0x8d, 0xc0, 0xf4,
+	0x4e, 0x48, 0x50, 0xde, 0x11, 0xcd, 0x30, 0xb4, 0x0e, 0xb9, 0x91, 0xdd, 0xab, 0x43, 0xc7, 0xa6,
+	0x63, 0x4c, 0x1c, 0xf3, 0xc4, 0x2f, 0x7f, 0x9b, 0xe7, 0x17, 0x0b, 0xdc, 0xb6, 0xcf, 0x4c, 0xbf,
+	0x90, 0xff, 0xf4, 0x75, 0x2d, 0x55, 0x77, 0xa1, 0x10, 0x9f, 0xc4, 0x54, 0x4b, 0x27, 0x13, 0x9f,
+	0x04, 0x5c, 0x62, 0x19, 0x1c, 0xce, 0x62, 0xe1, 0xa4, 0x39, 0x47, 0x7c, 0xcc, 0x6c, 0x2f, 0x4c,
+	0xff, 0x05, 0x17, 0x53, 0x09, 0xf3, 0x31, 0x2b, 0x43, 0x67, 0xc4, 0x7c, 0x69, 0x70, 0x87, 0x90,
+	0x92, 0xc2, 0x0c, 0x4f, 0x4c, 0xff, 0x45, 0x78, 0xde, 0xaf, 0x20, 0x27, 0xae, 0x0a, 0x7d, 0x0e,
+	0xca, 0x98, 0xce, 0xdd, 0x60, 0xdd, 0xaa, 0x76, 0x92, 0x95, 0x8e, 0x7b, 0xc2, 0xd8, 0x63, 0x60,
+	0x7d, 0x1f, 0xf2, 0xa1, 0x0b, 0xdd, 0x8b, 0xcb, 0xb0, 0xdc, 0xba, 0x79, 0xe9, 0x56, 0x36, 0x7b,
+	0xd7, 0xa9, 0xe9, 0xcc, 0xc5, 0xcf, 0xcb, 0x58, 0x4c, 0xea, 0x7f, 0x93, 0x20, 0x8f, 0x99, 0x12,
+	0xfc, 0x20, 0xd1, 0xf5, 0xb2, 0x1b, 0x5d, 0x6f, 0x9d, 0xc3, 0xe9, 0x8d, 0x1c, 0x8e, 0xd2, 0x30,
+	0x93, 0x48, 0xc3, 0x35, 0x73, 0xf2, 0x3b, 0x99, 0xcb, 0xbe, 0x83, 0xb9, 0x5c, 0x82, 0xb9, 0x7b,
+	0xb0, 0x3d, 0xf1, 0xe8, 0x94, 0xf7, 0x35, 0xea, 0x99, 0xde, 0x22, 0x2c, 0xc2, 0x5b, 0xcc, 0x3a,
+	0x8c, 0x8c, 0x9b, 0x04, 0x2b, 0x9b, 0x04, 0xd7, 0x0d, 0x50, 0x30, 0xf1, 0x67, 0xd4, 0xf5, 0xc9,
+	0x7b, 0x63, 0x42, 0x20, 0x5b, 0x66, 0x60, 0xf2, 0x88, 0x4a, 0x98, 0x8f, 0xd1, 0x7d, 0x90, 0xc7,
+	0xd4, 0x12, 0xf1, 0x6c, 0x27, 0x25, 0xa8, 0x7b, 0x1e, 0xf5, 0xda, 0xd4, 0x22, 0x98, 0x03, 0xea,
+	0x33, 0x50, 0x3b, 0xf4, 0xcc, 0x75, 0xa8, 0x69, 0x1d, 0x79, 0xf4, 0x84, 0x95, 0xd2, 0xf7, 0x16,
+	0xba, 0x0e, 0xe4, 0xa3, 0x47, 0x93, 0x28, 0x75, 0x77, 0x37, 0x4b, 0xd3, 0xe5, 0x8d, 0x44, 0xdd,
+	0x8c, 0xf2, 0x37, 0x5c, 0x5a, 0xff, 0x87, 0x04, 0x95, 0xf7, 0xa3, 0x51, 0x17, 0x8a, 0x02, 0x69,
+	0x24, 0xde, 0x5b, 0x8d, 0xef, 0x73, 0x10, 0xaf, 0x8a, 0x30, 0x8f, 0xc7, 0xef, 0x6c, 0xd6, 0x89,
+	0x7a, 0x93, 0xf9, 0x7e, 0xf5, 0xe6, 0x3e, 0x6c, 0x89, 0x02, 0x10, 0x3d, 0x4d, 0x64, 0x2d, 0xd3,
+	0xc8, 0xb6, 0xd2, 0x6a, 0x0a, 0x97, 0x46, 0x22, 0xcd, 0xb8, 0xbd, 0x9e, 0x03, 0xf9, 0xc8, 0x76,
+	0x4f, 0xea, 0x35, 0xc8, 0xb6, 0x1d, 0xca, 0x2f, 0x2c, 0xe7, 0x11, 0xd3, 0xa7, 0x6e, 0xc4, 0xa3,
+	0x98, 0xed, 0xfd, 0x3d, 0x0d, 0xc5, 0xc4, 0xb3, 0x11, 0x3d, 0x82, 0xed, 0xf6, 0xe1, 0xf1, 0x60,
+	0xa8, 0x63, 0xa3, 0xdd, 0xef, 0xed, 0x77, 0x0f, 0xd4, 0x54, 0xe5, 0xd6, 0x72, 0xa5, 0x95, 0xa7,
+	0x6b, 0xd0, 0xe6, 0x8b, 0xb0, 0x06, 0xd9, 0x6e, 0xaf, 0xa3, 0xff, 0x4e, 0x95, 0x2a, 0x37, 0x96,
+	0x2b, 0x4d, 0x4d, 0x00, 0x45, 0x0b, 0xfc, 0x04, 0x4a, 0x1c, 0x60, 0x1c, 0x1f, 0x75, 0x9a, 0x43,
+	0x5d, 0x4d, 0x57, 0x2a, 0xcb, 0x95, 0xb6, 0x7b, 0x19, 0x17, 0x72, 0x7e, 0x07, 0xf2, 0x58, 0xff,
+	0xed, 0xb1, 0x3e, 0x18, 0xaa, 0x99, 0xca, 0xee, 0x72, 0xa5, 0xa1, 0x04, 0x30, 0x4a, 0xa9, 0x7b,
+	0xa0, 0x60, 0x7d, 0x70, 0xd4, 0xef, 0x0d, 0x74, 0x55, 0xae, 0xfc, 0x60, 0xb9, 0xd2, 0xae, 0x6f,
+	0xa0, 0x42, 0x95, 0xfe, 0x14, 0x76, 0x3a, 0xfd, 0x2f, 0x7a, 0x87, 0xfd, 0x66, 0xc7, 0x38, 0xc2,
+	0xfd, 0x03, 0xac, 0x0f, 0x06, 0x6a, 0xb6, 0x52, 0x5b, 0xae, 0xb4, 0x0f, 0x12, 0xf8, 0x2b, 0xa2,
+	0xfb, 0x10, 0xe4, 0xa3, 0x6e, 0xef, 0x40, 0xcd, 0x55, 0xae, 0x2f, 0x57, 0xda, 0xb5, 0x04, 0x94,
+	0x91, 0xca, 0x22, 0x6e, 0x1f, 0xf6, 0x07, 0xba, 0x9a, 0xbf, 0x12, 0x31, 0x27, 0x7b, 0xef, 0xf7,
+	0x80, 0xae, 0x3e, 0xac, 0xd1, 0x5d, 0x90, 0x7b, 0xfd, 0x9e, 0xae, 0xa6, 0x44, 0xfc, 0x57, 0x11,
+	0x3d, 0xea, 0x12, 0x54, 0x87, 0xcc, 0xe1, 0x97, 0x8f, 0x55, 0xa9, 0xf2, 0xc3, 0xe5, 0x4a, 0xbb,
+	0x79, 0x15, 0x74, 0xf8, 0xe5, 0xe3, 0x3d, 0x0a, 0xc5, 0xe4, 0xc6, 0x75, 0x50, 0x9e, 0xea, 0xc3,
+	0x66, 0xa7, 0x39, 0x6c, 0xaa, 0x29, 0xf1, 0x4b, 0x91, 0xfb, 0x29, 0x09, 0x4c, 0x9e, 0x84, 0xb7,
+	0x20, 0xdb, 0xd3, 0x9f, 0xe9, 0x58, 0x95, 0x2a, 0x3b, 0xcb, 0x95, 0xb6, 0x15, 0x01, 0x7a, 0xe4,
+	0x94, 0x78, 0xa8, 0x0a, 0xb9, 0xe6, 0xe1, 0x17, 0xcd, 0xe7, 0x03, 0x35, 0x5d, 0x41, 0xcb, 0x95,
+	0xb6, 0x1d, 0xb9, 0x9b, 0xce, 0x99, 0xb9, 0xf0, 0xf7, 0xfe, 0x2b, 0x41, 0x29, 0xd9, 0xf0, 0x51,
+	0x15, 0xe4, 0xfd, 0xee, 0xa1, 0x1e, 0x1d, 0x97, 0xf4, 0xb1, 0x31, 0x6a, 0x40, 0xa1, 0xd3, 0xc5,
+	0x7a, 0x7b, 0xd8, 0xc7, 0xcf, 0xa3, 0x58, 0x92, 0xa0, 0x8e, 0xed, 0x71, 0x81, 0x2f, 0xd0, 0xcf,
+	0xa1, 0x34, 0x78, 0xfe, 0xf4, 0xb0, 0xdb, 0xfb, 0x8d, 0xc1, 0x77, 0x4c, 0x57, 0xee, 0x2f, 0x57,
+	0xda, 0xed, 0x0d, 0x30, 0x99, 0x79, 0x64, 0x6c, 0x06, 0xc4, 0x1a, 0x88, 0x1e, 0xc4, 0x9c, 0x8a,
+	0x84, 0xda, 0xb0, 0x13, 0x2d, 0x5d, 0x1f, 0x96, 0xa9, 0x7c, 0xb2, 0x5c, 0x69, 0x1f, 0x7d, 0xe7,
+	0xfa, 0xf8, 0x74, 0x45, 0x42, 0x77, 0x21, 0x1f, 0x6e, 0x12, 0x29, 0x29, 0xb9, 0x34, 0x5c, 0xb0,
+	0xf7, 0x17, 0x09, 0x0a, 0x71, 0xb9, 0x62, 0x84, 0xf7, 0xfa, 0x86, 0x8e, 0x71, 0x1f, 0x47, 0x0c,
+	0xc4, 0xce, 0x1e, 0xe5, 0x43, 0x74, 0x1b, 0xf2, 0x07, 0x7a, 0x4f, 0xc7, 0xdd, 0x76, 0x94, 0x18,
+	0x31, 0xe4, 0x80, 0xb8, 0xc4, 0xb3, 0xc7, 0xe8, 0x63, 0x28, 0xf5, 0xfa, 0xc6, 0xe0, 0xb8, 0xfd,
+	0x24, 0x0a, 0x9d, 0x9f, 0x9f, 0xd8, 0x6a, 0x30, 0x1f, 0xbf, 0xe0, 0x7c, 0xee, 0xb1, 0x1c, 0x7a,
+	0xd6, 0x3c, 0xec, 0x76, 0x04, 0x34, 0x53, 0x29, 0x2f, 0x57, 0xda, 0x8d, 0x18, 0x1a, 0x3e, 0x79,
+	0x18, 0x76, 0xcf, 0x82, 0xea, 0x77, 0x17, 0x26, 0xa4, 0x41, 0xae, 0x79, 0x74, 0xa4, 0xf7, 0x3a,
+	0xd1, 0xdf, 0xaf, 0x7d, 0xcd, 0xd9, 0x8c, 0xb8, 0x16, 0x43, 0xec, 0xf7, 0xf1, 0x81, 0x3e, 0x54,
+	0xa5, 0xcb, 0x88, 0x7d, 0xca, 0x1e, 0x00, 0xad, 0xc6, 0xeb, 0x6f, 0xaa, 0xa9, 0x37, 0xdf, 0x54,
+	0x53, 0xaf, 0x2f, 0xaa, 0xd2, 0x9b, 0x8b, 0xaa, 0xf4, 0xef, 0x8b, 0x6a, 0xea, 0xdb, 0x8b, 0xaa,
+	0xf4, 0xc7, 0xb7, 0xd5, 0xd4, 0xd7, 0x6f, 0xab, 0xd2, 0x9b, 0xb7, 0xd5, 0xd4, 0x3f, 0xdf, 0x56,
+	0x53, 0xa3, 0x1c, 0x2f, 0x6a, 0x9f, 0xff, 0x6f, 0x00, 0xad, 0x8a, 0xa6, 0x29, 0xed, 0x0f, 0x00,
+	0x00,
 }
@@ -20,9 +20,12 @@ type HelloIntf interface {
 // The HelloResult is the non version specific interpretation of the other
 // side's Hello message.
 type HelloResult struct {
-	DeviceName    string
-	ClientName    string
-	ClientVersion string
+	DeviceName           string
+	ClientName           string
+	ClientVersion        string
+	NumConnections       int32 // how many connections it wants with us, zero for one
+	Additional           bool  // whether it dialed this as an additional connection
+	ClusterConfigUpdates bool  // whether it takes further cluster configs as updates to the addresses
 }
 
 var (
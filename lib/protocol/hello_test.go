@@ -14,9 +14,11 @@ func TestVersion14Hello(t *testing.T) {
 	// Tests that we can send and receive a version 0.14 hello message.
 
 	expected := Hello{
-		DeviceName:    "test device",
-		ClientName:    "syncthing",
-		ClientVersion: "v0.14.5",
+		DeviceName:     "test device",
+		ClientName:     "syncthing",
+		ClientVersion:  "v0.14.5",
+		NumConnections: 2,
+		Additional:     true,
 	}
 	msgBuf, err := expected.Marshal()
 	if err != nil {
@@ -55,6 +57,9 @@ func TestVersion14Hello(t *testing.T) {
 	if res.DeviceName != expected.DeviceName {
 		t.Errorf("incorrect DeviceName %q != expected %q", res.DeviceName, expected.DeviceName)
 	}
+	if res.NumConnections != expected.NumConnections || res.Additional != expected.Additional {
+		t.Errorf("incorrect NumConnections %d, Additional %v != expected %d, %v", res.NumConnections, res.Additional, expected.NumConnections, expected.Additional)
+	}
 }
 
 func TestOldHelloMsgs(t *testing.T) {
@@ -17,11 +17,17 @@ import (
 )
 
 func GetInvitationFromRelay(uri *url.URL, id syncthingprotocol.DeviceID, certs []tls.Certificate, timeout time.Duration) (protocol.SessionInvitation, error) {
+	return GetInvitationFromRelayProxy("", uri, id, certs, timeout)
+}
+
+// GetInvitationFromRelayProxy is GetInvitationFromRelay, connecting to the
+// relay through the given proxy, as for dialer.DialTimeoutProxy.
+func GetInvitationFromRelayProxy(proxy string, uri *url.URL, id syncthingprotocol.DeviceID, certs []tls.Certificate, timeout time.Duration) (protocol.SessionInvitation, error) {
 	if uri.Scheme != "relay" {
 		return protocol.SessionInvitation{}, fmt.Errorf("Unsupported relay scheme: %v", uri.Scheme)
 	}
 
-	rconn, err := dialer.DialTimeout("tcp", uri.Host, timeout)
+	rconn, err := dialer.DialTimeoutProxy(proxy, "tcp", uri.Host, timeout)
 	if err != nil {
 		return protocol.SessionInvitation{}, err
 	}
@@ -64,9 +70,15 @@ func GetInvitationFromRelay(uri *url.URL, id syncthingprotocol.DeviceID, certs [
 }
 
 func JoinSession(invitation protocol.SessionInvitation) (net.Conn, error) {
+	return JoinSessionProxy("", invitation)
+}
+
+// JoinSessionProxy is JoinSession, connecting to the relay through the
+// given proxy, as for dialer.DialTimeoutProxy.
+func JoinSessionProxy(proxy string, invitation protocol.SessionInvitation) (net.Conn, error) {
 	addr := net.JoinHostPort(net.IP(invitation.Address).String(), strconv.Itoa(int(invitation.Port)))
 
-	conn, err := dialer.Dial("tcp", addr)
+	conn, err := dialer.DialTimeoutProxy(proxy, "tcp", addr, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,59 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// ErrFileModified is returned by VerifyFile when the file on disk doesn't
+// have the size and modification time of the given file, i.e. it has been
+// modified since it was last scanned.
+var ErrFileModified = errors.New("file modified since last scan")
+
+// VerifyFile rehashes the given file on disk and returns the indexes of the
+// blocks that don't match the hashes in file.Blocks. Mismatching blocks of a
+// file with unchanged size and modification time mean that the data has been
+// corrupted on disk.
+func VerifyFile(ctx context.Context, fs fs.Filesystem, file protocol.FileInfo, counter Counter) ([]int, error) {
+	info, err := fs.Lstat(file.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsRegular() || info.Size() != file.Size || !info.ModTime().Equal(file.ModTime()) {
+		return nil, ErrFileModified
+	}
+
+	blocks, err := HashFile(ctx, fs, file.Name, file.BlockSize(), counter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// HashFile verified that the file didn't change while hashing, but it
+	// may have changed between the stat above and opening it.
+	if info, err := fs.Lstat(file.Name); err != nil {
+		return nil, err
+	} else if info.Size() != file.Size || !info.ModTime().Equal(file.ModTime()) {
+		return nil, ErrFileModified
+	}
+
+	var corrupted []int
+	for i, block := range blocks {
+		if i >= len(file.Blocks) || !bytes.Equal(block.Hash, file.Blocks[i].Hash) {
+			corrupted = append(corrupted, i)
+		}
+	}
+	for i := len(blocks); i < len(file.Blocks); i++ {
+		corrupted = append(corrupted, i)
+	}
+	return corrupted, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package scanner
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestVerifyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syncthing-verify-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := make([]byte, 3*protocol.MinBlockSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	path := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1234567890, 0)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	testFs := fs.NewFilesystem(fs.FilesystemTypeBasic, dir)
+	blocks, err := HashFile(context.TODO(), testFs, "file", protocol.MinBlockSize, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := protocol.FileInfo{
+		Name:         "file",
+		Size:         int64(len(data)),
+		ModifiedS:    mtime.Unix(),
+		RawBlockSize: protocol.MinBlockSize,
+		Blocks:       blocks,
+	}
+
+	if corrupted, err := VerifyFile(context.TODO(), testFs, file, nil); err != nil || len(corrupted) != 0 {
+		t.Fatalf("intact file: corrupted %v, err %v", corrupted, err)
+	}
+
+	// Flip a bit in the second block, keeping size and modification time.
+	data[protocol.MinBlockSize+10] ^= 1
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if corrupted, err := VerifyFile(context.TODO(), testFs, file, nil); err != nil || len(corrupted) != 1 || corrupted[0] != 1 {
+		t.Fatalf("corrupted file: corrupted %v, err %v", corrupted, err)
+	}
+
+	// A changed modification time is a modification, not corruption.
+	if err := os.Chtimes(path, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(context.TODO(), testFs, file, nil); err != ErrFileModified {
+		t.Fatalf("modified file: err %v, expected %v", err, ErrFileModified)
+	}
+}
@@ -13,8 +13,9 @@ import (
 )
 
 type FolderStatistics struct {
-	LastFile LastFile  `json:"lastFile"`
-	LastScan time.Time `json:"lastScan"`
+	LastFile  LastFile  `json:"lastFile"`
+	LastScan  time.Time `json:"lastScan"`
+	LastScrub time.Time `json:"lastScrub"`
 }
 
 type FolderStatisticsReference struct {
@@ -71,9 +72,22 @@ func (s *FolderStatisticsReference) GetLastScanTime() time.Time {
 	return lastScan
 }
 
+func (s *FolderStatisticsReference) ScrubCompleted() {
+	s.ns.PutTime("lastScrub", time.Now())
+}
+
+func (s *FolderStatisticsReference) GetLastScrubTime() time.Time {
+	lastScrub, ok := s.ns.Time("lastScrub")
+	if !ok {
+		return time.Time{}
+	}
+	return lastScrub
+}
+
 func (s *FolderStatisticsReference) GetStatistics() FolderStatistics {
 	return FolderStatistics{
-		LastFile: s.GetLastFile(),
-		LastScan: s.GetLastScanTime(),
+		LastFile:  s.GetLastFile(),
+		LastScan:  s.GetLastScanTime(),
+		LastScrub: s.GetLastScrubTime(),
 	}
 }
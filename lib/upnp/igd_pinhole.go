@@ -0,0 +1,136 @@
+// Copyright (C) 2019 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package upnp
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/nat"
+)
+
+// The longest lease allowed for a pinhole, and the one we use when asked
+// for a permanent mapping, which pinholes don't have.
+const maxPinholeLease = 24 * time.Hour
+
+// An IGDPinholeService is a WANIPv6FirewallControl service of an IGD, which
+// opens pinholes for incoming IPv6 connections to our global address.
+// Pinholes don't translate the port or the address, so the external address
+// is the internal one. Like port mappings, pinholes aren't closed when no
+// longer needed but run out with their lease.
+type IGDPinholeService struct {
+	IGDService
+	InternalIP net.IP
+}
+
+// AddPortMapping opens a pinhole for the internal port, from anywhere. The
+// external port is ignored, as it's the same as the internal one. An IGD
+// that already has the same pinhole updates its lease time, so this is
+// also how pinholes are renewed.
+func (s *IGDPinholeService) AddPortMapping(protocol nat.Protocol, internalPort, externalPort int, description string, duration time.Duration) (int, error) {
+	if duration <= 0 || duration > maxPinholeLease {
+		duration = maxPinholeLease
+	}
+
+	var protocolNumber int
+	switch protocol {
+	case nat.TCP:
+		protocolNumber = 6
+	case nat.UDP:
+		protocolNumber = 17
+	default:
+		return 0, fmt.Errorf("unsupported protocol %s", protocol)
+	}
+
+	tpl := `<u:AddPinhole xmlns:u="%s">
+	<RemoteHost></RemoteHost>
+	<RemotePort>0</RemotePort>
+	<InternalClient>%s</InternalClient>
+	<InternalPort>%d</InternalPort>
+	<Protocol>%d</Protocol>
+	<LeaseTime>%d</LeaseTime>
+	</u:AddPinhole>`
+	body := fmt.Sprintf(tpl, s.URN, s.InternalIP, internalPort, protocolNumber, duration/time.Second)
+
+	response, err := soapRequest(s.URL, s.URN, "AddPinhole", body)
+	if err != nil {
+		envelope := &soapErrorResponse{}
+		if unmarshalErr := xml.Unmarshal(response, envelope); unmarshalErr == nil && envelope.ErrorCode != 0 {
+			return 0, fmt.Errorf("AddPinhole: %d %s", envelope.ErrorCode, envelope.ErrorDescription)
+		}
+		return 0, err
+	}
+
+	envelope := &soapAddPinholeResponseEnvelope{}
+	if err := xml.Unmarshal(response, envelope); err != nil {
+		return 0, err
+	}
+	if envelope.Body.AddPinholeResponse.UniqueID == "" {
+		return 0, errors.New("AddPinhole: no pinhole ID in response")
+	}
+	l.Debugln("Opened pinhole", envelope.Body.AddPinholeResponse.UniqueID, "for", net.JoinHostPort(s.InternalIP.String(), fmt.Sprint(internalPort)), "on", s.ID())
+
+	return internalPort, nil
+}
+
+// GetExternalIPAddress returns the address pinholes are opened for.
+func (s *IGDPinholeService) GetExternalIPAddress() (net.IP, error) {
+	return s.InternalIP, nil
+}
+
+// GetLocalIPAddress returns the address pinholes are opened for, which is
+// what a mapping has to be listening on.
+func (s *IGDPinholeService) GetLocalIPAddress() net.IP {
+	return s.InternalIP
+}
+
+type soapAddPinholeResponseEnvelope struct {
+	XMLName xml.Name
+	Body    soapAddPinholeResponseBody `xml:"Body"`
+}
+
+type soapAddPinholeResponseBody struct {
+	XMLName            xml.Name
+	AddPinholeResponse addPinholeResponse `xml:"AddPinholeResponse"`
+}
+
+type addPinholeResponse struct {
+	UniqueID string `xml:"UniqueID"`
+}
+
+// globalIPv6 returns a global IPv6 address on the interface with the given
+// address, if there is one.
+func globalIPv6(ip net.IP) net.IP {
+	intfs, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	for _, intf := range intfs {
+		addrs, err := intf.Addrs()
+		if err != nil {
+			continue
+		}
+		if hasIP(addrs, ip) {
+			return nat.GlobalIPv6Among(addrs)
+		}
+	}
+	return nil
+}
+
+// hasIP returns whether the given address is among the addresses of an
+// interface.
+func hasIP(addrs []net.Addr, ip net.IP) bool {
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
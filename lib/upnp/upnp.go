@@ -198,14 +198,13 @@ USER-AGENT: syncthing/1.0
 			continue
 		}
 		for _, igd := range igds {
-			igd := igd // Copy before sending pointer to the channel.
-			results <- &igd
+			results <- igd
 		}
 	}
 	l.Debugln("Discovery for device type", deviceType, "on", intf.Name, "finished.")
 }
 
-func parseResponse(deviceType string, resp []byte) ([]IGDService, error) {
+func parseResponse(deviceType string, resp []byte) ([]nat.Device, error) {
 	l.Debugln("Handling UPnP response:\n\n" + string(resp))
 
 	reader := bufio.NewReader(bytes.NewBuffer(resp))
@@ -262,7 +261,11 @@ func parseResponse(deviceType string, resp []byte) ([]IGDService, error) {
 		return nil, err
 	}
 
-	services, err := getServiceDescriptions(deviceUUID, localIPAddress, deviceDescriptionLocation, upnpRoot.Device)
+	// Pinholes are opened for our global IPv6 address, on the same
+	// interface.
+	globalIPAddress := globalIPv6(localIPAddress)
+
+	services, err := getServiceDescriptions(deviceUUID, localIPAddress, globalIPAddress, deviceDescriptionLocation, upnpRoot.Device)
 	if err != nil {
 		return nil, err
 	}
@@ -305,8 +308,8 @@ func getChildServices(d upnpDevice, serviceType string) []upnpService {
 	return result
 }
 
-func getServiceDescriptions(deviceUUID string, localIPAddress net.IP, rootURL string, device upnpDevice) ([]IGDService, error) {
-	var result []IGDService
+func getServiceDescriptions(deviceUUID string, localIPAddress, globalIPAddress net.IP, rootURL string, device upnpDevice) ([]nat.Device, error) {
+	var result []nat.Device
 
 	if device.DeviceType == "urn:schemas-upnp-org:device:InternetGatewayDevice:1" {
 		descriptions := getIGDServices(deviceUUID, localIPAddress, rootURL, device,
@@ -314,14 +317,34 @@ func getServiceDescriptions(deviceUUID string, localIPAddress net.IP, rootURL st
 			"urn:schemas-upnp-org:device:WANConnectionDevice:1",
 			[]string{"urn:schemas-upnp-org:service:WANIPConnection:1", "urn:schemas-upnp-org:service:WANPPPConnection:1"})
 
-		result = append(result, descriptions...)
+		for i := range descriptions {
+			result = append(result, &descriptions[i])
+		}
 	} else if device.DeviceType == "urn:schemas-upnp-org:device:InternetGatewayDevice:2" {
 		descriptions := getIGDServices(deviceUUID, localIPAddress, rootURL, device,
 			"urn:schemas-upnp-org:device:WANDevice:2",
 			"urn:schemas-upnp-org:device:WANConnectionDevice:2",
 			[]string{"urn:schemas-upnp-org:service:WANIPConnection:2", "urn:schemas-upnp-org:service:WANPPPConnection:2"})
 
-		result = append(result, descriptions...)
+		for i := range descriptions {
+			result = append(result, &descriptions[i])
+		}
+
+		// IPv6 isn't translated, but the firewall may let us open a
+		// pinhole for the port.
+		if globalIPAddress != nil {
+			descriptions = getIGDServices(deviceUUID, localIPAddress, rootURL, device,
+				"urn:schemas-upnp-org:device:WANDevice:2",
+				"urn:schemas-upnp-org:device:WANConnectionDevice:2",
+				[]string{"urn:schemas-upnp-org:service:WANIPv6FirewallControl:1"})
+
+			for _, service := range descriptions {
+				result = append(result, &IGDPinholeService{
+					IGDService: service,
+					InternalIP: globalIPAddress,
+				})
+			}
+		}
 	} else {
 		return result, errors.New("[" + rootURL + "] Malformed root device description: not an InternetGatewayDevice.")
 	}
@@ -8,8 +8,16 @@ package upnp
 
 import (
 	"encoding/xml"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/nat"
 )
 
 func TestExternalIPParsing(t *testing.T) {
@@ -81,3 +89,126 @@ func TestControlURLParsing(t *testing.T) {
 		t.Error("URL normalization of", subject, "failed; expected", expected, "got", u.String())
 	}
 }
+
+func TestPinholeServiceDescriptions(t *testing.T) {
+	description := []byte(`<root xmlns="urn:schemas-upnp-org:device-1-0">
+	<device>
+		<deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:2</deviceType>
+		<deviceList>
+			<device>
+				<deviceType>urn:schemas-upnp-org:device:WANDevice:2</deviceType>
+				<deviceList>
+					<device>
+						<deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:2</deviceType>
+						<serviceList>
+							<service>
+								<serviceType>urn:schemas-upnp-org:service:WANIPConnection:2</serviceType>
+								<serviceId>urn:upnp-org:serviceId:WANIPConn1</serviceId>
+								<controlURL>/ctl/IPConn</controlURL>
+							</service>
+							<service>
+								<serviceType>urn:schemas-upnp-org:service:WANIPv6FirewallControl:1</serviceType>
+								<serviceId>urn:upnp-org:serviceId:WANIPv6FC1</serviceId>
+								<controlURL>/ctl/IP6FCtl</controlURL>
+							</service>
+						</serviceList>
+					</device>
+				</deviceList>
+			</device>
+		</deviceList>
+	</device>
+</root>`)
+
+	var root upnpRoot
+	if err := xml.Unmarshal(description, &root); err != nil {
+		t.Fatal(err)
+	}
+
+	localIP := net.ParseIP("192.168.0.10")
+	globalIP := net.ParseIP("2001:db8::10")
+	services, err := getServiceDescriptions("uuid", localIP, globalIP, "http://192.168.0.1:5000/rootDesc.xml", root.Device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 {
+		t.Fatal("Unexpected services", services)
+	}
+	pinhole, ok := services[1].(*IGDPinholeService)
+	if !ok {
+		t.Fatalf("Expected a pinhole service, not %T", services[1])
+	}
+	if pinhole.URL != "http://192.168.0.1:5000/ctl/IP6FCtl" || !pinhole.GetLocalIPAddress().Equal(globalIP) {
+		t.Errorf("Unexpected pinhole service %+v", pinhole)
+	}
+
+	// Without a global IPv6 address there's nothing to open pinholes for.
+	services, err = getServiceDescriptions("uuid", localIP, nil, "http://192.168.0.1:5000/rootDesc.xml", root.Device)
+	if err != nil || len(services) != 1 {
+		t.Error("Unexpected services", services, err)
+	}
+}
+
+func TestAddPinhole(t *testing.T) {
+	var lastAction, lastBody string
+	igd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := ioutil.ReadAll(r.Body)
+		lastAction = r.Header.Get("SOAPAction")
+		lastBody = string(bs)
+		w.Write([]byte(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+		<s:Body>
+			<u:AddPinholeResponse xmlns:u="urn:schemas-upnp-org:service:WANIPv6FirewallControl:1">
+			<UniqueID>42</UniqueID>
+			</u:AddPinholeResponse>
+		</s:Body>
+		</s:Envelope>`))
+	}))
+	defer igd.Close()
+
+	s := &IGDPinholeService{
+		IGDService: IGDService{
+			URL: igd.URL,
+			URN: "urn:schemas-upnp-org:service:WANIPv6FirewallControl:1",
+		},
+		InternalIP: net.ParseIP("2001:db8::10"),
+	}
+	port, err := s.AddPortMapping(nat.TCP, 22000, 31337, "syncthing-31337", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 22000 {
+		t.Error("Pinholes should keep the port, not", port)
+	}
+	if lastAction != `"urn:schemas-upnp-org:service:WANIPv6FirewallControl:1#AddPinhole"` {
+		t.Error("Unexpected action", lastAction)
+	}
+	for _, part := range []string{"<InternalClient>2001:db8::10</InternalClient>", "<InternalPort>22000</InternalPort>", "<Protocol>6</Protocol>", "<LeaseTime>86400</LeaseTime>"} {
+		if !strings.Contains(lastBody, part) {
+			t.Errorf("Request lacks %s:\n%s", part, lastBody)
+		}
+	}
+	if ip, _ := s.GetExternalIPAddress(); !ip.Equal(s.InternalIP) {
+		t.Error("Unexpected external address", ip)
+	}
+
+	if _, err := s.AddPortMapping(nat.TCP, 22000, 0, "", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lastBody, "<LeaseTime>3600</LeaseTime>") {
+		t.Errorf("Unexpected lease time:\n%s", lastBody)
+	}
+}
+
+func TestHasIP(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("192.168.0.10")},
+		&net.IPNet{IP: net.ParseIP("fe80::10")},
+		&net.IPNet{IP: net.ParseIP("fd00::10")},
+		&net.IPNet{IP: net.ParseIP("2001:db8::10")},
+	}
+	if !hasIP(addrs, net.ParseIP("192.168.0.10")) {
+		t.Error("Address of the interface not found")
+	}
+	if hasIP(addrs, net.ParseIP("192.168.1.10")) {
+		t.Error("Address of another interface found")
+	}
+}